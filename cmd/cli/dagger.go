@@ -4,27 +4,42 @@ import (
 	"fmt"
 	"github.com/excoriate/stiletto/internal/core/entities"
 	"github.com/excoriate/stiletto/internal/core/job"
+	"github.com/excoriate/stiletto/internal/core/manifest"
 	"github.com/excoriate/stiletto/internal/core/runner"
-	"github.com/excoriate/stiletto/internal/core/scheduler"
+	"github.com/excoriate/stiletto/internal/core/selector"
 	"github.com/excoriate/stiletto/internal/core/specs"
 	"github.com/excoriate/stiletto/internal/tui"
 	"github.com/excoriate/stiletto/pkg/clients"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"strings"
 )
 
 var (
 	taskFiles []string
+	jobFiles  []string
+
+	// taskDirs are the directories whose manifests (tasks, and jobs) are run.
+	taskDirs []string
 )
 
 var DaggerCMD = &cobra.Command{
 	Version: "v0.0.1",
 	Use:     "dagger",
 	Long: `The 'dagger' command is an special type of 'Job' that runs tasks on top of
-Dagger (Container).`,
+Dagger (Container). The task and job files can be paths, or glob patterns (where '**'
+matches any number of directories). If neither files nor directories are passed, the
+manifests of the '.stiletto' directory (the closest one to the current directory, up to
+the root of the repository) are run. The images pinned in the lockfile (see 'stiletto lock')
+are run by their digest.`,
 	Example: `
-stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml`,
+stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml
+stiletto job dagger --task-files='ci/**/*.yml'
+stiletto job dagger --task-dir=.stiletto/
+stiletto job dagger --task-dir=.stiletto/ --labels=stage=plan,team=infra --exclude='*-destroy'
+stiletto job dagger --job-files=../../stiletto/jobs/terragrunt.yml
+stiletto job dagger --task-dir=.stiletto/ --frozen`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// CLI UX utilities.
 		cliLog := tui.NewTUIMessage()
@@ -35,15 +50,6 @@ stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml`,
 		mountDir := viper.GetString("mountDir")
 		showEnvVars := viper.GetBool("showEnvVars")
 
-		// Task files/manifests to mount.
-		taskFilesCfg := viper.GetStringSlice("taskFiles")
-
-		if len(taskFilesCfg) == 0 {
-			cliLog.ShowError("", "No task files (specs, or manifests) were provided",
-				nil)
-			os.Exit(1)
-		}
-
 		// New client builder.
 		c := clients.NewClient(entities.ClientTypeCli)
 
@@ -55,67 +61,56 @@ stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml`,
 			os.Exit(1)
 		}
 
+		// The tasks and jobs to run, out of the ones loaded.
+		s, err := selectorFromFlags("job")
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		// The lockfile that pins the images of the tasks, if any.
+		lock, err := lockfileFromFlags(i, "job")
+		if err != nil {
+			cliLog.ShowError("LOCK", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		// Task and job files/manifests to mount.
+		taskFilesCfg, jobFilesCfg, err := daggerManifestFiles(i, cliLog)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
 		cliUX.ShowTitleAndDescription("STILETTO",
 			"Automated pipelines, "+
 				"workflows and whatever can be containerized in your own laptop 👨🏻‍💻("+
 				"powered by Dagger.IO)")
 
-		var tasksConvertedFromManifest []specs.ConvertedTask
-		for _, taskFile := range taskFilesCfg {
-			manifestBuilder, err := specs.NewTaskSpecBuilder(specs.NewOpts{
-				ManifestType: entities.ManifestTypeTask,
-				ManifestFile: taskFile,
-				Client:       i,
-			})
-
-			if err != nil {
-				cliLog.ShowError("", err.Error(), nil)
-				os.Exit(1)
-			}
-
-			// Task manifest, ready to be transformed into a valid Dagger job (task).
-			taskManifest, err := manifestBuilder.
-				WithCompiledManifestStructure().
-				WithExtractedManifestContent().
-				WithCompiledManifestFunctions().
-				WithConstructedSpec().
-				WithStrictDeepValidation().
-				Build()
-
-			if err != nil {
-				cliLog.ShowError("", err.Error(), nil)
-				os.Exit(1)
-			}
-
-			convertedTask, err := taskManifest.Convert()
-
-			if err != nil {
-				cliLog.ShowError("", err.Error(), nil)
-				os.Exit(1)
-			}
-
-			if workDir != "" && convertedTask.Task.WorkDir != "" {
-				cliLog.ShowWarning("", fmt.Sprintf("The workDir '%s' was set in the CLI, "+
-					"but also set in the manifest file '%s'. The CLI value will be used.", workDir, taskFile))
-
-				convertedTask.Task.WorkDir = workDir
-			}
-
-			if mountDir != "" && convertedTask.Task.MountDir != "" {
-				cliLog.ShowWarning("", fmt.Sprintf("The mountDir '%s' was set in the CLI, "+
-					"but also set in the manifest file '%s'. The CLI value will be used.", mountDir, taskFile))
-
-				convertedTask.Task.MountDir = mountDir
-			}
+		tasksConvertedFromManifest, err := loadTaskManifests(i, taskFilesCfg)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
 
-			tasksConvertedFromManifest = append(tasksConvertedFromManifest, *convertedTask)
+		jobsConvertedFromManifest, err := loadJobManifests(i, jobFilesCfg)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
 		}
 
-		daggerClient := job.NewDaggerClient(i)
+		tasksConvertedFromManifest, jobsConvertedFromManifest, err = selectTasksAndJobs(cliLog, s,
+			tasksConvertedFromManifest, jobsConvertedFromManifest)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
 
 		var jobs []entities.Job
 		for _, task := range tasksConvertedFromManifest {
-			j, err := daggerClient.WithJob(job.NewArgs{
+			overrideTaskDirs(cliLog, task.Task, workDir, mountDir)
+
+			j, err := job.NewDaggerClient(i).WithJob(job.NewArgs{
 				Name: fmt.Sprintf("job-task-%s", task.Task.Name),
 			}, job.EnvVarsOptions{}).WithTasks([]job.TaskNewArgs{*task.Task},
 				*task.TaskEnvCfg).Build()
@@ -128,29 +123,29 @@ stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml`,
 			jobs = append(jobs, *j)
 		}
 
-		// Running the Dagger job.
-		scheduler := scheduler.NewScheduler()
+		for _, convertedJob := range jobsConvertedFromManifest {
+			j, err := buildJob(i, cliLog, convertedJob, workDir, mountDir)
+			if err != nil {
+				cliLog.ShowError("JOB-ERROR", err.Error(), nil)
+				os.Exit(1)
+			}
 
-		// Create a runner with the jobs to run.
-		scheduleJobs, err := scheduler.WithClient(i).WithJobsToRun(jobs).WithDaggerEngine().Build()
-		if err != nil {
-			cliLog.ShowError("SCHEDULER-ERROR", err.Error(), nil)
-			os.Exit(1)
+			jobs = append(jobs, *j)
 		}
 
-		// Run the jobs.
-		runnerBuilder := runner.NewRunnerDagger(scheduleJobs)
-		runner, err := runnerBuilder.WithDaggerClient(nil).WithOptions(runner.DaggerRunnerOptions{
-			ShowEnvVars: showEnvVars,
-		}).Build()
-
-		if err != nil {
-			cliLog.ShowError("RUNNER-ERROR", err.Error(), nil)
-			os.Exit(1)
+		frozen := viper.GetBool("jobFrozen")
+		if !frozen {
+			showUnpinnedImages(cliLog, lock, jobs)
 		}
 
-		err = runner.RunInDagger(jobs)
-		if err != nil {
+		// Jobs run from task or job files don't depend on each other, however
+		// a failing job stops the whole run.
+		if err := runJobsInDagger(i, jobs, false, runner.DaggerRunnerOptions{
+			ShowEnvVars: showEnvVars,
+			FailFast:    true,
+			Lockfile:    lock,
+			Frozen:      frozen,
+		}); err != nil {
 			cliLog.ShowError("RUNNER-ERROR", err.Error(), nil)
 			os.Exit(1)
 		}
@@ -159,12 +154,121 @@ stiletto job dagger --task-files=../../stiletto/tasks/terragrunt-plan.yml`,
 
 func addFlagsToDaggerCMD() {
 	DaggerCMD.Flags().StringSliceVarP(&taskFiles, "task-files",
-		"", []string{}, "The tasks  in .yml format that'll be executed")
+		"", []string{}, "The tasks in .yml format that'll be executed. Glob patterns are supported")
+
+	DaggerCMD.Flags().StringSliceVarP(&jobFiles, "job-files",
+		"", []string{}, "The jobs in .yml format that'll be executed, each of them with its own tasks")
+
+	DaggerCMD.Flags().StringSliceVarP(&taskDirs, "task-dir",
+		"", []string{}, "The directories whose manifests (tasks, and jobs) will be executed")
 
 	_ = viper.BindPFlag("taskFiles", DaggerCMD.Flags().Lookup("task-files"))
+	_ = viper.BindPFlag("jobFiles", DaggerCMD.Flags().Lookup("job-files"))
+	_ = viper.BindPFlag("taskDirs", DaggerCMD.Flags().Lookup("task-dir"))
+
+	addSelectionFlags(DaggerCMD, "job")
+	addLockfileFlags(DaggerCMD, "job", "Fail if the image of a task isn't pinned in the lockfile")
+}
+
+// selectTasksAndJobs keeps the tasks and jobs selected by their name, or their labels,
+// through the selection flags. Each job is selected (or not) as a whole.
+func selectTasksAndJobs(cliLog tui.UXMessenger, s *selector.Selector, tasks []specs.ConvertedTask,
+	jobs []specs.ConvertedJob) ([]specs.ConvertedTask, []specs.ConvertedJob, error) {
+	if s.IsEmpty() {
+		return tasks, jobs, nil
+	}
+
+	var selectedTasks []specs.ConvertedTask
+	for _, task := range tasks {
+		if s.Matches(task.Task.Name, task.Labels) {
+			selectedTasks = append(selectedTasks, task)
+		}
+	}
+
+	var selectedJobs []specs.ConvertedJob
+	for _, convertedJob := range jobs {
+		if s.Matches(convertedJob.Job.Name, convertedJob.Labels) {
+			selectedJobs = append(selectedJobs, convertedJob)
+		}
+	}
+
+	if len(selectedTasks) == 0 && len(selectedJobs) == 0 {
+		return nil, nil, fmt.Errorf("no task or job matches the selection (%s)", s)
+	}
+
+	cliLog.ShowInfo("SELECTION", fmt.Sprintf("Selected %d of %d task(s), and %d of %d job(s), matching %s",
+		len(selectedTasks), len(tasks), len(selectedJobs), len(jobs), s))
+
+	return selectedTasks, selectedJobs, nil
+}
+
+// daggerManifestFiles returns the task and job files to run. The manifests of the task
+// directories (or of the '.stiletto' directory, if nothing is passed) are grouped by
+// their kind; the workflows are skipped, since they're run by 'stiletto workflow dagger'.
+func daggerManifestFiles(c *entities.Client, cliLog tui.UXMessenger) ([]string, []string, error) {
+	taskFilesCfg, err := resolveManifestFiles(c, viper.GetStringSlice("taskFiles"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobFilesCfg, err := resolveManifestFiles(c, viper.GetStringSlice("jobFiles"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taskDirsCfg := viper.GetStringSlice("taskDirs")
+
+	if len(taskFilesCfg) == 0 && len(jobFilesCfg) == 0 && len(taskDirsCfg) == 0 {
+		defaultDir := defaultManifestDir(c)
+		if defaultDir == "" {
+			return nil, nil, fmt.Errorf("no task or job files (specs, or manifests) were provided, "+
+				"and no '%s' directory was found", manifest.DefaultDir)
+		}
+
+		cliLog.ShowInfo("MANIFEST", fmt.Sprintf("Running the manifests of %s", defaultDir))
+		taskDirsCfg = []string{defaultDir}
+	}
+
+	dirFiles, err := resolveManifestDirs(c, taskDirsCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dirFilesByType, err := manifestFilesByType(dirFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, workflowFile := range dirFilesByType[entities.ManifestTypeWorkflow] {
+		cliLog.ShowWarning("MANIFEST", fmt.Sprintf("The workflow %s is skipped. "+
+			"Run it with 'stiletto workflow dagger'", workflowFile))
+	}
+
+	taskFilesCfg = append(taskFilesCfg, dirFilesByType[entities.ManifestTypeTask]...)
+	jobFilesCfg = append(jobFilesCfg, dirFilesByType[entities.ManifestTypeJob]...)
+
+	if len(taskFilesCfg) == 0 && len(jobFilesCfg) == 0 {
+		return nil, nil, fmt.Errorf("no task or job files (specs, or manifests) were found in %s",
+			strings.Join(taskDirsCfg, ", "))
+	}
+
+	return taskFilesCfg, jobFilesCfg, nil
+}
+
+// overrideTaskDirs overrides the directories of a task with the ones passed through the CLI.
+func overrideTaskDirs(cliLog tui.UXMessenger, task *job.TaskNewArgs, workDir, mountDir string) {
+	if workDir != "" && task.WorkDir != "" {
+		cliLog.ShowWarning("", fmt.Sprintf("The workDir '%s' was set in the CLI, "+
+			"but also set in the manifest of the task '%s'. The CLI value will be used.", workDir, task.Name))
+
+		task.WorkDir = workDir
+	}
+
+	if mountDir != "" && task.MountDir != "" {
+		cliLog.ShowWarning("", fmt.Sprintf("The mountDir '%s' was set in the CLI, "+
+			"but also set in the manifest of the task '%s'. The CLI value will be used.", mountDir, task.Name))
 
-	if err := DaggerCMD.MarkFlagRequired("task-files"); err != nil {
-		panic(err)
+		task.MountDir = mountDir
 	}
 }
 
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"os"
+	"path/filepath"
+)
+
+// resolveManifestFiles resolves the manifest files passed through the CLI, which can be
+// paths, glob patterns (E.g.: 'ci/**/*.yml') or directories, relative to the current one.
+// The references of a source (E.g.: 'https://...', or 'git+file://...') are resolved
+// into the local files they're materialized into.
+func resolveManifestFiles(c *entities.Client, paths []string) ([]string, error) {
+	resolvedManifests, err := resolveManifests(c, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifestFiles []string
+	for _, resolved := range resolvedManifests {
+		manifestFiles = append(manifestFiles, resolved.Path)
+	}
+
+	return manifestFiles, nil
+}
+
+// resolveManifests is like resolveManifestFiles, but it keeps the reference of each
+// manifest file. E.g.: to report the URL a manifest was loaded from.
+func resolveManifests(c *entities.Client, paths []string) ([]*manifest.Resolved, error) {
+	manifestFiles, err := manifest.FindFiles(c.CfgDir.BaseDir, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources *manifest.Sources
+	var resolvedManifests []*manifest.Resolved
+	for _, manifestFile := range manifestFiles {
+		if !manifest.IsRemote(manifestFile) {
+			resolvedManifests = append(resolvedManifests, &manifest.Resolved{Ref: manifestFile, Path: manifestFile})
+			continue
+		}
+
+		if sources == nil {
+			sources = manifest.NewSources(manifest.DefaultCacheDir())
+		}
+
+		resolved, err := sources.Resolve(manifestFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if !resolved.Pinned {
+			hint := "Pin it to a tag, or a commit, with '?ref=<tag or commit>'"
+			if resolved.Checksum != "" {
+				hint = fmt.Sprintf("Pin its current content with '#sha256=%s'", resolved.Checksum)
+			}
+
+			tui.NewTUIMessage().ShowWarning("MANIFEST", fmt.Sprintf("The manifest %s isn't pinned, "+
+				"so its content can change. %s", manifestFile, hint))
+		}
+
+		resolvedManifests = append(resolvedManifests, resolved)
+	}
+
+	return resolvedManifests, nil
+}
+
+// resolveManifestDirs resolves the manifest files of the directories passed through
+// the CLI. Unlike resolveManifestFiles, every path should be a directory.
+func resolveManifestDirs(c *entities.Client, dirs []string) ([]string, error) {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("the manifests directory %s does not exist, or it's not a directory", dir)
+		}
+	}
+
+	return manifest.FindFiles(c.CfgDir.BaseDir, dirs)
+}
+
+// defaultManifestDir returns the conventional '.stiletto' directory closest to the
+// current one, walking up to the root of the git repository. It's relative to the
+// current directory, and empty if there's none.
+func defaultManifestDir(c *entities.Client) string {
+	defaultDir := manifest.FindDefaultDir(c.CfgDir.BaseDirAbs, c.CfgDir.GitDirAbs)
+	if defaultDir == "" {
+		return ""
+	}
+
+	if rel, err := filepath.Rel(c.CfgDir.BaseDirAbs, defaultDir); err == nil {
+		return rel
+	}
+
+	return defaultDir
+}
+
+// manifestFilesByType groups the manifest files by the type detected out of their kind.
+func manifestFilesByType(manifestFiles []string) (map[string][]string, error) {
+	filesByType := map[string][]string{}
+
+	for _, manifestFile := range manifestFiles {
+		manifestType, err := specs.DetectManifestType(manifestFile)
+		if err != nil {
+			return nil, err
+		}
+
+		filesByType[manifestType] = append(filesByType[manifestType], manifestFile)
+	}
+
+	return filesByType, nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+var (
+	// fmtCheck is a flag that indicates if the manifests are only checked, instead of rewritten.
+	fmtCheck bool
+)
+
+var ManifestFmtCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "fmt [manifest files, glob patterns or directories...]",
+	Long: `The 'fmt' command rewrites the manifests (in place) in the canonical format: the
+fields are sorted as they're declared in the spec, the casing of their names is fixed, and
+every object or list is indented with 4 spaces from the key it's nested in (the items of
+a list start right after their '- '). The comments are preserved, along with the order of
+the items of the lists. If no manifest is passed, the manifests of the '.stiletto' directory
+are formatted. With --check, the manifests aren't rewritten, and the command fails if
+any of them isn't formatted. E.g.: in CI.`,
+	Example: `
+stiletto manifest fmt
+stiletto manifest fmt 'ci/**/*.yml'
+stiletto manifest fmt --check examples/`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		check := viper.GetBool("fmtCheck")
+
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		paths := args
+		if len(paths) == 0 {
+			defaultDir := defaultManifestDir(i)
+			if defaultDir == "" {
+				cliLog.ShowError("", fmt.Sprintf("No manifest files were provided, and no '%s' directory "+
+					"was found", manifest.DefaultDir), nil)
+				os.Exit(1)
+			}
+
+			paths = []string{defaultDir}
+		}
+
+		manifestFiles, err := manifest.FindFiles(i.CfgDir.BaseDir, paths)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		var pending, failed int
+		for _, manifestFile := range manifestFiles {
+			// The manifests of a source are formatted where they're published.
+			if manifest.IsRemote(manifestFile) {
+				cliLog.ShowWarning("FMT", fmt.Sprintf("%s is skipped, since it isn't a local file", manifestFile))
+				continue
+			}
+
+			content, err := os.ReadFile(manifestFile)
+			if err != nil {
+				cliLog.ShowError("FMT", fmt.Sprintf("Cannot read the manifest %s", manifestFile), err)
+				failed++
+				continue
+			}
+
+			formatted, err := specs.FormatManifest(content)
+			if err != nil {
+				cliLog.ShowError("FMT", manifestFile, err)
+				failed++
+				continue
+			}
+
+			if bytes.Equal(content, formatted) {
+				continue
+			}
+
+			pending++
+
+			if check {
+				cliLog.ShowWarning("FMT", fmt.Sprintf("%s isn't formatted", manifestFile))
+				continue
+			}
+
+			if err := os.WriteFile(manifestFile, formatted, 0644); err != nil {
+				cliLog.ShowError("FMT", fmt.Sprintf("Cannot write the manifest %s", manifestFile), err)
+				failed++
+				continue
+			}
+
+			cliLog.ShowSuccess("FMT", fmt.Sprintf("%s was formatted", manifestFile))
+		}
+
+		if failed > 0 || (check && pending > 0) {
+			if check && pending > 0 {
+				cliLog.ShowError("", fmt.Sprintf("%d manifest(s) should be formatted. Run 'stiletto manifest "+
+					"fmt' to format them", pending), nil)
+			}
+
+			os.Exit(1)
+		}
+
+		if pending == 0 {
+			cliLog.ShowInfo("FMT", fmt.Sprintf("%d manifest(s) are already formatted", len(manifestFiles)))
+		}
+	},
+}
+
+func addFlagsToManifestFmtCMD() {
+	ManifestFmtCMD.Flags().BoolVarP(&fmtCheck, "check",
+		"", false, "Only report the manifests that aren't formatted, and fail if there's any")
+
+	_ = viper.BindPFlag("fmtCheck", ManifestFmtCMD.Flags().Lookup("check"))
+}
+
+func init() {
+	addFlagsToManifestFmtCMD()
+	ManifestCMD.AddCommand(ManifestFmtCMD)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var (
+	// listFormat is the format of the list: 'human' or 'json'.
+	listFormat string
+)
+
+var ManifestListCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "list [manifest files, glob patterns or directories...]",
+	Long: `The 'list' command prints the kind, name, image, labels and path of each manifest
+found in the paths passed, which can be files, glob patterns (where '**' matches any
+number of directories) or directories. If none is passed, the manifests of the '.stiletto' directory
+(the closest one to the current directory, up to the root of the repository) are listed.
+The manifests are listed in a deterministic order, and they're not compiled, so their
+templates aren't rendered.`,
+	Example: `
+stiletto manifest list
+stiletto manifest list 'ci/**/*.yml'
+stiletto manifest list --format=json examples/`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		format := viper.GetString("listFormat")
+		if format != "human" && format != "json" {
+			cliLog.ShowError("", fmt.Sprintf("Invalid format: %s. Should be 'human' or 'json'", format), nil)
+			os.Exit(1)
+		}
+
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		paths := args
+		if len(paths) == 0 {
+			defaultDir := defaultManifestDir(i)
+			if defaultDir == "" {
+				cliLog.ShowError("", fmt.Sprintf("No manifest files were provided, and no '%s' directory "+
+					"was found", manifest.DefaultDir), nil)
+				os.Exit(1)
+			}
+
+			paths = []string{defaultDir}
+		}
+
+		resolvedManifests, err := resolveManifests(i, paths)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		summaries := []specs.ManifestSummary{}
+		for _, resolved := range resolvedManifests {
+			manifestSummaries, err := specs.SummarizeManifest(resolved.Path)
+			if err != nil {
+				cliLog.ShowError("", err.Error(), nil)
+				os.Exit(1)
+			}
+
+			// The manifests of a source are listed by their reference (E.g.: their URL).
+			for _, summary := range manifestSummaries {
+				summary.Path = resolved.Ref
+				summaries = append(summaries, summary)
+			}
+		}
+
+		if format == "json" {
+			content, _ := json.MarshalIndent(summaries, "", "  ")
+			fmt.Println(string(content))
+			return
+		}
+
+		showManifestList(summaries)
+	},
+}
+
+func showManifestList(summaries []specs.ManifestSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tNAME\tIMAGE\tLABELS\tPATH")
+
+	for _, summary := range summaries {
+		var labels []string
+		for key, value := range summary.Labels {
+			labels = append(labels, key+"="+value)
+		}
+
+		sort.Strings(labels)
+
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", orDash(summary.Kind), orDash(summary.Name),
+			orDash(summary.Image), orDash(strings.Join(labels, ",")), summary.Path)
+	}
+
+	_ = w.Flush()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+func addFlagsToManifestListCMD() {
+	ManifestListCMD.Flags().StringVarP(&listFormat, "format",
+		"", "human", "The format of the list: human or json")
+
+	_ = viper.BindPFlag("listFormat", ManifestListCMD.Flags().Lookup("format"))
+}
+
+func init() {
+	addFlagsToManifestListCMD()
+	ManifestCMD.AddCommand(ManifestListCMD)
+}
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/imagelock"
+	"github.com/excoriate/stiletto/internal/core/job"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var LockCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "lock [manifest files, glob patterns or directories...]",
+	Long: `The 'lock' command resolves the container image of every task of the manifests
+passed (tasks, jobs and workflows) to the digest it currently points to, and pins it in
+the lockfile ('stiletto.lock', at the root of the repository). The jobs then run on the
+pinned digests, so the same manifests keep running the same tools.
+If no manifest is passed, the manifests of the '.stiletto' directory are locked. The images
+already pinned keep their digest, unless --update is passed, and the images that aren't
+referenced anymore are dropped. With --frozen, the lockfile isn't written, and the command
+fails if it doesn't match the manifests. E.g.: in CI.`,
+	Example: `
+stiletto lock
+stiletto lock --update
+stiletto lock --frozen 'ci/**/*.yml'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		paths := args
+		if len(paths) == 0 {
+			defaultDir := defaultManifestDir(i)
+			if defaultDir == "" {
+				cliLog.ShowError("", fmt.Sprintf("No manifest files were provided, and no '%s' directory "+
+					"was found", manifest.DefaultDir), nil)
+				os.Exit(1)
+			}
+
+			paths = []string{defaultDir}
+		}
+
+		images, err := manifestImages(i, paths)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		lockfilePath := lockfilePathFromFlags(i, "lock")
+
+		lock := imagelock.New()
+		if _, err := os.Stat(lockfilePath); err == nil {
+			if lock, err = imagelock.Read(lockfilePath); err != nil {
+				cliLog.ShowError("LOCK", err.Error(), nil)
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("lockFrozen") {
+			if drift := lock.Drift(images); !drift.IsEmpty() {
+				cliLog.ShowError("LOCK", fmt.Sprintf("The lockfile %s doesn't match the manifests. %s. "+
+					"Run 'stiletto lock' to update it", lockfilePath, describeDrift(drift)), nil)
+				os.Exit(1)
+			}
+
+			cliLog.ShowSuccess("LOCK", fmt.Sprintf("The lockfile %s is up to date", lockfilePath))
+			return
+		}
+
+		locked, err := lock.Lock(images, imagelock.NewRegistryResolver(nil), viper.GetBool("lockUpdate"))
+		if err != nil {
+			cliLog.ShowError("LOCK", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		for _, image := range images {
+			previous, wasLocked := lock.Images[image]
+			current := locked.Images[image]
+
+			if !wasLocked || previous.Digest != current.Digest {
+				cliLog.ShowInfo("LOCK", fmt.Sprintf("Pinned %s", imagelock.Pin(image, current.Digest)))
+			}
+		}
+
+		for _, image := range lock.Drift(images).Stale {
+			cliLog.ShowInfo("LOCK", fmt.Sprintf("Dropped %s, since it's not referenced anymore", image))
+		}
+
+		if err := locked.Write(lockfilePath); err != nil {
+			cliLog.ShowError("LOCK", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		cliLog.ShowSuccess("LOCK", fmt.Sprintf("Pinned %d image(s) in %s", len(locked.Images), lockfilePath))
+	},
+}
+
+// manifestImages returns the container images (sorted, and without duplicates) of the
+// tasks of the manifests found in the paths passed, whatever their kind is.
+func manifestImages(c *entities.Client, paths []string) ([]string, error) {
+	manifestFiles, err := resolveManifestFiles(c, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	filesByType, err := manifestFilesByType(manifestFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*job.TaskNewArgs
+
+	convertedTasks, err := loadTaskManifests(c, filesByType[entities.ManifestTypeTask])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, convertedTask := range convertedTasks {
+		tasks = append(tasks, convertedTask.Task)
+	}
+
+	convertedJobs, err := loadJobManifests(c, filesByType[entities.ManifestTypeJob])
+	if err != nil {
+		return nil, err
+	}
+
+	convertedWorkflows, err := loadWorkflowManifests(c, filesByType[entities.ManifestTypeWorkflow])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, convertedWorkflow := range convertedWorkflows {
+		convertedJobs = append(convertedJobs, convertedWorkflow.Jobs...)
+	}
+
+	for _, convertedJob := range convertedJobs {
+		for _, convertedTask := range convertedJob.Tasks {
+			tasks = append(tasks, convertedTask.Task)
+		}
+	}
+
+	seen := map[string]bool{}
+	var images []string
+	for _, task := range tasks {
+		// The image of a task with a matrix can be different on each combination.
+		taskImages, err := task.ContainerImages(c.CfgDir.BaseDirAbs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, image := range taskImages {
+			if !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+
+	sort.Strings(images)
+
+	return images, nil
+}
+
+// describeDrift describes the images missing in the lockfile, and the stale ones.
+func describeDrift(drift imagelock.Drift) string {
+	var description []string
+
+	if len(drift.Missing) != 0 {
+		description = append(description, "Not pinned: "+strings.Join(drift.Missing, ", "))
+	}
+
+	if len(drift.Stale) != 0 {
+		description = append(description, "Not referenced anymore: "+strings.Join(drift.Stale, ", "))
+	}
+
+	return strings.Join(description, ". ")
+}
+
+// addLockfileFlags adds the flags that point to the lockfile, and freeze it. The flags are
+// bound to viper with the given prefix, so several commands can declare them. E.g.:
+// 'jobFrozen' and 'jobLockfile'.
+func addLockfileFlags(cmd *cobra.Command, viperPrefix, frozenUsage string) {
+	cmd.Flags().Bool("frozen", false, frozenUsage)
+
+	cmd.Flags().String("lockfile", "",
+		"The lockfile of the container images (default is 'stiletto.lock', at the root of the repository)")
+
+	_ = viper.BindPFlag(viperPrefix+"Frozen", cmd.Flags().Lookup("frozen"))
+	_ = viper.BindPFlag(viperPrefix+"Lockfile", cmd.Flags().Lookup("lockfile"))
+}
+
+// lockfilePathFromFlags returns the lockfile passed, or the one at the root of the
+// repository (or of the current directory, outside a repository).
+func lockfilePathFromFlags(c *entities.Client, viperPrefix string) string {
+	if lockfilePath := viper.GetString(viperPrefix + "Lockfile"); lockfilePath != "" {
+		return lockfilePath
+	}
+
+	if c.CfgDir.GitDirAbs != "" {
+		return filepath.Join(c.CfgDir.GitDirAbs, imagelock.FileName)
+	}
+
+	return filepath.Join(c.CfgDir.BaseDirAbs, imagelock.FileName)
+}
+
+// lockfileFromFlags reads the lockfile to run the jobs with. If it doesn't exist, the
+// images aren't pinned (and a frozen run fails).
+func lockfileFromFlags(c *entities.Client, viperPrefix string) (*imagelock.Lockfile, error) {
+	lockfilePath := lockfilePathFromFlags(c, viperPrefix)
+
+	if _, err := os.Stat(lockfilePath); err != nil {
+		if viper.GetString(viperPrefix+"Lockfile") != "" || viper.GetBool(viperPrefix+"Frozen") {
+			return nil, fmt.Errorf("the lockfile %s doesn't exist. Run 'stiletto lock' to create it", lockfilePath)
+		}
+
+		return nil, nil
+	}
+
+	return imagelock.Read(lockfilePath)
+}
+
+// showUnpinnedImages warns about the images of the jobs that aren't pinned in the lockfile.
+func showUnpinnedImages(cliLog tui.UXMessenger, lock *imagelock.Lockfile, jobs []entities.Job) {
+	if lock == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, j := range jobs {
+		for _, task := range j.Tasks {
+			if _, ok := lock.Pinned(task.ContainerImage); ok || seen[task.ContainerImage] {
+				continue
+			}
+
+			seen[task.ContainerImage] = true
+			cliLog.ShowWarning("LOCK", fmt.Sprintf("The image %s isn't pinned in the lockfile, so it can "+
+				"drift. Run 'stiletto lock' to pin it", task.ContainerImage))
+		}
+	}
+}
+
+func addFlagsToLockCMD() {
+	LockCMD.Flags().Bool("update", false, "Resolve the digests of the images already pinned again")
+
+	_ = viper.BindPFlag("lockUpdate", LockCMD.Flags().Lookup("update"))
+
+	addLockfileFlags(LockCMD, "lock", "Fail if the lockfile doesn't match the manifests, without writing it")
+}
+
+func init() {
+	addFlagsToLockCMD()
+}
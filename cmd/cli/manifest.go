@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ManifestCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "manifest",
+	Long: `The 'manifest' command groups the utilities to work with the manifests (Task, Job
+or Workflow) without running them.`,
+	Example: `
+	  stiletto manifest new --kind=task --template=terragrunt
+	  stiletto manifest list
+	  stiletto manifest validate stiletto/tasks/*.yml
+	  stiletto manifest migrate stiletto/tasks/*.yml
+	  stiletto manifest fmt --check
+	  stiletto manifest schema --kind=task`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/job"
+	"github.com/excoriate/stiletto/internal/core/runner"
+	"github.com/excoriate/stiletto/internal/core/scheduler"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/spf13/viper"
+)
+
+// loadTaskManifests compiles, validates and converts the task manifests passed.
+func loadTaskManifests(c *entities.Client, manifestFiles []string) ([]specs.ConvertedTask, error) {
+	var convertedTasks []specs.ConvertedTask
+
+	for _, manifestFile := range manifestFiles {
+		manifestBuilder, err := newManifestBuilder(c, entities.ManifestTypeTask, manifestFile)
+		if err != nil {
+			return nil, err
+		}
+
+		// Task manifests, ready to be transformed into valid Dagger jobs (tasks).
+		taskManifests, err := manifestBuilder.BuildTasks()
+		if err != nil {
+			return nil, err
+		}
+
+		showManifestWarnings(manifestBuilder)
+
+		for _, taskManifest := range taskManifests {
+			convertedTask, err := taskManifest.Convert()
+			if err != nil {
+				return nil, err
+			}
+
+			convertedTasks = append(convertedTasks, *convertedTask)
+		}
+	}
+
+	return convertedTasks, nil
+}
+
+// loadJobManifests compiles, validates and converts the job manifests passed.
+func loadJobManifests(c *entities.Client, manifestFiles []string) ([]specs.ConvertedJob, error) {
+	var convertedJobs []specs.ConvertedJob
+
+	for _, manifestFile := range manifestFiles {
+		manifestBuilder, err := newManifestBuilder(c, entities.ManifestTypeJob, manifestFile)
+		if err != nil {
+			return nil, err
+		}
+
+		jobManifests, err := manifestBuilder.BuildJobs()
+		if err != nil {
+			return nil, err
+		}
+
+		showManifestWarnings(manifestBuilder)
+
+		for _, jobManifest := range jobManifests {
+			convertedJob, err := jobManifest.Convert()
+			if err != nil {
+				return nil, err
+			}
+
+			convertedJobs = append(convertedJobs, *convertedJob)
+		}
+	}
+
+	return convertedJobs, nil
+}
+
+// loadWorkflowManifests compiles, validates and converts the workflow manifests passed.
+func loadWorkflowManifests(c *entities.Client, manifestFiles []string) ([]specs.ConvertedWorkflow, error) {
+	var convertedWorkflows []specs.ConvertedWorkflow
+
+	for _, manifestFile := range manifestFiles {
+		manifestBuilder, err := newManifestBuilder(c, entities.ManifestTypeWorkflow, manifestFile)
+		if err != nil {
+			return nil, err
+		}
+
+		workflowManifests, err := manifestBuilder.BuildWorkflows()
+		if err != nil {
+			return nil, err
+		}
+
+		showManifestWarnings(manifestBuilder)
+
+		for _, workflowManifest := range workflowManifests {
+			convertedWorkflow, err := workflowManifest.Convert()
+			if err != nil {
+				return nil, err
+			}
+
+			convertedWorkflows = append(convertedWorkflows, *convertedWorkflow)
+		}
+	}
+
+	return convertedWorkflows, nil
+}
+
+// buildJob builds a job, and each one of its tasks out of a converted job manifest.
+func buildJob(c *entities.Client, cliLog tui.UXMessenger, convertedJob specs.ConvertedJob, workDir,
+	mountDir string) (*entities.Job, error) {
+	jobBuilder := job.NewDaggerClient(c).WithJob(*convertedJob.Job, *convertedJob.JobEnvCfg)
+
+	// Tasks are added one by one, since each of them has its own env vars configuration.
+	for _, task := range convertedJob.Tasks {
+		overrideTaskDirs(cliLog, task.Task, workDir, mountDir)
+		jobBuilder = jobBuilder.WithTasks([]job.TaskNewArgs{*task.Task}, *task.TaskEnvCfg)
+	}
+
+	return jobBuilder.Build()
+}
+
+// runJobsInDagger schedules the jobs and runs them in Dagger. If the jobs depend on each
+// other (E.g.: a workflow), they're scheduled following their dependency graph.
+func runJobsInDagger(c *entities.Client, jobs []entities.Job, withDependencyGraph bool,
+	opts runner.DaggerRunnerOptions) error {
+	schedulerBuilder := scheduler.NewScheduler().
+		WithClient(c).
+		WithJobsToRun(jobs)
+
+	if withDependencyGraph {
+		schedulerBuilder = schedulerBuilder.WithDependencyGraph()
+	}
+
+	scheduledJobs, err := schedulerBuilder.WithDaggerEngine().Build()
+
+	if err != nil {
+		return err
+	}
+
+	daggerRunner, err := runner.NewRunnerDagger(scheduledJobs).
+		WithDaggerClient(nil).
+		WithOptions(opts).
+		Build()
+
+	if err != nil {
+		return err
+	}
+
+	result, err := daggerRunner.RunInDagger(scheduledJobs.Jobs)
+	showExecutionSummary(result)
+
+	return err
+}
+
+// showExecutionSummary shows the jobs, tasks and commands that were retried, or skipped in the run.
+func showExecutionSummary(result *runner.ExecutionResult) {
+	if result == nil {
+		return
+	}
+
+	cliLog := tui.NewTUIMessage()
+
+	for _, retried := range result.Retried() {
+		cliLog.ShowWarning("RETRIED", retried)
+	}
+
+	for _, skipped := range result.Skipped() {
+		cliLog.ShowWarning("SKIPPED", skipped)
+	}
+
+	if result.Status() == runner.StatusWarning {
+		for _, warning := range result.Warnings() {
+			cliLog.ShowWarning("WARNING", warning)
+		}
+
+		cliLog.ShowWarning("RUNNER", "The run succeeded with warnings")
+	}
+}
+
+// showManifestWarnings shows the warnings (E.g.: deprecated fields) found in a manifest.
+func showManifestWarnings(manifestBuilder *specs.Builder) {
+	cliLog := tui.NewTUIMessage()
+
+	for _, diagnostic := range manifestBuilder.Diagnostics() {
+		if diagnostic.Severity == specs.SeverityWarning {
+			cliLog.ShowWarning("MANIFEST", diagnostic.String())
+		}
+	}
+}
+
+// newManifestBuilder returns a manifest builder that went through the whole
+// compilation and validation chain.
+func newManifestBuilder(c *entities.Client, manifestType, manifestFile string) (*specs.Builder, error) {
+	inputs, err := manifestInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	manifestBuilder, err := specs.NewTaskSpecBuilder(specs.NewOpts{
+		ManifestType: manifestType,
+		ManifestFile: manifestFile,
+		Client:       c,
+		Inputs:       inputs,
+		Lenient:      viper.GetBool("lenient"),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return manifestBuilder.
+		WithCompiledManifestStructure().
+		WithExtractedManifestContent().
+		WithResolvedInputs().
+		WithCompiledManifestFunctions().
+		WithResolvedInheritance().
+		WithConstructedSpec().
+		WithStrictDeepValidation(), nil
+}
+
+// manifestInputs returns the values of the inputs passed with --vars-file and --set
+// (which take precedence).
+func manifestInputs() (map[string]interface{}, error) {
+	inputs := map[string]interface{}{}
+
+	if varsFile := viper.GetString("varsFile"); varsFile != "" {
+		varsFileInputs, err := specs.LoadInputsFile(varsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs = varsFileInputs
+	}
+
+	assignedInputs, err := specs.ParseInputAssignments(viper.GetStringSlice("set"))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range assignedInputs {
+		inputs[name] = value
+	}
+
+	return inputs, nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+var (
+	// migrateTo is the version of the spec the manifests are migrated to.
+	migrateTo string
+
+	// migrateCheck is a flag that indicates if the manifests are only checked, instead of rewritten.
+	migrateCheck bool
+)
+
+var ManifestMigrateCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "migrate [manifest files...]",
+	Args:    cobra.MinimumNArgs(1),
+	Long: `The 'migrate' command rewrites the manifests (in place) to another version of the spec,
+which is the latest one by default. Only the renamed fields and the 'apiVersion' are
+changed, so the comments and the formatting of the manifests are preserved.`,
+	Example: `
+stiletto manifest migrate stiletto/tasks/*.yml
+stiletto manifest migrate --check stiletto/jobs/*.yml
+stiletto manifest migrate --to=v1 stiletto/tasks/terragrunt.yml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		to := viper.GetString("migrateTo")
+		check := viper.GetBool("migrateCheck")
+
+		var pending, failed int
+		for _, manifestFile := range args {
+			content, err := os.ReadFile(manifestFile)
+			if err != nil {
+				cliLog.ShowError("MIGRATE", fmt.Sprintf("Cannot read the manifest %s", manifestFile), err)
+				failed++
+				continue
+			}
+
+			migration, err := specs.MigrateManifest(content, to)
+			if err != nil {
+				cliLog.ShowError("MIGRATE", manifestFile, err)
+				failed++
+				continue
+			}
+
+			if len(migration.Changes) == 0 {
+				cliLog.ShowInfo("MIGRATE", fmt.Sprintf("%s is already on '%s'", manifestFile, to))
+				continue
+			}
+
+			pending++
+
+			for _, change := range migration.Changes {
+				cliLog.ShowInfo("MIGRATE", fmt.Sprintf("%s:%d:%d: '%s' -> '%s' (%s)", manifestFile,
+					change.Line, change.Column, change.From, change.To, change.Path))
+			}
+
+			if check {
+				continue
+			}
+
+			if err := os.WriteFile(manifestFile, migration.Content, 0644); err != nil {
+				cliLog.ShowError("MIGRATE", fmt.Sprintf("Cannot write the manifest %s", manifestFile), err)
+				failed++
+				continue
+			}
+
+			cliLog.ShowSuccess("MIGRATE", fmt.Sprintf("%s was migrated to '%s'", manifestFile, to))
+		}
+
+		if failed > 0 || (check && pending > 0) {
+			if check && pending > 0 {
+				cliLog.ShowError("", fmt.Sprintf("%d manifest(s) should be migrated to '%s'", pending, to), nil)
+			}
+
+			os.Exit(1)
+		}
+	},
+}
+
+func addFlagsToManifestMigrateCMD() {
+	ManifestMigrateCMD.Flags().StringVarP(&migrateTo, "to",
+		"", specs.LatestAPIVersion, "The version of the spec the manifests are migrated to")
+
+	ManifestMigrateCMD.Flags().BoolVarP(&migrateCheck, "check",
+		"", false, "Only report the changes, and fail if any manifest should be migrated")
+
+	_ = viper.BindPFlag("migrateTo", ManifestMigrateCMD.Flags().Lookup("to"))
+	_ = viper.BindPFlag("migrateCheck", ManifestMigrateCMD.Flags().Lookup("check"))
+}
+
+func init() {
+	addFlagsToManifestMigrateCMD()
+	ManifestCMD.AddCommand(ManifestMigrateCMD)
+}
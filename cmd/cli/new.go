@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+)
+
+var (
+	newKind           string
+	newTemplate       string
+	newName           string
+	newContainerImage string
+	newMountDir       string
+	newWorkDir        string
+	newOutput         string
+	newForce          bool
+)
+
+var ManifestNewCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "new",
+	Long: `The 'new' command generates a manifest out of a built-in template. The values that
+aren't passed as flags are prompted (if the terminal is interactive), or taken from the
+defaults of the template. The manifest is validated before it's written.`,
+	Example: `
+stiletto manifest new --kind=task --template=terragrunt --name=iac-plan --workdir=infra
+stiletto manifest new --template=rust --output=stiletto/tasks/rust-build.yml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+		prompter := tui.NewTUIPrompter()
+		interactive := prompter.IsInteractive()
+
+		kind := viper.GetString("newKind")
+		templateName := viper.GetString("newTemplate")
+
+		if templateName == "" {
+			if !interactive {
+				cliLog.ShowError("", "The template is required. Pass it through the '--template' flag", nil)
+				os.Exit(1)
+			}
+
+			var err error
+			templateName, err = prompter.AskSelect("Template", manifest.ScaffoldTemplates(kind), "")
+			if err != nil {
+				cliLog.ShowError("PROMPT-ERROR", "Failed to read the template", err)
+				os.Exit(1)
+			}
+		}
+
+		opts, err := manifest.ScaffoldDefaults(kind, templateName)
+		if err != nil {
+			cliLog.ShowError("MANIFEST-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		values := []struct {
+			flag     string
+			key      string
+			question string
+			value    *string
+		}{
+			{"name", "newName", "Name", &opts.Name},
+			{"container-image", "newContainerImage", "Container image", &opts.ContainerImage},
+			{"mountdir", "newMountDir", "Mount directory", &opts.MountDir},
+			{"workdir", "newWorkDir", "Working directory (relative to the mount directory)", &opts.Workdir},
+		}
+
+		for _, v := range values {
+			if cmd.Flags().Changed(v.flag) {
+				*v.value = viper.GetString(v.key)
+				continue
+			}
+
+			if !interactive {
+				continue
+			}
+
+			answer, err := prompter.AskText(v.question, *v.value)
+			if err != nil {
+				cliLog.ShowError("PROMPT-ERROR", fmt.Sprintf("Failed to read the %s", v.flag), err)
+				os.Exit(1)
+			}
+
+			*v.value = answer
+		}
+
+		output := viper.GetString("newOutput")
+		if output == "" {
+			output = fmt.Sprintf("%s.yml", opts.Name)
+		}
+
+		if _, err := os.Stat(output); err == nil && !viper.GetBool("newForce") {
+			cliLog.ShowError("", fmt.Sprintf("The file %s already exists. "+
+				"Use '--force' to overwrite it", output), nil)
+			os.Exit(1)
+		}
+
+		content, err := manifest.Scaffold(opts)
+		if err != nil {
+			cliLog.ShowError("MANIFEST-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		if err := writeValidatedManifest(i, entities.ManifestTypeTask, output, content); err != nil {
+			cliLog.ShowError("MANIFEST-ERROR", "The generated manifest is invalid", err)
+			os.Exit(1)
+		}
+
+		cliLog.ShowSuccess("MANIFEST", fmt.Sprintf("The %s manifest '%s' was written into %s",
+			opts.Kind, opts.Name, output))
+	},
+}
+
+// writeValidatedManifest writes the manifest content into a temporary file next
+// to the output, validates it, and only then moves it into the output file.
+func writeValidatedManifest(c *entities.Client, manifestType, output string, content []byte) error {
+	outputAbs, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputAbs), 0755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(outputAbs), ".stiletto-new-*.yml")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(tmpFile.Name())
+	}()
+
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	manifestBuilder, err := newManifestBuilder(c, manifestType, tmpFile.Name())
+	if err != nil {
+		return err
+	}
+
+	if _, err := manifestBuilder.Build(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), outputAbs)
+}
+
+func addFlagsToManifestNewCMD() {
+	ManifestNewCMD.Flags().StringVarP(&newKind, "kind",
+		"", "task", "The kind of manifest to generate")
+
+	ManifestNewCMD.Flags().StringVarP(&newTemplate, "template",
+		"t", "", "The template of the manifest: terragrunt, rust, node or aws-cli")
+
+	ManifestNewCMD.Flags().StringVarP(&newName, "name",
+		"", "", "The name of the manifest")
+
+	ManifestNewCMD.Flags().StringVarP(&newContainerImage, "container-image",
+		"", "", "The container image where the commands run")
+
+	ManifestNewCMD.Flags().StringVarP(&newMountDir, "mountdir",
+		"", "", "The directory, relative to the current one, that's mounted in the container")
+
+	ManifestNewCMD.Flags().StringVarP(&newWorkDir, "workdir",
+		"", "", "The directory, relative to the mountdir, where the commands run")
+
+	ManifestNewCMD.Flags().StringVarP(&newOutput, "output",
+		"o", "", "The file where the manifest is written. It defaults to '<name>.yml'")
+
+	ManifestNewCMD.Flags().BoolVarP(&newForce, "force",
+		"f", false, "Overwrite the output file if it already exists")
+
+	_ = viper.BindPFlag("newKind", ManifestNewCMD.Flags().Lookup("kind"))
+	_ = viper.BindPFlag("newTemplate", ManifestNewCMD.Flags().Lookup("template"))
+	_ = viper.BindPFlag("newName", ManifestNewCMD.Flags().Lookup("name"))
+	_ = viper.BindPFlag("newContainerImage", ManifestNewCMD.Flags().Lookup("container-image"))
+	_ = viper.BindPFlag("newMountDir", ManifestNewCMD.Flags().Lookup("mountdir"))
+	_ = viper.BindPFlag("newWorkDir", ManifestNewCMD.Flags().Lookup("workdir"))
+	_ = viper.BindPFlag("newOutput", ManifestNewCMD.Flags().Lookup("output"))
+	_ = viper.BindPFlag("newForce", ManifestNewCMD.Flags().Lookup("force"))
+}
+
+func init() {
+	addFlagsToManifestNewCMD()
+	ManifestCMD.AddCommand(ManifestNewCMD)
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"github.com/excoriate/stiletto/internal/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
@@ -14,7 +15,7 @@ var (
 )
 
 var rootCmd = &cobra.Command{
-	Version: "v0.0.1",
+	Version: version.Version,
 	Use:     "stiletto",
 	Long: `stiletto is a cmd-line tool that helps you to run your pipelines in a
 containerized environment.`,
@@ -45,8 +46,21 @@ func addPersistentFlagsToRootCMD() {
 		"c", "",
 		"config file (default is $HOME/.stiletto.yaml)")
 
+	rootCmd.PersistentFlags().StringArray("set", []string{},
+		"Set the value of an input of the manifests, as 'name=value'. It can be repeated")
+
+	rootCmd.PersistentFlags().String("vars-file", "",
+		"Yaml file with the values of the inputs of the manifests, as 'name: value'. "+
+			"The ones passed with --set take precedence")
+
+	rootCmd.PersistentFlags().Bool("lenient", false,
+		"Report the unknown fields of the manifests as warnings, instead of errors")
+
 	_ = viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+	_ = viper.BindPFlag("set", rootCmd.PersistentFlags().Lookup("set"))
+	_ = viper.BindPFlag("varsFile", rootCmd.PersistentFlags().Lookup("vars-file"))
+	_ = viper.BindPFlag("lenient", rootCmd.PersistentFlags().Lookup("lenient"))
 }
 
 func initConfig() {
@@ -67,7 +81,7 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
 
@@ -78,4 +92,13 @@ func init() {
 
 	// Add Job JobCMD.
 	rootCmd.AddCommand(JobCMD)
+
+	// Add Workflow WorkflowCMD.
+	rootCmd.AddCommand(WorkflowCMD)
+
+	// Add Manifest ManifestCMD.
+	rootCmd.AddCommand(ManifestCMD)
+
+	// Add Lock LockCMD.
+	rootCmd.AddCommand(LockCMD)
 }
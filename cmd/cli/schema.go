@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+var (
+	// schemaKind is the kind of manifest the schema is generated for.
+	schemaKind string
+
+	// schemaOutput is the file where the schema is written. If empty, it's printed to stdout.
+	schemaOutput string
+)
+
+var ManifestSchemaCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "schema",
+	Long: `The 'schema' command generates the JSON Schema of the manifests. It can be used by
+editors (E.g.: through yaml-language-server) to validate and autocomplete the manifests.`,
+	Example: `
+stiletto manifest schema --kind=task --output=stiletto-task.schema.json
+stiletto manifest schema > stiletto.schema.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		kind := viper.GetString("schemaKind")
+		output := viper.GetString("schemaOutput")
+
+		schema, err := specs.JSONSchema(kind)
+		if err != nil {
+			cliLog.ShowError("SCHEMA-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		content, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			cliLog.ShowError("SCHEMA-ERROR", "Failed to encode the schema", err)
+			os.Exit(1)
+		}
+
+		if output == "" {
+			fmt.Println(string(content))
+			return
+		}
+
+		if err := os.WriteFile(output, append(content, '\n'), 0644); err != nil {
+			cliLog.ShowError("SCHEMA-ERROR", fmt.Sprintf("Failed to write the schema into %s", output), err)
+			os.Exit(1)
+		}
+
+		cliLog.ShowSuccess("SCHEMA", fmt.Sprintf("The schema was written into %s", output))
+	},
+}
+
+func addFlagsToManifestSchemaCMD() {
+	ManifestSchemaCMD.Flags().StringVarP(&schemaKind, "kind",
+		"", specs.SchemaKindAll, "The kind of manifest: task, job, workflow or all")
+
+	ManifestSchemaCMD.Flags().StringVarP(&schemaOutput, "output",
+		"o", "", "The file where the schema is written. If not set, it's printed to stdout")
+
+	_ = viper.BindPFlag("schemaKind", ManifestSchemaCMD.Flags().Lookup("kind"))
+	_ = viper.BindPFlag("schemaOutput", ManifestSchemaCMD.Flags().Lookup("output"))
+}
+
+func init() {
+	addFlagsToManifestSchemaCMD()
+	ManifestCMD.AddCommand(ManifestSchemaCMD)
+}
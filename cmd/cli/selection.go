@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"github.com/excoriate/stiletto/internal/core/selector"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addSelectionFlags adds the flags that select, out of the manifests loaded, the ones
+// to run. The flags are bound to viper with the given prefix, so several commands can
+// declare them. E.g.: 'jobOnly', 'jobExclude' and 'jobLabels'.
+func addSelectionFlags(cmd *cobra.Command, viperPrefix string) {
+	cmd.Flags().StringSlice("only", []string{},
+		"Run only the tasks (or jobs) whose name matches any of these glob patterns. E.g.: 'iac-*'")
+
+	cmd.Flags().StringSlice("exclude", []string{},
+		"Skip the tasks (or jobs) whose name matches any of these glob patterns")
+
+	cmd.Flags().StringSlice("labels", []string{},
+		"Run only the tasks (or jobs) whose labels match every requirement. "+
+			"E.g.: 'stage=plan,team=infra', 'stage!=apply', 'team' or '!manual'")
+
+	_ = viper.BindPFlag(viperPrefix+"Only", cmd.Flags().Lookup("only"))
+	_ = viper.BindPFlag(viperPrefix+"Exclude", cmd.Flags().Lookup("exclude"))
+	_ = viper.BindPFlag(viperPrefix+"Labels", cmd.Flags().Lookup("labels"))
+}
+
+// selectorFromFlags returns the selector built out of the flags added by addSelectionFlags.
+func selectorFromFlags(viperPrefix string) (*selector.Selector, error) {
+	return selector.New(viper.GetStringSlice(viperPrefix+"Only"),
+		viper.GetStringSlice(viperPrefix+"Exclude"),
+		viper.GetStringSlice(viperPrefix+"Labels"))
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/specs"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+var (
+	// validateKind is the kind of the manifests to validate. If 'auto', it's detected from each manifest.
+	validateKind string
+
+	// validateFormat is the format of the report: 'human' or 'json'.
+	validateFormat string
+)
+
+// validationReport is the result of validating one or many manifest files.
+type validationReport struct {
+	Valid       bool               `json:"valid"`
+	Files       int                `json:"files"`
+	Errors      int                `json:"errors"`
+	Warnings    int                `json:"warnings"`
+	Diagnostics []specs.Diagnostic `json:"diagnostics"`
+}
+
+var ManifestValidateCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "validate [manifest files, glob patterns or directories...]",
+	Args:    cobra.MinimumNArgs(1),
+	Long: `The 'validate' command validates the manifests (Task, Job or Workflow) without running
+them, and without connecting to Dagger. Every problem found is reported along with its
+position (file, line and column), and the command exits with a non-zero status if any
+manifest is invalid, so it can be used to gate CI pipelines.`,
+	Example: `
+stiletto manifest validate examples/tasks/*.yml
+stiletto manifest validate '.stiletto/**/*.yml'
+stiletto manifest validate --kind=job --format=json stiletto/jobs/terragrunt.yml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cliLog := tui.NewTUIMessage()
+
+		kind := viper.GetString("validateKind")
+		format := viper.GetString("validateFormat")
+
+		if format != "human" && format != "json" {
+			cliLog.ShowError("", fmt.Sprintf("Invalid format: %s. Should be 'human' or 'json'", format), nil)
+			os.Exit(1)
+		}
+
+		manifestType, err := manifestTypeFromKind(kind)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		manifestFiles, err := resolveManifestFiles(i, args)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		report := validationReport{Files: len(manifestFiles), Diagnostics: []specs.Diagnostic{}}
+		for _, manifestFile := range manifestFiles {
+			report.Diagnostics = append(report.Diagnostics, validateManifest(i, manifestType, manifestFile)...)
+		}
+
+		for _, diagnostic := range report.Diagnostics {
+			if diagnostic.Severity == specs.SeverityError {
+				report.Errors++
+			} else {
+				report.Warnings++
+			}
+		}
+
+		report.Valid = report.Errors == 0
+
+		if format == "json" {
+			content, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(content))
+		} else {
+			showValidationReport(cliLog, report)
+		}
+
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+// validateManifest runs the whole manifest builder chain over the manifest file,
+// and returns the problems found.
+func validateManifest(c *entities.Client, manifestType, manifestFile string) []specs.Diagnostic {
+	if manifestType == "" {
+		// If the kind can't be detected, the manifest is validated as a task, so the
+		// problem (E.g.: a broken yaml, or an invalid kind) is reported with its position.
+		manifestType = entities.ManifestTypeTask
+		if detectedType, err := specs.DetectManifestType(manifestFile); err == nil {
+			manifestType = detectedType
+		}
+	}
+
+	manifestBuilder, err := newManifestBuilder(c, manifestType, manifestFile)
+	if err != nil {
+		return []specs.Diagnostic{{File: manifestFile, Severity: specs.SeverityError, Message: err.Error()}}
+	}
+
+	return manifestBuilder.Diagnostics()
+}
+
+func showValidationReport(cliLog tui.UXMessenger, report validationReport) {
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Severity == specs.SeverityError {
+			cliLog.ShowError("", diagnostic.String(), nil)
+		} else {
+			cliLog.ShowWarning("", diagnostic.String())
+		}
+	}
+
+	summary := fmt.Sprintf("%d file(s) validated: %d error(s), %d warning(s)", report.Files,
+		report.Errors, report.Warnings)
+
+	if report.Valid {
+		cliLog.ShowSuccess("VALIDATE", summary)
+		return
+	}
+
+	cliLog.ShowError("VALIDATE", summary, nil)
+}
+
+// manifestTypeFromKind returns the manifest type of the given kind. If the kind
+// is 'auto', it returns an empty type, so it's detected from each manifest.
+func manifestTypeFromKind(kind string) (string, error) {
+	switch kind {
+	case "auto":
+		return "", nil
+	case "task":
+		return entities.ManifestTypeTask, nil
+	case "job":
+		return entities.ManifestTypeJob, nil
+	case "workflow":
+		return entities.ManifestTypeWorkflow, nil
+	default:
+		return "", fmt.Errorf("invalid manifest kind: %s. Should be 'auto', 'task', 'job' or 'workflow'", kind)
+	}
+}
+
+func addFlagsToManifestValidateCMD() {
+	ManifestValidateCMD.Flags().StringVarP(&validateKind, "kind",
+		"", "auto", "The kind of the manifests: auto, task, job or workflow")
+
+	ManifestValidateCMD.Flags().StringVarP(&validateFormat, "format",
+		"", "human", "The format of the report: human or json")
+
+	_ = viper.BindPFlag("validateKind", ManifestValidateCMD.Flags().Lookup("kind"))
+	_ = viper.BindPFlag("validateFormat", ManifestValidateCMD.Flags().Lookup("format"))
+}
+
+func init() {
+	addFlagsToManifestValidateCMD()
+	ManifestCMD.AddCommand(ManifestValidateCMD)
+}
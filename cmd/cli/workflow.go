@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/core/runner"
+	"github.com/excoriate/stiletto/internal/tui"
+	"github.com/excoriate/stiletto/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+)
+
+var (
+	// workflowFiles is the list of workflow manifests to run.
+	workflowFiles []string
+
+	// workflowShowEnvVars is a flag that indicates if the environment variables should be shown.
+	workflowShowEnvVars bool
+)
+
+var WorkflowCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "workflow",
+	Long: `The 'workflow' command runs one or many jobs that can depend on each other.
+A 'Workflow' is an entity that groups one or many Jobs, where each job declares
+(through 'needs') the jobs that should succeed before it runs.`,
+	Example: `
+	  stiletto workflow dagger --workflow-files=build-test-publish.yml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+var WorkflowDaggerCMD = &cobra.Command{
+	Version: "v0.0.1",
+	Use:     "dagger",
+	Long: `The 'dagger' command runs the jobs of a workflow on top of Dagger (Container),
+following the dependencies between them. If a job fails, the jobs that need it are skipped.`,
+	Example: `
+stiletto workflow dagger --workflow-files=../../stiletto/workflows/build-test-publish.yml
+stiletto workflow dagger --workflow-files='ci/workflows/*.yml'
+stiletto workflow dagger --workflow-files=build-test-publish.yml --frozen`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// CLI UX utilities.
+		cliLog := tui.NewTUIMessage()
+		cliUX := tui.NewTitle()
+
+		showEnvVars := viper.GetBool("workflowShowEnvVars")
+
+		// Client instance.
+		i, err := clients.NewClient(entities.ClientTypeCli).WithCLI(entities.CLIConfigArgs{}).WithHost().Build()
+
+		if err != nil {
+			cliLog.ShowError("CLIENT-ERROR", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		// The lockfile that pins the images of the tasks, if any.
+		lock, err := lockfileFromFlags(i, "workflow")
+		if err != nil {
+			cliLog.ShowError("LOCK", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		workflowFilesCfg, err := workflowManifestFiles(i, cliLog)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		cliUX.ShowTitleAndDescription("STILETTO",
+			"Automated pipelines, "+
+				"workflows and whatever can be containerized in your own laptop 👨🏻‍💻("+
+				"powered by Dagger.IO)")
+
+		workflowsConvertedFromManifest, err := loadWorkflowManifests(i, workflowFilesCfg)
+		if err != nil {
+			cliLog.ShowError("", err.Error(), nil)
+			os.Exit(1)
+		}
+
+		for _, workflow := range workflowsConvertedFromManifest {
+			var jobs []entities.Job
+			for _, convertedJob := range workflow.Jobs {
+				j, err := buildJob(i, cliLog, convertedJob, "", "")
+				if err != nil {
+					cliLog.ShowError("JOB-ERROR", err.Error(), nil)
+					os.Exit(1)
+				}
+
+				jobs = append(jobs, *j)
+			}
+
+			cliLog.ShowInfo("WORKFLOW", fmt.Sprintf("Running workflow '%s' with %d job(s)",
+				workflow.Name, len(jobs)))
+
+			frozen := viper.GetBool("workflowFrozen")
+			if !frozen {
+				showUnpinnedImages(cliLog, lock, jobs)
+			}
+
+			if err := runJobsInDagger(i, jobs, true, runner.DaggerRunnerOptions{
+				ShowEnvVars: showEnvVars,
+				Lockfile:    lock,
+				Frozen:      frozen,
+			}); err != nil {
+				cliLog.ShowError("RUNNER-ERROR", err.Error(), nil)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func addFlagsToWorkflowDaggerCMD() {
+	WorkflowDaggerCMD.Flags().StringSliceVarP(&workflowFiles, "workflow-files",
+		"", []string{}, "The workflows in .yml format that'll be executed. Glob patterns are supported")
+
+	WorkflowDaggerCMD.Flags().BoolVarP(&workflowShowEnvVars,
+		"show-env-vars",
+		"", false,
+		"Show the environment variables that'll be used in each job.")
+
+	_ = viper.BindPFlag("workflowFiles", WorkflowDaggerCMD.Flags().Lookup("workflow-files"))
+	_ = viper.BindPFlag("workflowShowEnvVars", WorkflowDaggerCMD.Flags().Lookup("show-env-vars"))
+
+	addLockfileFlags(WorkflowDaggerCMD, "workflow", "Fail if the image of a task isn't pinned in the lockfile")
+}
+
+// workflowManifestFiles returns the workflow files to run. If none is passed, the
+// workflows of the '.stiletto' directory are run.
+func workflowManifestFiles(c *entities.Client, cliLog tui.UXMessenger) ([]string, error) {
+	workflowFilesCfg, err := resolveManifestFiles(c, viper.GetStringSlice("workflowFiles"))
+	if err != nil || len(workflowFilesCfg) != 0 {
+		return workflowFilesCfg, err
+	}
+
+	defaultDir := defaultManifestDir(c)
+	if defaultDir == "" {
+		return nil, fmt.Errorf("no workflow files (specs, or manifests) were provided, "+
+			"and no '%s' directory was found", manifest.DefaultDir)
+	}
+
+	dirFiles, err := resolveManifestDirs(c, []string{defaultDir})
+	if err != nil {
+		return nil, err
+	}
+
+	dirFilesByType, err := manifestFilesByType(dirFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	workflowFilesCfg = dirFilesByType[entities.ManifestTypeWorkflow]
+	if len(workflowFilesCfg) == 0 {
+		return nil, fmt.Errorf("no workflow files (specs, or manifests) were found in %s", defaultDir)
+	}
+
+	cliLog.ShowInfo("MANIFEST", fmt.Sprintf("Running the workflows of %s", defaultDir))
+
+	return workflowFilesCfg, nil
+}
+
+func init() {
+	addFlagsToWorkflowDaggerCMD()
+	WorkflowCMD.AddCommand(WorkflowDaggerCMD)
+}
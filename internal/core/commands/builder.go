@@ -2,14 +2,76 @@ package commands
 
 import (
 	"fmt"
+	"github.com/excoriate/stiletto/internal/core/retry"
 	"github.com/excoriate/stiletto/internal/errors"
 	"github.com/excoriate/stiletto/internal/utils"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
+// Shells that run the script steps.
+const (
+	ShellSh   = "sh"
+	ShellBash = "bash"
+)
+
+// ScriptsDir is the directory of the container where the script files are copied.
+const ScriptsDir = "/stiletto/scripts"
+
 type CMD struct {
 	Binary   string
 	Commands []string
 	Error    error
+	// Script is the script of a 'run' step, executed by a shell as a single command.
+	Script string
+	// ScriptFile is the host path of the script file copied into the container, and
+	// executed by a shell. It's relative to the base directory.
+	ScriptFile string
+	// Condition is the 'if' expression that decides whether the command runs. See the expr package.
+	Condition string
+	// Timeout is the maximum duration of each attempt of the command. If zero, there's none.
+	Timeout time.Duration
+	// Retry is the policy applied when the command fails. If nil, it's not retried.
+	Retry *retry.Policy
+	// ContinueOnError records a failure of the command as a warning, instead of failing the task.
+	ContinueOnError bool
+	// AllowedExitCodes are the non-zero exit codes recorded as warnings, instead of failures.
+	AllowedExitCodes []int
+	// Isolated runs the command on the container left by the previous commands, without
+	// passing its own changes to the next ones.
+	Isolated bool
+}
+
+// AllowsExitCode reports whether the exit code is recorded as a warning, instead of a failure.
+func (c *CMD) AllowsExitCode(exitCode int) bool {
+	for _, allowed := range c.AllowedExitCodes {
+		if allowed == exitCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String describes the command. E.g.: 'cargo build', or 'run: cargo build && ls target'.
+func (c *CMD) String() string {
+	switch {
+	case c.Script != "":
+		script := strings.TrimSpace(c.Script)
+		lines := strings.SplitN(script, "\n", 2)
+
+		if len(lines) > 1 {
+			return "run: " + strings.TrimSpace(lines[0]) + " ..."
+		}
+
+		return "run: " + script
+	case c.ScriptFile != "":
+		return "scriptFile: " + c.ScriptFile
+	default:
+		return strings.Join(c.Commands, " ")
+	}
 }
 
 type CMDNewArgs struct {
@@ -18,10 +80,18 @@ type CMDNewArgs struct {
 }
 
 type CMDBuilder struct {
-	binary      string
-	commandArgs string
-	commands    []string
-	error       error
+	binary           string
+	commandArgs      string
+	commands         []string
+	script           string
+	scriptFile       string
+	condition        string
+	timeout          time.Duration
+	retry            *retry.Policy
+	continueOnError  bool
+	allowedExitCodes []int
+	isolated         bool
+	error            error
 }
 
 func (b *CMDBuilder) WithBinary(binary string) *CMDBuilder {
@@ -29,6 +99,37 @@ func (b *CMDBuilder) WithBinary(binary string) *CMDBuilder {
 	return b
 }
 
+// WithScript runs the script with the shell, as a single command that stops at the
+// first failure ('set -e'). The script is passed as a single argument, so it keeps
+// its quoting, pipes, redirects and variables.
+func (b *CMDBuilder) WithScript(shell, script string) *CMDBuilder {
+	b.script = script
+	b.commands = append(shellArgs(shell), "-c", script)
+	return b
+}
+
+// WithScriptFile runs the script file, once it's copied into the container (see
+// ScriptPath), with the shell.
+func (b *CMDBuilder) WithScriptFile(shell, scriptFile string) *CMDBuilder {
+	b.scriptFile = scriptFile
+	b.commands = append(shellArgs(shell), ScriptPath(scriptFile))
+	return b
+}
+
+// ScriptPath returns the path of the container where the script file is copied.
+func ScriptPath(scriptFile string) string {
+	return path.Join(ScriptsDir, path.Clean("/"+filepath.ToSlash(scriptFile)))
+}
+
+// shellArgs returns the shell, with the options that stop the script at the first failure.
+func shellArgs(shell string) []string {
+	if shell == ShellBash {
+		return []string{ShellBash, "-e", "-o", "pipefail"}
+	}
+
+	return []string{ShellSh, "-e"}
+}
+
 func (b *CMDBuilder) WithCommands(commandArgs string) *CMDBuilder {
 	commands, err := utils.GetCommandArgs(commandArgs)
 	if err != nil {
@@ -45,14 +146,59 @@ func (b *CMDBuilder) WithCommands(commandArgs string) *CMDBuilder {
 	return b
 }
 
+// WithCondition sets the 'if' expression of the command. An empty one always runs,
+// unless a previous command failed.
+func (b *CMDBuilder) WithCondition(condition string) *CMDBuilder {
+	b.condition = condition
+	return b
+}
+
+// WithTimeout sets the maximum duration of the command.
+func (b *CMDBuilder) WithTimeout(timeout time.Duration) *CMDBuilder {
+	b.timeout = timeout
+	return b
+}
+
+// WithRetry sets the policy applied when the command fails.
+func (b *CMDBuilder) WithRetry(policy *retry.Policy) *CMDBuilder {
+	b.retry = policy
+	return b
+}
+
+// WithContinueOnError records the failures of the command as warnings.
+func (b *CMDBuilder) WithContinueOnError(continueOnError bool) *CMDBuilder {
+	b.continueOnError = continueOnError
+	return b
+}
+
+// WithAllowedExitCodes sets the non-zero exit codes recorded as warnings.
+func (b *CMDBuilder) WithAllowedExitCodes(exitCodes []int) *CMDBuilder {
+	b.allowedExitCodes = exitCodes
+	return b
+}
+
+// WithIsolated keeps the changes of the command out of the container of the next ones.
+func (b *CMDBuilder) WithIsolated(isolated bool) *CMDBuilder {
+	b.isolated = isolated
+	return b
+}
+
 func (b *CMDBuilder) Build() (*CMD, error) {
 	if b.error != nil {
 		return nil, errors.NewConfigurationError("Could not create a valid 'jobcmd' instance", b.error)
 	}
 
 	return &CMD{
-		Binary:   b.binary,
-		Commands: b.commands,
+		Binary:           b.binary,
+		Commands:         b.commands,
+		Script:           b.script,
+		ScriptFile:       b.scriptFile,
+		Condition:        b.condition,
+		Timeout:          b.timeout,
+		Retry:            b.retry,
+		ContinueOnError:  b.continueOnError,
+		AllowedExitCodes: b.allowedExitCodes,
+		Isolated:         b.isolated,
 	}, nil
 }
 
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCMDBuilder(t *testing.T) {
+	t.Run("should run the script as a single argument of the shell", func(t *testing.T) {
+		script := "cargo build --release\nls target/release | grep \"my app\" > out.txt\n"
+
+		cmd, err := NewCMD().WithScript(ShellBash, script).Build()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bash", "-e", "-o", "pipefail", "-c", script}, cmd.Commands)
+		assert.Equal(t, "run: cargo build --release ...", cmd.String())
+	})
+
+	t.Run("should run the script file copied into the container", func(t *testing.T) {
+		cmd, err := NewCMD().WithScriptFile("", "scripts/../scripts/deploy.sh").Build()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sh", "-e", "/stiletto/scripts/scripts/deploy.sh"}, cmd.Commands)
+		assert.Equal(t, "scriptFile: scripts/../scripts/deploy.sh", cmd.String())
+	})
+
+	t.Run("should split the commands without a shell", func(t *testing.T) {
+		cmd, err := NewCMD().WithBinary("cargo").WithCommands("build --features 'a b'").Build()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cargo", "build", "--features", "a b"}, cmd.Commands)
+		assert.Equal(t, "cargo build --features a b", cmd.String())
+		assert.False(t, cmd.Isolated)
+	})
+
+	t.Run("should mark the command as isolated", func(t *testing.T) {
+		cmd, err := NewCMD().WithBinary("ls").WithCommands("target/release").WithIsolated(true).Build()
+
+		assert.NoError(t, err)
+		assert.True(t, cmd.Isolated)
+	})
+}
@@ -3,12 +3,16 @@ package entities
 import (
 	"context"
 	"go.uber.org/zap"
+	"time"
 )
 
 type Client struct {
 	// Id is the unique identifier for the instance.
 	Id string
 
+	// StartedAt is the time the instance was created.
+	StartedAt time.Time
+
 	// Ctx is the context for the instance.
 	Ctx *context.Context
 
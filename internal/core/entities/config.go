@@ -49,4 +49,11 @@ type DirCfg struct {
 	HomeDirAbs string
 	IsGitRepo  bool
 	GitDirAbs  string
+	GitBranch  string
+	GitSHA     string
+	GitTag     string
+	GitRemote  string
+	// GitDirty reports whether any of the files tracked was modified. It's computed the
+	// first time it's called, since it hashes the tracked files. See IsGitDirty.
+	GitDirty func() bool
 }
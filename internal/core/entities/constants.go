@@ -1,39 +1,22 @@
 package entities
 
-import (
-	"os"
-	"strings"
-	"text/template"
-)
-
 const ClientTypeCli = "CLI"
 const ManifestTypeTask = "MANIFEST_TASK"
 const ManifestTypeJob = "MANIFEST_JOB"
 const ManifestTypeWorkflow = "MANIFEST_WORKFLOW"
 
-type FunctionMap map[string]interface{}
+// Manifest kinds, as they're declared in the 'kind' field of a manifest.
+const ManifestKindTask = "Task"
+const ManifestKindJob = "Job"
+const ManifestKindWorkflow = "Workflow"
+
+// Versions of the manifest spec, as they're declared in the 'apiVersion' field.
+const ManifestAPIVersionV1 = "v1"
+const ManifestAPIVersionV2 = "v2"
 
-// TmplCfgFuncMaps is a map of template keywords and their respective functions.
-// It's used to map a specific word or keyword in a given manifes into
-// a function that will be executed by the template engine.
-var TmplCfgFuncMaps = map[string]template.FuncMap{
-	"readEnv": {
-		"readEnv": os.Getenv,
-	},
-	"readPWD": {
-		"getPwd": os.Getwd,
-	},
-	"readHome": {
-		"getHome": os.UserHomeDir,
-	},
-	"doReplace": {
-		"replace": func(s, old, new string) string {
-			return strings.ReplaceAll(s, old, new)
-		},
-	},
-	"doTrimspace": {
-		"trimspace": func(s string) string {
-			return strings.TrimSpace(s)
-		},
-	},
+// ManifestKindByType maps a manifest type into the kind its manifests must declare.
+var ManifestKindByType = map[string]string{
+	ManifestTypeTask:     ManifestKindTask,
+	ManifestTypeJob:      ManifestKindJob,
+	ManifestTypeWorkflow: ManifestKindWorkflow,
 }
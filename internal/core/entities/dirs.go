@@ -1,33 +1,110 @@
 package entities
 
 import (
+	"fmt"
 	"github.com/excoriate/stiletto/internal/utils"
+	"go.uber.org/zap"
 	"os"
+	"strconv"
+	"sync"
 )
 
-func GetDirCfg() *DirCfg {
+// maxGitRepoDirLevels is the number of directories walked up, from the current one,
+// to find the root of the git repository.
+const maxGitRepoDirLevels = 64
+
+// Env vars set, in every task, with the git metadata of the repository Stiletto runs from.
+const (
+	EnvVarGitBranch   = "STILETTO_GIT_BRANCH"
+	EnvVarGitSHA      = "STILETTO_GIT_SHA"
+	EnvVarGitShortSHA = "STILETTO_GIT_SHORT_SHA"
+	EnvVarGitTag      = "STILETTO_GIT_TAG"
+	EnvVarGitRemote   = "STILETTO_GIT_REMOTE"
+	EnvVarGitDirty    = "STILETTO_GIT_DIRTY"
+)
+
+// GetDirCfg returns the directories Stiletto runs from, along with the git metadata of
+// the repository. The metadata that can't be read is left empty, and logged at debug.
+func GetDirCfg(logger *zap.Logger) *DirCfg {
 	currentDir, _ := os.Getwd()
 	currentDirAbs, _ := utils.PathToAbsolute(currentDir)
 	homeDir, _ := os.UserHomeDir()
 	homeDirAbs, _ := utils.PathToAbsolute(homeDir)
 
-	if err := utils.IsGitRepository(currentDirAbs); err != nil {
-		return &DirCfg{
-			BaseDir:    currentDir,
-			BaseDirAbs: currentDirAbs,
-			HomeDir:    homeDir,
-			HomeDirAbs: homeDirAbs,
-			IsGitRepo:  false,
-			GitDirAbs:  "",
-		}
-	}
-
-	return &DirCfg{
+	dirCfg := &DirCfg{
 		BaseDir:    currentDir,
 		BaseDirAbs: currentDirAbs,
-		IsGitRepo:  true,
 		HomeDir:    homeDir,
 		HomeDirAbs: homeDirAbs,
-		GitDirAbs:  "",
+	}
+
+	// The current directory can be any directory of the repository, not only its root.
+	gitRepoDir, err := utils.FindGitRepoDir(currentDirAbs, maxGitRepoDirLevels)
+	if err != nil {
+		return dirCfg
+	}
+
+	dirCfg.IsGitRepo = true
+	dirCfg.GitDirAbs = gitRepoDir
+
+	gitInfo, err := utils.ReadGitInfo(gitRepoDir)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Cannot read the git metadata of the repository %s: %s", gitRepoDir, err))
+	}
+
+	if gitInfo != nil {
+		dirCfg.GitBranch = gitInfo.Branch
+		dirCfg.GitSHA = gitInfo.SHA
+		dirCfg.GitTag = gitInfo.Tag
+		dirCfg.GitRemote = gitInfo.Remote
+	}
+
+	var once sync.Once
+	var dirty bool
+	dirCfg.GitDirty = func() bool {
+		once.Do(func() {
+			var dirtyErr error
+			if dirty, dirtyErr = utils.IsGitDirty(gitRepoDir); dirtyErr != nil {
+				logger.Debug(fmt.Sprintf("Cannot read whether the git repository %s is dirty: %s",
+					gitRepoDir, dirtyErr))
+			}
+		})
+
+		return dirty
+	}
+
+	return dirCfg
+}
+
+// IsGitDirty returns true if any of the files tracked by the git repository was modified.
+// It's false if it's not a git repository.
+func (d *DirCfg) IsGitDirty() bool {
+	if d == nil || d.GitDirty == nil {
+		return false
+	}
+
+	return d.GitDirty()
+}
+
+// GitEnvVars returns the 'STILETTO_GIT_*' env vars, with the git metadata of the
+// repository. They're set even if they're empty (E.g.: the tag), so the tasks can rely
+// on them. If it's not a git repository, there are none.
+func (d *DirCfg) GitEnvVars() map[string]string {
+	if d == nil || !d.IsGitRepo {
+		return nil
+	}
+
+	shortSHA := d.GitSHA
+	if len(shortSHA) > 7 {
+		shortSHA = shortSHA[:7]
+	}
+
+	return map[string]string{
+		EnvVarGitBranch:   d.GitBranch,
+		EnvVarGitSHA:      d.GitSHA,
+		EnvVarGitShortSHA: shortSHA,
+		EnvVarGitTag:      d.GitTag,
+		EnvVarGitRemote:   d.GitRemote,
+		EnvVarGitDirty:    strconv.FormatBool(d.IsGitDirty()),
 	}
 }
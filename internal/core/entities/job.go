@@ -2,6 +2,8 @@ package entities
 
 import (
 	"github.com/excoriate/stiletto/internal/core/commands"
+	"github.com/excoriate/stiletto/internal/core/retry"
+	"time"
 )
 
 type Job struct {
@@ -25,6 +27,12 @@ type Job struct {
 
 	// EnvVars is the environment variables to be passed to the container.
 	EnvVars map[string]string
+
+	// Needs The name of the jobs that should succeed before this job runs.
+	Needs []string
+
+	// Timeout The maximum duration of the whole job. If zero, there's none.
+	Timeout time.Duration
 }
 
 type Task struct {
@@ -55,4 +63,16 @@ type Task struct {
 	// CommandsCfg is the configuration of the jobcmd to be executed.
 	// It includes the main binary, and the commands passed to it.
 	CommandsCfg []*commands.CMD
+
+	// Condition is the 'if' expression that decides whether the task runs.
+	Condition string
+
+	// Inputs are the values of the inputs of the manifest the task comes from.
+	Inputs map[string]interface{}
+
+	// Timeout The maximum duration of each attempt of the task. If zero, there's none.
+	Timeout time.Duration
+
+	// Retry If set, the whole task is retried, in a new container, when it fails.
+	Retry *retry.Policy
 }
@@ -0,0 +1,399 @@
+// Package expr implements the expressions of the 'if' fields of the manifests. It's
+// a small, and safe, language: it has no side effects, and it can only read the
+// variables of its Context. E.g.:
+//
+//	git.branch == 'main' && env.DEPLOY != ''
+//	failure() || inputs.environment == 'prod'
+//	always() && startsWith(git.tag, 'v')
+//
+// The operators are '==', '!=', '<', '<=', '>', '>=', '&&', '||' and '!', and the
+// functions are the status ones (success, failure and always), contains, startsWith,
+// endsWith and matches (a regular expression).
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Status functions. An expression that doesn't use any of them only runs if the
+// previous steps succeeded, as if it was 'success() && (expression)'.
+const (
+	FuncSuccess = "success"
+	FuncFailure = "failure"
+	FuncAlways  = "always"
+)
+
+// Namespaces of the variables available in the expressions.
+const (
+	NamespaceEnv    = "env"
+	NamespaceInputs = "inputs"
+	NamespaceGit    = "git"
+	NamespaceTasks  = "tasks"
+)
+
+// Context holds the variables that the expressions can read.
+type Context struct {
+	Env    map[string]string
+	Inputs map[string]interface{}
+	Git    map[string]interface{}
+	// Tasks holds the status (E.g.: 'success', 'failure' or 'skipped') of the previous
+	// tasks of the job, by name.
+	Tasks map[string]string
+	// Failed is set if any of the previous steps failed.
+	Failed bool
+}
+
+// Lazy is a variable computed only if an expression reads it. E.g.: 'git.dirty', which
+// hashes the files tracked by the repository.
+type Lazy func() interface{}
+
+// Expression is a parsed expression.
+type Expression struct {
+	source     string
+	root       node
+	usesStatus bool
+}
+
+// Parse parses the expression. It fails if its syntax is invalid, or if it uses
+// unknown functions, or namespaces.
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", source, err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected '%s' at position %d", p.peek().value, p.peek().pos)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", source, err)
+	}
+
+	return &Expression{source: source, root: root, usesStatus: p.usesStatus}, nil
+}
+
+// Evaluate parses, and evaluates the expression. An empty expression is true, unless
+// a previous step failed.
+func Evaluate(source string, ctx Context) (bool, error) {
+	if strings.TrimSpace(source) == "" {
+		return !ctx.Failed, nil
+	}
+
+	expression, err := Parse(source)
+	if err != nil {
+		return false, err
+	}
+
+	return expression.Evaluate(ctx)
+}
+
+// Evaluate evaluates the expression in the given context.
+func (e *Expression) Evaluate(ctx Context) (bool, error) {
+	value, err := e.root.eval(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot evaluate the expression '%s': %w", e.source, err)
+	}
+
+	if !e.usesStatus && ctx.Failed {
+		return false, nil
+	}
+
+	return truthy(value), nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+type node interface {
+	eval(ctx Context) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(Context) (interface{}, error) {
+	return n.value, nil
+}
+
+// referenceNode reads a variable. E.g.: 'env.HOME', or "tasks['plan-dev']".
+type referenceNode struct {
+	namespace string
+	keys      []string
+}
+
+func (n referenceNode) eval(ctx Context) (interface{}, error) {
+	var value interface{}
+
+	switch n.namespace {
+	case NamespaceEnv:
+		value = stringMap(ctx.Env)
+	case NamespaceInputs:
+		value = ctx.Inputs
+	case NamespaceGit:
+		value = ctx.Git
+	case NamespaceTasks:
+		value = stringMap(ctx.Tasks)
+	}
+
+	for _, key := range n.keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+
+		value = object[key]
+		if lazy, ok := value.(Lazy); ok {
+			value = lazy()
+		}
+	}
+
+	return value, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(ctx Context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return !truthy(value), nil
+}
+
+type logicalNode struct {
+	operator    string
+	left, right node
+}
+
+func (n logicalNode) eval(ctx Context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.operator == "&&" && !truthy(left) || n.operator == "||" && truthy(left) {
+		return truthy(left), nil
+	}
+
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return truthy(right), nil
+}
+
+type comparisonNode struct {
+	operator    string
+	left, right node
+}
+
+func (n comparisonNode) eval(ctx Context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	leftNumber, leftIsNumber := number(left)
+	rightNumber, rightIsNumber := number(right)
+
+	var comparison int
+	switch {
+	case leftIsNumber && rightIsNumber:
+		comparison = compareFloats(leftNumber, rightNumber)
+	default:
+		comparison = strings.Compare(toString(left), toString(right))
+	}
+
+	switch n.operator {
+	case "<":
+		return comparison < 0, nil
+	case "<=":
+		return comparison <= 0, nil
+	case ">":
+		return comparison > 0, nil
+	default:
+		return comparison >= 0, nil
+	}
+}
+
+type callNode struct {
+	function string
+	args     []node
+}
+
+// functionArity is the number of arguments of each function.
+var functionArity = map[string]int{
+	FuncSuccess:  0,
+	FuncFailure:  0,
+	FuncAlways:   0,
+	"contains":   2,
+	"startsWith": 2,
+	"endsWith":   2,
+	"matches":    2,
+}
+
+func (n callNode) eval(ctx Context) (interface{}, error) {
+	var args []interface{}
+	for _, arg := range n.args {
+		value, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	switch n.function {
+	case FuncSuccess:
+		return !ctx.Failed, nil
+	case FuncFailure:
+		return ctx.Failed, nil
+	case FuncAlways:
+		return true, nil
+	case "contains":
+		if list, ok := args[0].([]string); ok {
+			for _, item := range list {
+				if item == toString(args[1]) {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		}
+
+		if list, ok := args[0].([]interface{}); ok {
+			for _, item := range list {
+				if equal(item, args[1]) {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		}
+
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	case "startsWith":
+		return strings.HasPrefix(toString(args[0]), toString(args[1])), nil
+	case "endsWith":
+		return strings.HasSuffix(toString(args[0]), toString(args[1])), nil
+	default:
+		pattern, err := regexp.Compile(toString(args[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s': %w", toString(args[1]), err)
+		}
+
+		return pattern.MatchString(toString(args[0])), nil
+	}
+}
+
+func stringMap(values map[string]string) map[string]interface{} {
+	converted := map[string]interface{}{}
+	for key, value := range values {
+		converted[key] = value
+	}
+
+	return converted
+}
+
+// truthy converts a value into a bool: null, false, 0 and empty strings (and lists) are false.
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []string:
+		return len(v) != 0
+	case []interface{}:
+		return len(v) != 0
+	}
+
+	if n, ok := number(value); ok {
+		return n != 0
+	}
+
+	return true
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+
+	leftNumber, leftIsNumber := number(left)
+	rightNumber, rightIsNumber := number(right)
+	if leftIsNumber && rightIsNumber {
+		return leftNumber == rightNumber
+	}
+
+	leftBool, leftIsBool := left.(bool)
+	rightBool, rightIsBool := right.(bool)
+	if leftIsBool && rightIsBool {
+		return leftBool == rightBool
+	}
+
+	return toString(left) == toString(right)
+}
+
+// number converts the value into a number, if it's one (or a string that holds one).
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
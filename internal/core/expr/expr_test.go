@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	ctx := Context{
+		Env:    map[string]string{"DEPLOY": "true", "REPLICAS": "3"},
+		Inputs: map[string]interface{}{"environment": "prod", "regions": []string{"eu-west-1"}, "dry-run": false},
+		Git:    map[string]interface{}{"branch": "main", "tag": "v1.2.0", "dirty": false},
+		Tasks:  map[string]string{"plan-dev": "success"},
+	}
+
+	t.Run("should evaluate the expressions over the variables", func(t *testing.T) {
+		for _, expression := range []string{
+			"",
+			"git.branch == 'main'",
+			"env.DEPLOY && inputs.environment == \"prod\"",
+			"env.REPLICAS > 2 && env.REPLICAS <= 3",
+			"!inputs.dry-run && !git.dirty",
+			"startsWith(git.tag, 'v') && contains(inputs.regions, 'eu-west-1')",
+			"matches(git.tag, '^v[0-9]+\\.[0-9]+\\.[0-9]+$')",
+			"tasks['plan-dev'] == 'success'",
+			"env.MISSING == null || (git.branch != 'main')",
+			"success()",
+		} {
+			result, err := Evaluate(expression, ctx)
+
+			assert.NoError(t, err, expression)
+			assert.True(t, result, expression)
+		}
+
+		for _, expression := range []string{
+			"git.branch == 'develop'",
+			"env.MISSING",
+			"failure()",
+			"contains(inputs.regions, 'us-east-1')",
+		} {
+			result, err := Evaluate(expression, ctx)
+
+			assert.NoError(t, err, expression)
+			assert.False(t, result, expression)
+		}
+	})
+
+	t.Run("should only run after a failure if a status function is used", func(t *testing.T) {
+		failed := ctx
+		failed.Failed = true
+
+		for expression, expected := range map[string]bool{
+			"":                             false,
+			"git.branch == 'main'":         false,
+			"failure()":                    true,
+			"always()":                     true,
+			"failure() && env.DEPLOY":      true,
+			"success() || env.MISSING":     false,
+			"always() && git.tag == 'v99'": false,
+		} {
+			result, err := Evaluate(expression, failed)
+
+			assert.NoError(t, err, expression)
+			assert.Equal(t, expected, result, expression)
+		}
+	})
+
+	t.Run("should fail with an invalid expression", func(t *testing.T) {
+		for _, expression := range []string{
+			"git.branch ==",
+			"git.branch = 'main'",
+			"secrets.TOKEN",
+			"exec('rm -rf /')",
+			"contains(git.branch)",
+			"'unterminated",
+			"(git.branch == 'main'",
+		} {
+			_, err := Parse(expression)
+
+			assert.Error(t, err, expression)
+		}
+	})
+
+	t.Run("should only compute the lazy variables that are read", func(t *testing.T) {
+		reads := 0
+		lazyCtx := Context{Git: map[string]interface{}{"branch": "main", "dirty": Lazy(func() interface{} {
+			reads++
+			return true
+		})}}
+
+		result, err := Evaluate("git.branch == 'main'", lazyCtx)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Equal(t, 0, reads)
+
+		result, err = Evaluate("git.dirty", lazyCtx)
+		assert.NoError(t, err)
+		assert.True(t, result)
+		assert.Equal(t, 1, reads)
+	})
+}
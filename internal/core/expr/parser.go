@@ -0,0 +1,320 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// operators are sorted so the longest ones are matched first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '\'' || r == '"':
+			end := pos + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenString, value: string(runes[pos+1 : end]), pos: pos})
+			pos = end + 1
+		case unicode.IsDigit(r):
+			end := pos
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[pos:end]), pos: pos})
+			pos = end
+		case unicode.IsLetter(r) || r == '_':
+			end := pos
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) ||
+				runes[end] == '_' || runes[end] == '-') {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, value: string(runes[pos:end]), pos: pos})
+			pos = end
+		case strings.ContainsRune("().,[]", r):
+			tokens = append(tokens, token{kind: tokenPunct, value: string(r), pos: pos})
+			pos++
+		default:
+			matched := false
+			for _, operator := range operators {
+				if strings.HasPrefix(string(runes[pos:]), operator) {
+					tokens = append(tokens, token{kind: tokenOperator, value: operator, pos: pos})
+					pos += len([]rune(operator))
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, pos)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, value: "end of the expression", pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	usesStatus bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) accept(kind tokenKind, value string) bool {
+	if t := p.peek(); t.kind == kind && t.value == value {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, value string) error {
+	if !p.accept(kind, value) {
+		t := p.peek()
+		return fmt.Errorf("expected '%s', but found '%s' at position %d", value, t.value, t.pos)
+	}
+
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokenOperator, "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logicalNode{operator: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokenOperator, "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logicalNode{operator: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept(tokenOperator, "!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == tokenOperator {
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.next()
+
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+
+			return comparisonNode{operator: t.value, left: left, right: right}, nil
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return literalNode{value: t.value}, nil
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at position %d", t.value, t.pos)
+		}
+
+		return literalNode{value: value}, nil
+	case tokenPunct:
+		if t.value != "(" {
+			break
+		}
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenPunct, ")"); err != nil {
+			return nil, err
+		}
+
+		return inner, nil
+	case tokenIdent:
+		switch t.value {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		}
+
+		if p.accept(tokenPunct, "(") {
+			return p.parseCall(t)
+		}
+
+		return p.parseReference(t)
+	}
+
+	return nil, fmt.Errorf("unexpected '%s' at position %d", t.value, t.pos)
+}
+
+func (p *parser) parseCall(function token) (node, error) {
+	arity, ok := functionArity[function.value]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at position %d", function.value, function.pos)
+	}
+
+	switch function.value {
+	case FuncSuccess, FuncFailure, FuncAlways:
+		p.usesStatus = true
+	}
+
+	var args []node
+	for !p.accept(tokenPunct, ")") {
+		if len(args) != 0 {
+			if err := p.expect(tokenPunct, ","); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	if len(args) != arity {
+		return nil, fmt.Errorf("the function '%s' expects %d argument(s), but it got %d",
+			function.value, arity, len(args))
+	}
+
+	return callNode{function: function.value, args: args}, nil
+}
+
+func (p *parser) parseReference(namespace token) (node, error) {
+	switch namespace.value {
+	case NamespaceEnv, NamespaceInputs, NamespaceGit, NamespaceTasks:
+	default:
+		return nil, fmt.Errorf("unknown variable '%s' at position %d. Should be one of: %s", namespace.value,
+			namespace.pos, strings.Join([]string{NamespaceEnv, NamespaceInputs, NamespaceGit, NamespaceTasks}, ", "))
+	}
+
+	reference := referenceNode{namespace: namespace.value}
+
+	for {
+		switch {
+		case p.accept(tokenPunct, "."):
+			key := p.next()
+			if key.kind != tokenIdent {
+				return nil, fmt.Errorf("expected a name after '.', but found '%s' at position %d", key.value, key.pos)
+			}
+
+			reference.keys = append(reference.keys, key.value)
+		case p.accept(tokenPunct, "["):
+			key := p.next()
+			if key.kind != tokenString {
+				return nil, fmt.Errorf("expected a string inside '[]', but found '%s' at position %d",
+					key.value, key.pos)
+			}
+
+			if err := p.expect(tokenPunct, "]"); err != nil {
+				return nil, err
+			}
+
+			reference.keys = append(reference.keys, key.value)
+		default:
+			return reference, nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package imagelock
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// FileName is the name of the lockfile, written at the root of the repository.
+	FileName = "stiletto.lock"
+
+	// Version is the version of the format of the lockfile.
+	Version = 1
+
+	header = "# This file is generated by 'stiletto lock'. Do not edit it by hand.\n"
+)
+
+// Lockfile pins the container images referenced by the manifests to the digests they
+// resolved to.
+type Lockfile struct {
+	Version int                    `yaml:"version"`
+	Images  map[string]LockedImage `yaml:"images"`
+}
+
+// LockedImage is an image pinned to a digest.
+type LockedImage struct {
+	Digest string `yaml:"digest"`
+}
+
+// Drift is the difference between the images referenced by the manifests, and the ones
+// pinned in the lockfile.
+type Drift struct {
+	// Missing are the images referenced by the manifests, that aren't pinned.
+	Missing []string
+	// Stale are the images pinned, that aren't referenced by the manifests anymore.
+	Stale []string
+}
+
+// New returns an empty lockfile.
+func New() *Lockfile {
+	return &Lockfile{Version: Version, Images: map[string]LockedImage{}}
+}
+
+// Read reads the lockfile from the file passed.
+func Read(file string) (*Lockfile, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the lockfile %s: %w", file, err)
+	}
+
+	lock := New()
+	if err := yaml.Unmarshal(content, lock); err != nil {
+		return nil, fmt.Errorf("cannot decode the lockfile %s: %w", file, err)
+	}
+
+	if lock.Version != Version {
+		return nil, fmt.Errorf("unsupported version %d of the lockfile %s. Should be %d", lock.Version,
+			file, Version)
+	}
+
+	if lock.Images == nil {
+		lock.Images = map[string]LockedImage{}
+	}
+
+	for image, locked := range lock.Images {
+		if !digestPattern.MatchString(locked.Digest) {
+			return nil, fmt.Errorf("invalid digest '%s' of the image '%s' in the lockfile %s", locked.Digest,
+				image, file)
+		}
+	}
+
+	return lock, nil
+}
+
+// Write writes the lockfile to the file passed. The images are sorted, so the lockfile
+// only changes when the pins do.
+func (l *Lockfile) Write(file string) error {
+	var content bytes.Buffer
+	content.WriteString(header)
+
+	encoder := yaml.NewEncoder(&content)
+	encoder.SetIndent(4)
+
+	if err := encoder.Encode(l); err != nil {
+		return fmt.Errorf("cannot encode the lockfile %s: %w", file, err)
+	}
+
+	_ = encoder.Close()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("cannot write the lockfile %s: %w", file, err)
+	}
+
+	if err := os.WriteFile(file, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write the lockfile %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// Pinned returns the image pinned to its digest, if it's in the lockfile.
+func (l *Lockfile) Pinned(image string) (string, bool) {
+	locked, ok := l.Images[image]
+	if !ok {
+		return image, false
+	}
+
+	return Pin(image, locked.Digest), true
+}
+
+// Drift compares the images referenced by the manifests with the ones pinned.
+func (l *Lockfile) Drift(images []string) Drift {
+	var drift Drift
+
+	referenced := map[string]bool{}
+	for _, image := range images {
+		referenced[image] = true
+
+		if _, ok := l.Images[image]; !ok {
+			drift.Missing = append(drift.Missing, image)
+		}
+	}
+
+	for image := range l.Images {
+		if !referenced[image] {
+			drift.Stale = append(drift.Stale, image)
+		}
+	}
+
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Stale)
+
+	return drift
+}
+
+// IsEmpty returns true if the manifests and the lockfile match.
+func (d Drift) IsEmpty() bool {
+	return len(d.Missing) == 0 && len(d.Stale) == 0
+}
+
+// Lock pins the images passed. The images already pinned keep their digest, unless
+// update is set, and the images that aren't passed anymore are dropped.
+func (l *Lockfile) Lock(images []string, resolver Resolver, update bool) (*Lockfile, error) {
+	lock := New()
+
+	for _, image := range images {
+		if locked, ok := l.Images[image]; ok && !update {
+			lock.Images[image] = locked
+			continue
+		}
+
+		digest, err := resolver.Resolve(image)
+		if err != nil {
+			return nil, err
+		}
+
+		lock.Images[image] = LockedImage{Digest: digest}
+	}
+
+	return lock, nil
+}
@@ -0,0 +1,108 @@
+package imagelock
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeResolver struct {
+	digests  map[string]string
+	resolved []string
+}
+
+func (f *fakeResolver) Resolve(image string) (string, error) {
+	f.resolved = append(f.resolved, image)
+
+	digest, ok := f.digests[image]
+	if !ok {
+		return "", fmt.Errorf("unknown image '%s'", image)
+	}
+
+	return digest, nil
+}
+
+func TestLockfile(t *testing.T) {
+	oldDigest := "sha256:" + strings.Repeat("1", 64)
+	newDigest := "sha256:" + strings.Repeat("2", 64)
+
+	t.Run("should keep the pins, resolve the missing images and drop the stale ones", func(t *testing.T) {
+		lock := New()
+		lock.Images["alpine"] = LockedImage{Digest: oldDigest}
+		lock.Images["golang:1.20"] = LockedImage{Digest: oldDigest}
+
+		resolver := &fakeResolver{digests: map[string]string{"alpine": newDigest, "alpine/terragrunt": newDigest}}
+		locked, err := lock.Lock([]string{"alpine", "alpine/terragrunt"}, resolver, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alpine/terragrunt"}, resolver.resolved)
+		assert.Equal(t, map[string]LockedImage{
+			"alpine":            {Digest: oldDigest},
+			"alpine/terragrunt": {Digest: newDigest},
+		}, locked.Images)
+	})
+
+	t.Run("should refresh the pins on update", func(t *testing.T) {
+		lock := New()
+		lock.Images["alpine"] = LockedImage{Digest: oldDigest}
+
+		locked, err := lock.Lock([]string{"alpine"}, &fakeResolver{digests: map[string]string{"alpine": newDigest}}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, newDigest, locked.Images["alpine"].Digest)
+	})
+
+	t.Run("should report the drift", func(t *testing.T) {
+		lock := New()
+		lock.Images["alpine"] = LockedImage{Digest: oldDigest}
+		lock.Images["golang:1.20"] = LockedImage{Digest: oldDigest}
+
+		assert.True(t, lock.Drift([]string{"alpine", "golang:1.20"}).IsEmpty())
+		assert.Equal(t, Drift{Missing: []string{"alpine/terragrunt"}, Stale: []string{"golang:1.20"}},
+			lock.Drift([]string{"alpine", "alpine/terragrunt"}))
+	})
+
+	t.Run("should pin the images locked", func(t *testing.T) {
+		lock := New()
+		lock.Images["alpine:3.18"] = LockedImage{Digest: oldDigest}
+
+		pinned, ok := lock.Pinned("alpine:3.18")
+		assert.True(t, ok)
+		assert.Equal(t, "alpine:3.18@"+oldDigest, pinned)
+
+		pinned, ok = lock.Pinned("golang")
+		assert.False(t, ok)
+		assert.Equal(t, "golang", pinned)
+	})
+
+	t.Run("should write and read the lockfile", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), FileName)
+
+		lock := New()
+		lock.Images["alpine"] = LockedImage{Digest: oldDigest}
+		assert.NoError(t, lock.Write(file))
+
+		content, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, header+"version: 1\nimages:\n    alpine:\n        digest: "+oldDigest+"\n", string(content))
+
+		read, err := Read(file)
+		assert.NoError(t, err)
+		assert.Equal(t, lock, read)
+	})
+
+	t.Run("should fail with an invalid lockfile", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), FileName)
+
+		assert.NoError(t, os.WriteFile(file, []byte("version: 2\n"), 0644))
+		_, err := Read(file)
+		assert.ErrorContains(t, err, "unsupported version 2")
+
+		assert.NoError(t, os.WriteFile(file, []byte("version: 1\nimages:\n  alpine:\n    digest: latest\n"), 0644))
+		_, err = Read(file)
+		assert.ErrorContains(t, err, "invalid digest 'latest'")
+	})
+}
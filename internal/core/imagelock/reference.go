@@ -0,0 +1,93 @@
+package imagelock
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// dockerHubDomain is the registry of the images without a domain. E.g.: 'alpine'.
+	dockerHubDomain = "docker.io"
+	// dockerHubRegistry is the host that serves the registry API of Docker Hub.
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// Reference is a container image reference, split into its parts. E.g.:
+// 'ghcr.io/org/tool:1.0' or 'alpine/terragrunt' (whose domain is 'docker.io', and whose
+// tag is 'latest').
+type Reference struct {
+	Domain     string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference, following the normalization of Docker: an
+// image without a domain is pulled from Docker Hub (where the official images live in
+// the 'library' repository), and an image without a tag nor a digest is 'latest'.
+func ParseReference(image string) (*Reference, error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") || strings.Contains(image, "{{") {
+		return nil, fmt.Errorf("invalid image reference '%s'", image)
+	}
+
+	ref := &Reference{}
+
+	name, digest, hasDigest := strings.Cut(image, "@")
+	if hasDigest {
+		if !digestPattern.MatchString(digest) {
+			return nil, fmt.Errorf("invalid digest '%s' of the image '%s'. Should be 'sha256:<hex digest>'",
+				digest, image)
+		}
+
+		ref.Digest = digest
+	}
+
+	if slash := strings.LastIndex(name, "/"); strings.LastIndex(name, ":") > slash {
+		colon := strings.LastIndex(name, ":")
+		name, ref.Tag = name[:colon], name[colon+1:]
+	}
+
+	domain, repository, hasDomain := strings.Cut(name, "/")
+	if hasDomain && (strings.ContainsAny(domain, ".:") || domain == "localhost") {
+		ref.Domain, ref.Repository = domain, repository
+	} else {
+		ref.Domain, ref.Repository = dockerHubDomain, name
+	}
+
+	if ref.Domain == dockerHubDomain && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return nil, fmt.Errorf("invalid image reference '%s'. The repository should be lowercase", image)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	return ref, nil
+}
+
+// registryHost returns the host that serves the registry API of the domain.
+func (r *Reference) registryHost() string {
+	if r.Domain == dockerHubDomain {
+		return dockerHubRegistry
+	}
+
+	return r.Domain
+}
+
+// Pin returns the image reference, as it's written, pinned to the digest. E.g.:
+// 'alpine/terragrunt@sha256:...'. The tag is kept, so the reference stays readable.
+func Pin(image, digest string) string {
+	if name, _, found := strings.Cut(image, "@"); found {
+		image = name
+	}
+
+	return image + "@" + digest
+}
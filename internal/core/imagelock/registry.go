@@ -0,0 +1,211 @@
+package imagelock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// registryTimeout limits each request to a registry.
+const registryTimeout = 30 * time.Second
+
+// manifestMediaTypes are the media types of the manifests accepted, where the indexes
+// (multi-platform images) come first, so the digest is the same on every platform.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver resolves an image reference to the digest of its manifest.
+type Resolver interface {
+	Resolve(image string) (string, error)
+}
+
+// RegistryResolver resolves the digests through the registry API (the OCI distribution
+// spec), over https. The registries that require a token get an anonymous one, so only
+// public images (or registries without authentication) are supported.
+type RegistryResolver struct {
+	client *http.Client
+}
+
+// NewRegistryResolver returns a registry resolver. If the client is nil, a default one is used.
+func NewRegistryResolver(client *http.Client) *RegistryResolver {
+	if client == nil {
+		client = &http.Client{Timeout: registryTimeout}
+	}
+
+	return &RegistryResolver{client: client}
+}
+
+func (r *RegistryResolver) Resolve(image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registryHost(), ref.Repository, ref.Tag)
+
+	// A HEAD request is enough if the registry returns the digest, without downloading
+	// the manifest (nor counting against the pull limits of some registries).
+	response, err := r.request(http.MethodHead, manifestURL, ref)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve the digest of the image '%s': %w", image, err)
+	}
+
+	_ = response.Body.Close()
+
+	digest := response.Header.Get("Docker-Content-Digest")
+	if response.StatusCode == http.StatusOK && digestPattern.MatchString(digest) {
+		return digest, nil
+	}
+
+	response, err = r.request(http.MethodGet, manifestURL, ref)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve the digest of the image '%s': %w", image, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cannot resolve the digest of the image '%s'. The registry %s returned: %s",
+			image, ref.registryHost(), response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve the digest of the image '%s': %w", image, err)
+	}
+
+	sum := sha256.Sum256(content)
+
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// request sends a request to the registry. If the registry requires a token, it's
+// requested (anonymously) to the realm the registry points to, and the request is retried.
+func (r *RegistryResolver) request(method, manifestURL string, ref *Reference) (*http.Response, error) {
+	response, err := r.send(method, manifestURL, "")
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	_ = response.Body.Close()
+
+	challenge := response.Header.Get("WWW-Authenticate")
+	token, err := r.token(challenge, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.send(method, manifestURL, token)
+}
+
+func (r *RegistryResolver) send(method, manifestURL, token string) (*http.Response, error) {
+	request, err := http.NewRequest(method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return r.client.Do(request)
+}
+
+// token requests an anonymous token, following a challenge like:
+// 'Bearer realm="https://auth.docker.io/token",service="registry.docker.io"'.
+func (r *RegistryResolver) token(challenge string, ref *Reference) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("the registry %s requires an authentication that isn't supported: '%s'",
+			ref.registryHost(), challenge)
+	}
+
+	values := parseChallengeParams(params)
+	if values["realm"] == "" {
+		return "", fmt.Errorf("the registry %s requires a token, but it doesn't declare its realm",
+			ref.registryHost())
+	}
+
+	tokenURL, err := url.Parse(values["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid token realm '%s' of the registry %s: %w", values["realm"],
+			ref.registryHost(), err)
+	}
+
+	query := tokenURL.Query()
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+
+	scope := values["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	response, err := r.client.Get(tokenURL.String())
+	if err != nil {
+		return "", fmt.Errorf("cannot get a token of the registry %s: %w", ref.registryHost(), err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cannot get a token of the registry %s: %s", ref.registryHost(), response.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("cannot decode the token of the registry %s: %w", ref.registryHost(), err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+// parseChallengeParams parses the 'key="value"' parameters of a challenge, separated by commas.
+func parseChallengeParams(params string) map[string]string {
+	values := map[string]string{}
+
+	for params != "" {
+		var key, value string
+
+		key, params, _ = strings.Cut(params, "=")
+		key = strings.ToLower(strings.TrimSpace(strings.TrimLeft(key, ", ")))
+
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+
+		params = strings.TrimLeft(params, ", ")
+		values[key] = value
+	}
+
+	return values
+}
@@ -0,0 +1,130 @@
+package imagelock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`
+
+// newTestRegistry returns a registry that serves the 'team/tool:1.0' image, and requires
+// a token (which is anonymous) to pull it, as Docker Hub does.
+func newTestRegistry(t *testing.T, withDigestHeader bool) (*httptest.Server, string) {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(testManifest))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "repository:team/tool:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/v2/team/tool/manifests/1.0" {
+			http.NotFound(w, r)
+			return
+		}
+
+		assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+
+		if withDigestHeader {
+			w.Header().Set("Docker-Content-Digest", digest)
+		}
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(testManifest))
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, digest
+}
+
+func TestParseReference(t *testing.T) {
+	t.Run("should normalize the images of Docker Hub", func(t *testing.T) {
+		ref, err := ParseReference("alpine")
+		assert.NoError(t, err)
+		assert.Equal(t, &Reference{Domain: "docker.io", Repository: "library/alpine", Tag: "latest"}, ref)
+		assert.Equal(t, "registry-1.docker.io", ref.registryHost())
+
+		ref, err = ParseReference("alpine/terragrunt:1.5")
+		assert.NoError(t, err)
+		assert.Equal(t, &Reference{Domain: "docker.io", Repository: "alpine/terragrunt", Tag: "1.5"}, ref)
+	})
+
+	t.Run("should parse the domain, including its port", func(t *testing.T) {
+		ref, err := ParseReference("localhost:5000/team/tool")
+		assert.NoError(t, err)
+		assert.Equal(t, &Reference{Domain: "localhost:5000", Repository: "team/tool", Tag: "latest"}, ref)
+	})
+
+	t.Run("should parse the digest", func(t *testing.T) {
+		digest := "sha256:" + strings.Repeat("a", 64)
+
+		ref, err := ParseReference("ghcr.io/org/tool:1.0@" + digest)
+		assert.NoError(t, err)
+		assert.Equal(t, &Reference{Domain: "ghcr.io", Repository: "org/tool", Tag: "1.0", Digest: digest}, ref)
+	})
+
+	t.Run("should fail with an invalid reference", func(t *testing.T) {
+		for _, image := range []string{"", "Alpine", "alpine@sha256:123", "{{ .Matrix.image }}"} {
+			_, err := ParseReference(image)
+			assert.Error(t, err, image)
+		}
+	})
+}
+
+func TestRegistryResolver(t *testing.T) {
+	t.Run("should resolve the digest from the header, getting a token", func(t *testing.T) {
+		server, digest := newTestRegistry(t, true)
+		image := strings.TrimPrefix(server.URL, "https://") + "/team/tool:1.0"
+
+		resolved, err := NewRegistryResolver(server.Client()).Resolve(image)
+
+		assert.NoError(t, err)
+		assert.Equal(t, digest, resolved)
+	})
+
+	t.Run("should hash the manifest, if the registry doesn't return its digest", func(t *testing.T) {
+		server, digest := newTestRegistry(t, false)
+		image := strings.TrimPrefix(server.URL, "https://") + "/team/tool:1.0"
+
+		resolved, err := NewRegistryResolver(server.Client()).Resolve(image)
+
+		assert.NoError(t, err)
+		assert.Equal(t, digest, resolved)
+	})
+
+	t.Run("should fail if the image doesn't exist", func(t *testing.T) {
+		server, _ := newTestRegistry(t, true)
+		image := strings.TrimPrefix(server.URL, "https://") + "/team/tool:9.9"
+
+		_, err := NewRegistryResolver(server.Client()).Resolve(image)
+
+		assert.ErrorContains(t, err, "404")
+	})
+
+	t.Run("should return the digest of an image already pinned", func(t *testing.T) {
+		digest := "sha256:" + strings.Repeat("b", 64)
+
+		resolved, err := NewRegistryResolver(nil).Resolve("alpine@" + digest)
+
+		assert.NoError(t, err)
+		assert.Equal(t, digest, resolved)
+	})
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"github.com/excoriate/stiletto/internal/core/commands"
 	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/retry"
 	"github.com/excoriate/stiletto/internal/core/validation"
 	"github.com/excoriate/stiletto/internal/errors"
 	"github.com/excoriate/stiletto/internal/utils"
 	"go.uber.org/zap"
 	"path/filepath"
+	"time"
 )
 
 type Builder struct {
@@ -29,18 +31,34 @@ type TaskNewArgs struct {
 	Name           string
 	ContainerImage string
 	Commands       []TaskNewCMDArgs
-	BaseDir        string // Equivalent to the current Dir
-	WorkDir        string // Equivalent to the directory that'll be used to perform tasks.
-	MountDir       string // The directory that'll be mounted in the container.
+	BaseDir        string      // Equivalent to the current Dir
+	WorkDir        string      // Equivalent to the directory that'll be used to perform tasks.
+	MountDir       string      // The directory that'll be mounted in the container.
+	Matrix         *MatrixArgs // If set, the task is expanded into a task per combination of the matrix.
+	Condition      string      // The 'if' expression that decides whether the task runs.
+	Inputs         map[string]interface{}
+	Timeout        time.Duration // Maximum duration of each attempt of the task. If zero, there's none.
+	Retry          *retry.Policy // If set, the whole task is retried when it fails.
 }
 
 type TaskNewCMDArgs struct {
-	Binary      string
-	CommandArgs string
+	Binary           string
+	CommandArgs      string
+	Condition        string        // The 'if' expression that decides whether the command runs.
+	Timeout          time.Duration // Maximum duration of each attempt of the command. If zero, there's none.
+	Retry            *retry.Policy // If set, the command is retried when it fails.
+	Script           string        // If set, the command is a script run by the shell, instead of a binary and its arguments.
+	ScriptFile       string        // If set, the command is a script file, relative to the base directory, run by the shell.
+	Shell            string        // The shell that runs the script. E.g.: 'sh', or 'bash'.
+	ContinueOnError  bool          // If set, a failure of the command is recorded as a warning.
+	AllowedExitCodes []int         // Non-zero exit codes recorded as warnings, instead of failures.
+	Isolated         bool          // If set, the changes of the command aren't passed to the next ones.
 }
 
 type NewArgs struct {
-	Name string
+	Name    string
+	Needs   []string      // Name of the jobs that should succeed before this one runs.
+	Timeout time.Duration // Maximum duration of the whole job. If zero, there's none.
 }
 
 func (b *Builder) Build() (*entities.Job, error) {
@@ -56,6 +74,8 @@ func (b *Builder) Build() (*entities.Job, error) {
 		BaseDir:    b.baseDir,
 		BaseDirAbs: b.baseDirAbs,
 		EnvVars:    b.envVars,
+		Needs:      b.job.Needs,
+		Timeout:    b.job.Timeout,
 	}, nil
 }
 
@@ -69,128 +89,180 @@ func (b *Builder) WithTasks(opts []TaskNewArgs,
 	}
 
 	for _, task := range opts {
-		if task.ContainerImage == "" {
-			taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'containerImage' argument is required for task '%s' with id '%s'.", task.Name, b.id), nil)
-			b.client.Logger.Error(taskErr.Error())
-			b.error = taskErr
-			return b
-		}
-
-		// Task directories validation.
-		workDir := task.WorkDir
-		baseDir := task.BaseDir
-		mountDir := task.MountDir
-
-		if filepath.IsAbs(workDir) {
-			taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'workDir' argument cannot be an absolute path: %s", workDir), nil)
-			b.client.Logger.Error(taskErr.Error())
-			b.error = taskErr
-			return b
-		}
-
-		if filepath.IsAbs(mountDir) {
-			taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'mountDir' argument cannot be an absolute path: %s", mountDir), nil)
-			b.client.Logger.Error(taskErr.Error())
-			b.error = taskErr
-			return b
-		}
-
-		if baseDir == "" {
-			b.logger.Warn(fmt.Sprintf("No taskBaseDir found for task '%s' with id '%s'. "+
-				"It'll be replaced by the base directory set at the job level '%s'.", task.Name,
-				b.id, b.baseDir))
-
-			baseDir = b.job.BaseDirAbs
-		}
+		if task.Matrix == nil {
+			if err := b.addTask(task, envVarsOpt); err != nil {
+				b.error = err
+				return b
+			}
 
-		if !filepath.IsAbs(baseDir) {
-			taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'baseDir' argument must be an absolute path: %s", baseDir), nil)
-			b.client.Logger.Error(taskErr.Error())
-			b.error = taskErr
-			return b
+			continue
 		}
 
-		if err := validation.WorkDirIsValid(validation.WorkDirIsValidArgs{
-			BaseDir:  baseDir,
-			WorkDir:  workDir,
-			MountDir: mountDir,
-		}); err != nil {
+		combinations, err := task.Matrix.Combinations(matrixGlobDir(task, b.job.BaseDirAbs))
+		if err != nil {
 			taskErr := errors.NewTaskConfigurationError(fmt.Sprintf(
-				"Cannot configure task '%s' with id '%s', ", task.Name, b.id), err)
+				"Cannot expand the matrix of the task '%s' with id '%s'.", task.Name, b.id), err)
 			b.client.Logger.Error(taskErr.Error())
 			b.error = taskErr
 
 			return b
 		}
 
-		b.logger.Info(fmt.Sprintf("Configuring task '%s' with id '%s'.", task.Name, b.id))
-		taskId := utils.GetUUID()
-
-		// Env vars for the task.
-		var taskEnvVars map[string]string
-		if envVarsOpt.InheritEnvVarsFromJob {
-			jobEnvVars := b.job.EnvVars
-			if utils.MapIsNulOrEmpty(jobEnvVars) {
-				b.client.Logger.Warn(fmt.Sprintf("No env vars found for job '%s' with id '%s', "+
-					"it means this task %s marked to inherit env vars from job will not have any ", b.job.Name, b.job.Id, task.Name))
-			} else {
-				b.client.Logger.Info(fmt.Sprintf(
-					"Inheriting env vars from job '%s' with id '%s' in task '%s' with id '%s'.", b.job.Name, b.job.Id, task.Name, taskId))
-				taskEnvVars = jobEnvVars
-			}
-		} else {
-			tempEnvVars, err := DecorateWithEnvVars(envVarsOpt)
+		b.logger.Info(fmt.Sprintf("Expanding the matrix of the task '%s' into %d task(s).", task.Name,
+			len(combinations)))
+
+		for _, combination := range combinations {
+			matrixTask, matrixEnvVarsOpt, err := expandMatrixTask(task, envVarsOpt, combination)
 			if err != nil {
-				taskErr := errors.NewArgumentError(fmt.Sprintf("Error decorating env vars for task '%s' with id '%s'.", task.Name, taskId), err)
+				taskErr := errors.NewTaskConfigurationError(fmt.Sprintf(
+					"Cannot render the matrix values of the task '%s' with id '%s'.", task.Name, b.id), err)
 				b.client.Logger.Error(taskErr.Error())
 				b.error = taskErr
 
 				return b
 			}
 
-			b.client.Logger.Info(fmt.Sprintf("Decorating env vars for task '%s' with id '%s'.", task.Name, taskId))
-			taskEnvVars = tempEnvVars
+			if err := b.addTask(matrixTask, matrixEnvVarsOpt); err != nil {
+				b.error = err
+				return b
+			}
 		}
+	}
 
-		// Building the required commands for the task.
-		var taskCommands []*commands.CMD
-		if len(task.Commands) != 0 {
-			cmdBuilder := commands.NewCMD()
-			for _, cmd := range task.Commands {
-				newCMD, cmdErr := cmdBuilder.WithBinary(cmd.Binary).WithCommands(cmd.CommandArgs).
-					Build()
+	return b
+}
 
-				if cmdErr != nil {
-					taskErr := errors.NewArgumentError(fmt.Sprintf(
-						"Error building jobcmd for task '%s' with id '%s'.", task.Name, taskId), cmdErr)
-					b.client.Logger.Error(taskErr.Error())
-					b.error = taskErr
+// addTask validates the task, and adds it to the job.
+func (b *Builder) addTask(task TaskNewArgs, envVarsOpt EnvVarsOptions) error {
+	if task.ContainerImage == "" {
+		taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'containerImage' argument is required for task '%s' with id '%s'.", task.Name, b.id), nil)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
 
-					return b
-				}
+	// Task directories validation.
+	workDir := task.WorkDir
+	baseDir := task.BaseDir
+	mountDir := task.MountDir
 
-				taskCommands = append(taskCommands, newCMD)
-			}
+	if filepath.IsAbs(workDir) {
+		taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'workDir' argument cannot be an absolute path: %s", workDir), nil)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
+
+	if filepath.IsAbs(mountDir) {
+		taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'mountDir' argument cannot be an absolute path: %s", mountDir), nil)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
+
+	if baseDir == "" {
+		b.logger.Warn(fmt.Sprintf("No taskBaseDir found for task '%s' with id '%s'. "+
+			"It'll be replaced by the base directory set at the job level '%s'.", task.Name,
+			b.id, b.baseDir))
+
+		baseDir = b.job.BaseDirAbs
+	}
+
+	if !filepath.IsAbs(baseDir) {
+		taskErr := errors.NewTaskConfigurationError(fmt.Sprintf("The 'baseDir' argument must be an absolute path: %s", baseDir), nil)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
+
+	if err := validation.WorkDirIsValid(validation.WorkDirIsValidArgs{
+		BaseDir:  baseDir,
+		WorkDir:  workDir,
+		MountDir: mountDir,
+	}); err != nil {
+		taskErr := errors.NewTaskConfigurationError(fmt.Sprintf(
+			"Cannot configure task '%s' with id '%s', ", task.Name, b.id), err)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
+
+	b.logger.Info(fmt.Sprintf("Configuring task '%s' with id '%s'.", task.Name, b.id))
+	taskId := utils.GetUUID()
+
+	// Env vars for the task.
+	taskEnvVars, err := DecorateWithEnvVars(envVarsOpt)
+	if err != nil {
+		taskErr := errors.NewArgumentError(fmt.Sprintf("Error decorating env vars for task '%s' with id '%s'.", task.Name, taskId), err)
+		b.client.Logger.Error(taskErr.Error())
+		return taskErr
+	}
+
+	b.client.Logger.Info(fmt.Sprintf("Decorating env vars for task '%s' with id '%s'.", task.Name, taskId))
+
+	// The env vars set explicitly in the task take precedence over the inherited ones.
+	if envVarsOpt.InheritEnvVarsFromJob {
+		jobEnvVars := b.job.EnvVars
+		if utils.MapIsNulOrEmpty(jobEnvVars) {
+			b.client.Logger.Warn(fmt.Sprintf("No env vars found for job '%s' with id '%s', "+
+				"it means this task %s marked to inherit env vars from job will not have any ", b.job.Name, b.job.Id, task.Name))
+		} else {
+			b.client.Logger.Info(fmt.Sprintf(
+				"Inheriting env vars from job '%s' with id '%s' in task '%s' with id '%s'.", b.job.Name, b.job.Id, task.Name, taskId))
+			taskEnvVars = utils.MergeEnvVars(jobEnvVars, taskEnvVars)
 		}
+	}
 
-		b.tasks = append(b.tasks, entities.Task{
-			Id:             taskId,
-			Name:           task.Name,
-			ContainerImage: task.ContainerImage,
-			Workdir:        workDir,
-			MountDir:       mountDir,
-			BaseDir:        baseDir,
-			BaseDirAbs:     baseDir,
-			EnvVars:        taskEnvVars,
-			CommandsCfg:    taskCommands,
-		})
-
-		b.client.Logger.Info(fmt.Sprintf("Task '%s' with id '%s' added to the job '%s' with id"+
-			" '%s'.", task.Name, taskId, b.job.Name, b.job.Id))
+	// Building the required commands for the task.
+	var taskCommands []*commands.CMD
+	if len(task.Commands) != 0 {
+		for _, cmd := range task.Commands {
+			cmdBuilder := commands.NewCMD().WithBinary(cmd.Binary)
+
+			switch {
+			case cmd.Script != "":
+				cmdBuilder = cmdBuilder.WithScript(cmd.Shell, cmd.Script)
+			case cmd.ScriptFile != "":
+				cmdBuilder = cmdBuilder.WithScriptFile(cmd.Shell, cmd.ScriptFile)
+			default:
+				cmdBuilder = cmdBuilder.WithCommands(cmd.CommandArgs)
+			}
 
+			newCMD, cmdErr := cmdBuilder.
+				WithCondition(cmd.Condition).
+				WithTimeout(cmd.Timeout).
+				WithRetry(cmd.Retry).
+				WithContinueOnError(cmd.ContinueOnError).
+				WithAllowedExitCodes(cmd.AllowedExitCodes).
+				WithIsolated(cmd.Isolated).
+				Build()
+
+			if cmdErr != nil {
+				taskErr := errors.NewArgumentError(fmt.Sprintf(
+					"Error building jobcmd for task '%s' with id '%s'.", task.Name, taskId), cmdErr)
+				b.client.Logger.Error(taskErr.Error())
+				return taskErr
+			}
+
+			taskCommands = append(taskCommands, newCMD)
+		}
 	}
 
-	return b
+	b.tasks = append(b.tasks, entities.Task{
+		Id:             taskId,
+		Name:           task.Name,
+		ContainerImage: task.ContainerImage,
+		Workdir:        workDir,
+		MountDir:       mountDir,
+		BaseDir:        baseDir,
+		BaseDirAbs:     baseDir,
+		EnvVars:        taskEnvVars,
+		CommandsCfg:    taskCommands,
+		Condition:      task.Condition,
+		Inputs:         task.Inputs,
+		Timeout:        task.Timeout,
+		Retry:          task.Retry,
+	})
+
+	b.client.Logger.Info(fmt.Sprintf("Task '%s' with id '%s' added to the job '%s' with id"+
+		" '%s'.", task.Name, taskId, b.job.Name, b.job.Id))
+
+	return nil
 }
 
 // WithJob creates a new Dagger job.
@@ -221,6 +293,8 @@ func (b *Builder) WithJob(args NewArgs,
 		BaseDir:    b.client.CfgDir.BaseDir,
 		BaseDirAbs: b.client.CfgDir.BaseDirAbs,
 		EnvVars:    jobEnvVars,
+		Needs:      args.Needs,
+		Timeout:    args.Timeout,
 	}
 
 	b.job = &job
@@ -0,0 +1,349 @@
+package job
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/utils"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// MatrixEnvVarPrefix is the prefix of the env vars that hold the values of the matrix,
+// in each task expanded out of it. E.g.: 'MATRIX_IMAGE'.
+const MatrixEnvVarPrefix = "MATRIX_"
+
+// MatrixArgs fans a task out over the combinations of the values of its axes.
+type MatrixArgs struct {
+	// Axes are the variables of the matrix, in the order they're declared.
+	Axes []MatrixAxis
+	// Include adds variables to the combinations that match them, or new combinations.
+	Include []map[string]string
+	// Exclude removes the combinations that match any of them.
+	Exclude []map[string]string
+}
+
+// MatrixAxis is a variable of the matrix, with a list of values, or computed out
+// of a glob pattern.
+type MatrixAxis struct {
+	Name   string
+	Values []string
+	// Glob is matched relative to the mountDir of the task. See utils.GlobFiles.
+	Glob string
+	// Dirname takes, as the values, the directories of the paths matched by the glob.
+	Dirname bool
+}
+
+// MatrixCombination is one of the combinations of the values of a matrix.
+type MatrixCombination struct {
+	// Keys are the variables of the combination, in order.
+	Keys   []string
+	Values map[string]string
+}
+
+var envVarNameInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// Combinations returns the combinations of the values of the matrix: the cartesian
+// product of its axes, without the excluded ones, and with the included ones. The
+// axes computed out of a glob are matched in the given directory. An include that
+// doesn't change any of the values of a combination that matches it extends it, and
+// if there's none, it's added as a new combination.
+func (m *MatrixArgs) Combinations(globDir string) ([]MatrixCombination, error) {
+	combinations := []MatrixCombination{{Values: map[string]string{}}}
+
+	for _, axis := range m.Axes {
+		values, err := axis.resolveValues(globDir)
+		if err != nil {
+			return nil, err
+		}
+
+		var expanded []MatrixCombination
+		for _, combination := range combinations {
+			for _, value := range values {
+				expanded = append(expanded, combination.with(axis.Name, value))
+			}
+		}
+
+		combinations = expanded
+	}
+
+	if len(m.Axes) == 0 {
+		combinations = nil
+	}
+
+	var kept []MatrixCombination
+	for _, combination := range combinations {
+		excluded := false
+		for _, exclude := range m.Exclude {
+			if combination.matches(exclude) {
+				excluded = true
+				break
+			}
+		}
+
+		if !excluded {
+			kept = append(kept, combination)
+		}
+	}
+
+	combinations = kept
+
+	axisNames := map[string]bool{}
+	for _, axis := range m.Axes {
+		axisNames[axis.Name] = true
+	}
+
+	for _, include := range m.Include {
+		extended := false
+
+		for idx, combination := range combinations {
+			if !combination.accepts(include, axisNames) {
+				continue
+			}
+
+			for _, key := range sortedKeys(include) {
+				combination = combination.with(key, include[key])
+			}
+
+			combinations[idx] = combination
+			extended = true
+		}
+
+		if !extended {
+			combination := MatrixCombination{Values: map[string]string{}}
+			for _, key := range sortedKeys(include) {
+				combination = combination.with(key, include[key])
+			}
+
+			combinations = append(combinations, combination)
+		}
+	}
+
+	if len(combinations) == 0 {
+		return nil, fmt.Errorf("the matrix has no combinations left")
+	}
+
+	return combinations, nil
+}
+
+func (a MatrixAxis) resolveValues(globDir string) ([]string, error) {
+	if a.Glob == "" {
+		if len(a.Values) == 0 {
+			return nil, fmt.Errorf("the matrix axis '%s' has no values", a.Name)
+		}
+
+		return a.Values, nil
+	}
+
+	matches, err := utils.GlobFiles(globDir, a.Glob)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute the matrix axis '%s': %w", a.Name, err)
+	}
+
+	var values []string
+	seen := map[string]bool{}
+	for _, match := range matches {
+		if a.Dirname {
+			match = path.Dir(match)
+		}
+
+		if !seen[match] {
+			seen[match] = true
+			values = append(values, match)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("the glob '%s' of the matrix axis '%s' didn't match any path in %s",
+			a.Glob, a.Name, globDir)
+	}
+
+	return values, nil
+}
+
+// with returns a copy of the combination, with the variable set.
+func (c MatrixCombination) with(key, value string) MatrixCombination {
+	keys := append([]string{}, c.Keys...)
+	values := map[string]string{}
+	for existing, existingValue := range c.Values {
+		values[existing] = existingValue
+	}
+
+	if _, ok := values[key]; !ok {
+		keys = append(keys, key)
+	}
+
+	values[key] = value
+
+	return MatrixCombination{Keys: keys, Values: values}
+}
+
+// matches reports whether the combination has every variable of the filter, with the same value.
+func (c MatrixCombination) matches(filter map[string]string) bool {
+	for key, value := range filter {
+		if current, ok := c.Values[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accepts reports whether the include can extend the combination, since it doesn't
+// change the value of any of its axes.
+func (c MatrixCombination) accepts(include map[string]string, axisNames map[string]bool) bool {
+	for key, value := range include {
+		if current, ok := c.Values[key]; ok && axisNames[key] && current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Name returns the name of the task expanded out of the combination. E.g.: 'build (alpine, 1.5)'.
+func (c MatrixCombination) Name(taskName string) string {
+	var values []string
+	for _, key := range c.Keys {
+		values = append(values, c.Values[key])
+	}
+
+	return fmt.Sprintf("%s (%s)", taskName, strings.Join(values, ", "))
+}
+
+// EnvVars returns the variables of the combination as env vars. E.g.: 'MATRIX_IMAGE'.
+func (c MatrixCombination) EnvVars() map[string]string {
+	envVars := map[string]string{}
+	for key, value := range c.Values {
+		name := envVarNameInvalidChars.ReplaceAllString(strings.ToUpper(key), "_")
+		envVars[MatrixEnvVarPrefix+name] = value
+	}
+
+	return envVars
+}
+
+// render renders the '{{ .Matrix.name }}' template variables of the value. The manifests
+// only reference them as they are (see specs.validateMatrixReferences), so no function is needed.
+func (c MatrixCombination) render(value string) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	compiled, err := utils.CompileTemplate(utils.TemplateCompilationOpts{
+		TemplateContent: value,
+		TemplateName:    "matrix",
+		Data:            map[string]interface{}{"Matrix": c.Values},
+		FuncMap:         template.FuncMap{},
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return compiled.String(), nil
+}
+
+// expandMatrixTask returns the task, and its env vars options, that correspond to a
+// combination of its matrix. The values of the combination are rendered in its fields,
+// and set as env vars.
+func expandMatrixTask(task TaskNewArgs, envVarsOpt EnvVarsOptions,
+	combination MatrixCombination) (TaskNewArgs, EnvVarsOptions, error) {
+	expanded := task
+	expanded.Name = combination.Name(task.Name)
+	expanded.Matrix = nil
+
+	var err error
+	for _, field := range []*string{&expanded.ContainerImage, &expanded.WorkDir, &expanded.MountDir,
+		&expanded.BaseDir} {
+		if *field, err = combination.render(*field); err != nil {
+			return TaskNewArgs{}, EnvVarsOptions{}, err
+		}
+	}
+
+	expanded.Commands = nil
+	for _, cmd := range task.Commands {
+		binary, err := combination.render(cmd.Binary)
+		if err != nil {
+			return TaskNewArgs{}, EnvVarsOptions{}, err
+		}
+
+		commandArgs, err := combination.render(cmd.CommandArgs)
+		if err != nil {
+			return TaskNewArgs{}, EnvVarsOptions{}, err
+		}
+
+		expandedCMD := cmd
+		expandedCMD.Binary = binary
+		expandedCMD.CommandArgs = commandArgs
+
+		for _, field := range []*string{&expandedCMD.Script, &expandedCMD.ScriptFile} {
+			if *field, err = combination.render(*field); err != nil {
+				return TaskNewArgs{}, EnvVarsOptions{}, err
+			}
+		}
+		expanded.Commands = append(expanded.Commands, expandedCMD)
+	}
+
+	expandedEnvVarsOpt := envVarsOpt
+	expandedEnvVarsOpt.EnvVarsExplicit = combination.EnvVars()
+	for name, value := range envVarsOpt.EnvVarsExplicit {
+		if expandedEnvVarsOpt.EnvVarsExplicit[name], err = combination.render(value); err != nil {
+			return TaskNewArgs{}, EnvVarsOptions{}, err
+		}
+	}
+
+	return expanded, expandedEnvVarsOpt, nil
+}
+
+// ContainerImages returns the container images the task runs on: one per combination of
+// its matrix (without duplicates), or its own image if it has no matrix. The glob axes of
+// the matrix are matched as the job builder does, from the base directory of the job.
+func (t TaskNewArgs) ContainerImages(jobBaseDir string) ([]string, error) {
+	if t.Matrix == nil {
+		return []string{t.ContainerImage}, nil
+	}
+
+	combinations, err := t.Matrix.Combinations(matrixGlobDir(t, jobBaseDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	seen := map[string]bool{}
+	for _, combination := range combinations {
+		image, err := combination.render(t.ContainerImage)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+
+	return images, nil
+}
+
+// matrixGlobDir returns the directory where the glob axes of the matrix of the task are matched.
+func matrixGlobDir(task TaskNewArgs, jobBaseDir string) string {
+	baseDir := task.BaseDir
+	if baseDir == "" {
+		baseDir = jobBaseDir
+	}
+
+	return filepath.Join(baseDir, task.MountDir)
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,143 @@
+package job
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func combinationNames(combinations []MatrixCombination) []string {
+	var names []string
+	for _, combination := range combinations {
+		names = append(names, combination.Name("plan"))
+	}
+
+	return names
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	t.Run("should combine the axes, without the excluded combinations", func(t *testing.T) {
+		matrix := &MatrixArgs{
+			Axes: []MatrixAxis{
+				{Name: "image", Values: []string{"alpine", "debian"}},
+				{Name: "terraform", Values: []string{"1.5", "1.6"}},
+			},
+			Exclude: []map[string]string{{"image": "debian", "terraform": "1.5"}},
+		}
+
+		combinations, err := matrix.Combinations("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"plan (alpine, 1.5)", "plan (alpine, 1.6)", "plan (debian, 1.6)"},
+			combinationNames(combinations))
+	})
+
+	t.Run("should extend the matching combinations, or add new ones with the includes", func(t *testing.T) {
+		matrix := &MatrixArgs{
+			Axes: []MatrixAxis{{Name: "image", Values: []string{"alpine", "debian"}}},
+			Include: []map[string]string{
+				{"image": "debian", "shell": "bash"},
+				{"image": "ubuntu"},
+			},
+		}
+
+		combinations, err := matrix.Combinations("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"plan (alpine)", "plan (debian, bash)", "plan (ubuntu)"},
+			combinationNames(combinations))
+		assert.Equal(t, map[string]string{"MATRIX_IMAGE": "debian", "MATRIX_SHELL": "bash"},
+			combinations[1].EnvVars())
+	})
+
+	t.Run("should compute an axis out of a glob", func(t *testing.T) {
+		root := t.TempDir()
+		for _, dir := range []string{"live/prod/vpc", "live/dev/vpc", "modules/vpc"} {
+			assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(root, dir, "terragrunt.hcl"), []byte{}, 0644))
+		}
+
+		matrix := &MatrixArgs{
+			Axes: []MatrixAxis{{Name: "dir", Glob: "live/**/terragrunt.hcl", Dirname: true}},
+		}
+
+		combinations, err := matrix.Combinations(root)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"plan (live/dev/vpc)", "plan (live/prod/vpc)"}, combinationNames(combinations))
+
+		matrix.Axes[0].Glob = "stacks/**/terragrunt.hcl"
+		_, err = matrix.Combinations(root)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestWithTasksMatrix(t *testing.T) {
+	t.Run("should expand a task per combination, with the matrix values rendered", func(t *testing.T) {
+		baseDir := t.TempDir()
+		for _, dir := range []string{"live/dev", "live/prod"} {
+			assert.NoError(t, os.MkdirAll(filepath.Join(baseDir, dir), 0755))
+		}
+
+		client := &entities.Client{
+			Logger: zap.NewNop(),
+			CfgDir: &entities.DirCfg{BaseDir: baseDir, BaseDirAbs: baseDir},
+		}
+
+		builtJob, err := NewDaggerClient(client).
+			WithJob(NewArgs{Name: "iac"}, EnvVarsOptions{}).
+			WithTasks([]TaskNewArgs{{
+				Name:           "plan",
+				ContainerImage: "alpine/terragrunt:{{ .Matrix.version }}",
+				MountDir:       ".",
+				WorkDir:        "live/{{ .Matrix.env }}",
+				Commands:       []TaskNewCMDArgs{{Binary: "terragrunt", CommandArgs: "plan -out={{ .Matrix.env }}.tfplan"}},
+				Matrix: &MatrixArgs{Axes: []MatrixAxis{
+					{Name: "env", Values: []string{"dev", "prod"}},
+					{Name: "version", Values: []string{"1.5"}},
+				}},
+			}}, EnvVarsOptions{EnvVarsExplicit: map[string]string{"TF_VAR_env": "{{ .Matrix.env }}"}}).
+			Build()
+
+		assert.NoError(t, err)
+		assert.Len(t, builtJob.Tasks, 2)
+
+		task := builtJob.Tasks[1]
+		assert.Equal(t, "plan (prod, 1.5)", task.Name)
+		assert.Equal(t, "alpine/terragrunt:1.5", task.ContainerImage)
+		assert.Equal(t, "live/prod", task.Workdir)
+		assert.Equal(t, []string{"terragrunt", "plan", "-out=prod.tfplan"}, task.CommandsCfg[0].Commands)
+		assert.Equal(t, "prod", task.EnvVars["TF_VAR_env"])
+		assert.Equal(t, "prod", task.EnvVars["MATRIX_ENV"])
+	})
+}
+
+func TestContainerImages(t *testing.T) {
+	t.Run("should render the image of each combination, without duplicates", func(t *testing.T) {
+		task := TaskNewArgs{
+			ContainerImage: "alpine/terragrunt:{{ .Matrix.terraform }}",
+			Matrix: &MatrixArgs{
+				Axes: []MatrixAxis{
+					{Name: "terraform", Values: []string{"1.5", "1.6"}},
+					{Name: "stack", Values: []string{"vpc", "eks"}},
+				},
+			},
+		}
+
+		images, err := task.ContainerImages("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alpine/terragrunt:1.5", "alpine/terragrunt:1.6"}, images)
+	})
+
+	t.Run("should return the image of a task without a matrix", func(t *testing.T) {
+		images, err := TaskNewArgs{ContainerImage: "alpine"}.ContainerImages("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alpine"}, images)
+	})
+}
@@ -0,0 +1,201 @@
+package manifest
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/utils"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultDir is the directory where, by convention, a repository keeps its manifests.
+// It's discovered from the current directory, walking up to the root of the repository.
+const DefaultDir = ".stiletto"
+
+// FindFiles resolves the manifest files out of paths, which can be files, glob patterns
+// (where '**' matches any number of directories. E.g.: 'ci/**/*.yml') or directories,
+// whose yaml files (.yml or .yaml) are found recursively. The relative paths are
+// resolved from the base directory, and kept relative in the result. The references of
+// a source (E.g.: 'https://...', see Sources) are returned as they are.
+//
+// The order is deterministic: the paths are resolved in the order they're passed, while
+// the matches of a glob pattern, and the files of a directory, are sorted. A file that
+// is resolved more than once is only returned the first time.
+func FindFiles(baseDir string, paths []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+
+	for _, p := range paths {
+		resolved, err := resolvePath(baseDir, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range resolved {
+			key := filepath.Clean(file)
+			if !filepath.IsAbs(key) {
+				key = filepath.Join(baseDir, key)
+			}
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+func resolvePath(baseDir, p string) ([]string, error) {
+	// The manifests of a source (E.g.: a URL) are resolved when they're loaded.
+	if IsRemote(p) {
+		return []string{p}, nil
+	}
+
+	if isGlobPattern(p) {
+		return resolveGlob(baseDir, p)
+	}
+
+	info, err := os.Stat(absPath(baseDir, p))
+	if err != nil || !info.IsDir() {
+		// A missing file is returned as it is, so it's reported when it's loaded.
+		return []string{p}, nil
+	}
+
+	return resolveDir(baseDir, p)
+}
+
+// resolveGlob walks only the static prefix of the pattern (E.g.: 'ci' for 'ci/**/*.yml'),
+// so a pattern doesn't walk the whole base directory.
+func resolveGlob(baseDir, pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+
+	var prefix []string
+	for _, segment := range segments[:len(segments)-1] {
+		if isGlobPattern(segment) {
+			break
+		}
+
+		prefix = append(prefix, segment)
+	}
+
+	root := strings.Join(prefix, "/")
+	if root == "" && strings.HasPrefix(pattern, "/") {
+		root = "/"
+	}
+
+	rootDir := filepath.FromSlash(root)
+	if _, err := os.Stat(absPath(baseDir, rootDir)); err != nil {
+		return nil, fmt.Errorf("the glob pattern '%s' matches no manifest files: %w", pattern, err)
+	}
+
+	matches, err := utils.GlobFiles(absPath(baseDir, rootDir), strings.Join(segments[len(prefix):], "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(absPath(baseDir, filepath.Join(rootDir, match))); err == nil && !info.IsDir() {
+			files = append(files, filepath.Join(rootDir, filepath.FromSlash(match)))
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("the glob pattern '%s' matches no manifest files", pattern)
+	}
+
+	return files, nil
+}
+
+func resolveDir(baseDir, dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(absPath(baseDir, dir), func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !IsManifestFile(entry.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(absPath(baseDir, dir), filePath)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.Join(dir, rel))
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the manifest files of the directory %s: %w", dir, err)
+	}
+
+	// The walk is already in lexical order; sorting keeps it stable across platforms.
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// FindDefaultDir returns the DefaultDir closest to the start directory, walking up to
+// the stop directory (E.g.: the root of the git repository). If the stop directory is
+// empty, or not an ancestor of the start one, only the start directory is looked up.
+// It returns an empty string if there's none.
+func FindDefaultDir(startDir, stopDir string) string {
+	dir := filepath.Clean(startDir)
+
+	for {
+		candidate := filepath.Join(dir, DefaultDir)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+
+		if stopDir == "" || dir == filepath.Clean(stopDir) {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || !utils.IsWithinDir(stopDir, dir) {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+// IsManifestFile reports whether the file has the extension of a manifest (.yml or .yaml).
+func IsManifestFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml":
+		return true
+	default:
+		return false
+	}
+}
+
+func isGlobPattern(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+func absPath(baseDir, p string) string {
+	if filepath.IsAbs(p) || baseDir == "" {
+		return p
+	}
+
+	return filepath.Join(baseDir, p)
+}
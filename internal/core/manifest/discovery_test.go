@@ -0,0 +1,97 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifestFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte("kind: Task\n"), 0644))
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeManifestFiles(t, dir, "ci/b.yml", "ci/a.yaml", "ci/nested/c.yml", "ci/README.md",
+		".stiletto/task.yml", "other.yml")
+
+	t.Run("should match the glob patterns, with '**' matching any number of directories", func(t *testing.T) {
+		files, err := FindFiles(dir, []string{"ci/**/*.yml"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ci/b.yml", "ci/nested/c.yml"}, toSlash(files))
+	})
+
+	t.Run("should find the manifests of a directory recursively, in lexical order", func(t *testing.T) {
+		files, err := FindFiles(dir, []string{"ci"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ci/a.yaml", "ci/b.yml", "ci/nested/c.yml"}, toSlash(files))
+	})
+
+	t.Run("should keep the order of the paths, without duplicates", func(t *testing.T) {
+		files, err := FindFiles(dir, []string{"other.yml", "ci/*.yml", "ci/b.yml", "./other.yml"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"other.yml", "ci/b.yml"}, toSlash(files))
+	})
+
+	t.Run("should return the missing files as they are, so they're reported when loaded", func(t *testing.T) {
+		files, err := FindFiles(dir, []string{"missing.yml"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"missing.yml"}, files)
+	})
+
+	t.Run("should fail if a glob pattern matches no files", func(t *testing.T) {
+		_, err := FindFiles(dir, []string{"ci/**/*.json"})
+
+		assert.ErrorContains(t, err, "matches no manifest files")
+	})
+
+	t.Run("should keep the absolute paths absolute", func(t *testing.T) {
+		files, err := FindFiles("", []string{filepath.Join(dir, "ci", "*.yaml")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "ci", "a.yaml")}, files)
+	})
+}
+
+func TestFindDefaultDir(t *testing.T) {
+	repoDir := t.TempDir()
+	writeManifestFiles(t, repoDir, ".stiletto/task.yml", "app/.stiletto/task.yml")
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoDir, "app", "src"), 0755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(repoDir, "lib", "src"), 0755))
+
+	t.Run("should find the closest directory, walking up to the stop directory", func(t *testing.T) {
+		assert.Equal(t, filepath.Join(repoDir, "app", DefaultDir),
+			FindDefaultDir(filepath.Join(repoDir, "app", "src"), repoDir))
+		assert.Equal(t, filepath.Join(repoDir, DefaultDir),
+			FindDefaultDir(filepath.Join(repoDir, "lib", "src"), repoDir))
+	})
+
+	t.Run("should not walk up past the stop directory", func(t *testing.T) {
+		assert.Empty(t, FindDefaultDir(filepath.Join(repoDir, "lib", "src"), filepath.Join(repoDir, "lib")))
+	})
+
+	t.Run("should only look up the start directory without a stop directory", func(t *testing.T) {
+		assert.Empty(t, FindDefaultDir(filepath.Join(repoDir, "lib"), ""))
+		assert.Equal(t, filepath.Join(repoDir, DefaultDir), FindDefaultDir(repoDir, ""))
+	})
+}
+
+func toSlash(paths []string) []string {
+	var slashed []string
+	for _, p := range paths {
+		slashed = append(slashed, filepath.ToSlash(p))
+	}
+
+	return slashed
+}
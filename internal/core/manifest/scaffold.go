@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/errors"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed scaffolds
+var scaffolds embed.FS
+
+// ScaffoldOpts are the values used to generate a new manifest out of a template.
+type ScaffoldOpts struct {
+	Kind           string
+	Template       string
+	Name           string
+	ContainerImage string
+	MountDir       string
+	Workdir        string
+}
+
+// scaffoldDefaults are the default values of each one of the templates, by kind.
+var scaffoldDefaults = map[string]map[string]ScaffoldOpts{
+	"task": {
+		"terragrunt": {Name: "iac-terragrunt", ContainerImage: "alpine/terragrunt", MountDir: ".", Workdir: "."},
+		"rust":       {Name: "rust-build", ContainerImage: "rust:alpine", MountDir: ".", Workdir: "."},
+		"node":       {Name: "node-build", ContainerImage: "node:alpine", MountDir: ".", Workdir: "."},
+		"aws-cli":    {Name: "aws-cli", ContainerImage: "amazon/aws-cli", MountDir: ".", Workdir: "."},
+	},
+}
+
+// ScaffoldKinds returns the kinds of manifest that can be scaffolded.
+func ScaffoldKinds() []string {
+	var kinds []string
+	for kind := range scaffoldDefaults {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// ScaffoldTemplates returns the templates available for the given kind.
+func ScaffoldTemplates(kind string) []string {
+	var templates []string
+	for name := range scaffoldDefaults[kind] {
+		templates = append(templates, name)
+	}
+
+	sort.Strings(templates)
+
+	return templates
+}
+
+// ScaffoldDefaults returns the default values of the given template.
+func ScaffoldDefaults(kind, templateName string) (ScaffoldOpts, error) {
+	templates, ok := scaffoldDefaults[kind]
+	if !ok {
+		return ScaffoldOpts{}, errors.NewArgumentError(fmt.Sprintf("invalid manifest kind: %s. "+
+			"Should be one of: %s", kind, strings.Join(ScaffoldKinds(), ", ")), nil)
+	}
+
+	defaults, ok := templates[templateName]
+	if !ok {
+		return ScaffoldOpts{}, errors.NewArgumentError(fmt.Sprintf("invalid template: %s. "+
+			"Should be one of: %s", templateName, strings.Join(ScaffoldTemplates(kind), ", ")), nil)
+	}
+
+	defaults.Kind = kind
+	defaults.Template = templateName
+
+	return defaults, nil
+}
+
+// Scaffold renders a new manifest out of the template set in the options. Every
+// value is rendered as a yaml scalar, so it's quoted whenever it's needed.
+func Scaffold(opts ScaffoldOpts) ([]byte, error) {
+	if _, err := ScaffoldDefaults(opts.Kind, opts.Template); err != nil {
+		return nil, err
+	}
+
+	templatePath := fmt.Sprintf("scaffolds/%s/%s.yml", opts.Kind, opts.Template)
+	tmpl, err := template.New(opts.Template+".yml").
+		Funcs(template.FuncMap{"yaml": yamlScalar}).
+		ParseFS(scaffolds, templatePath)
+
+	if err != nil {
+		return nil, errors.NewManifestError(fmt.Sprintf("failed to parse the template %s", templatePath), err)
+	}
+
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, opts); err != nil {
+		return nil, errors.NewManifestError(fmt.Sprintf("failed to render the template %s", templatePath), err)
+	}
+
+	return content.Bytes(), nil
+}
+
+func yamlScalar(value string) (string, error) {
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
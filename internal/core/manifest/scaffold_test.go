@@ -0,0 +1,47 @@
+package manifest
+
+import (
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScaffold(t *testing.T) {
+	t.Run("should render every template into a task manifest", func(t *testing.T) {
+		for _, name := range ScaffoldTemplates("task") {
+			opts, err := ScaffoldDefaults("task", name)
+			assert.NoError(t, err)
+
+			content, err := Scaffold(opts)
+			assert.NoError(t, err)
+
+			documents, err := yamlparser.DocumentsFromContent(string(content))
+			assert.NoError(t, err)
+
+			var manifest struct {
+				Kind     string `yaml:"kind"`
+				Metadata struct {
+					Name string `yaml:"name"`
+				} `yaml:"metadata"`
+			}
+
+			assert.NoError(t, documents[0].Decode(&manifest))
+			assert.Equal(t, "Task", manifest.Kind)
+			assert.Equal(t, opts.Name, manifest.Metadata.Name)
+		}
+	})
+
+	t.Run("should quote the values that aren't plain yaml scalars", func(t *testing.T) {
+		content, err := Scaffold(ScaffoldOpts{Kind: "task", Template: "rust", Name: "build: release",
+			ContainerImage: "rust:alpine", MountDir: ".", Workdir: "."})
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "name: 'build: release'")
+	})
+
+	t.Run("should fail with an unknown template", func(t *testing.T) {
+		_, err := ScaffoldDefaults("task", "python")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,449 @@
+package manifest
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// httpTimeout limits the download of a manifest published through https.
+const httpTimeout = 30 * time.Second
+
+// Source loads the manifests of a location that isn't a local file (E.g.: a URL), by
+// materializing them into the cache directory, so they're built as any other manifest.
+type Source interface {
+	// Name identifies the source in the errors. E.g.: 'https'.
+	Name() string
+	// Matches reports whether the reference is one of the source.
+	Matches(ref string) bool
+	// Fetch materializes the manifest of the reference into the cache directory.
+	Fetch(ref, cacheDir string) (*Resolved, error)
+}
+
+// Resolved is a manifest reference, materialized as a local file.
+type Resolved struct {
+	// Ref is the reference of the manifest, as it was passed.
+	Ref string
+	// Path is the local file of the manifest.
+	Path string
+	// Pinned reports whether the content of the manifest is pinned (E.g.: by a checksum,
+	// or a git commit), so it can't change without changing the reference.
+	Pinned bool
+	// Checksum is the sha256 digest of the content of the manifest, if the source pins
+	// it by its checksum.
+	Checksum string
+}
+
+// Sources resolves the manifest references through the sources registered.
+type Sources struct {
+	cacheDir string
+	sources  []Source
+}
+
+// NewSources returns the sources of the manifests published through https, and
+// through local git repositories, cached in the given directory.
+func NewSources(cacheDir string) *Sources {
+	return &Sources{
+		cacheDir: cacheDir,
+		sources:  []Source{NewHTTPSource(nil), NewGitSource()},
+	}
+}
+
+// DefaultCacheDir returns the directory where the manifests of the sources are cached.
+func DefaultCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	return filepath.Join(cacheDir, "stiletto", "manifests")
+}
+
+// sourceRootDepth is the number of directories, under the cache directory of a source,
+// of the root its manifests are confined to. E.g.: 'https/<checksum>', and
+// 'git/<repository>/<commit>', the tree of the repository at the commit.
+var sourceRootDepth = map[string]int{"https": 1, "git": 2}
+
+// Root returns the directory a manifest file materialized by the sources is confined to,
+// and whether it was materialized by them. Those manifests aren't trusted, so their
+// templates can't read the host, nor the files out of the root. See TemplateOpts.Root.
+func (s *Sources) Root(manifestFile string) (string, bool) {
+	manifestFileAbs, err := filepath.Abs(manifestFile)
+	if err != nil {
+		return "", false
+	}
+
+	cacheDirAbs, err := filepath.Abs(s.cacheDir)
+	if err != nil || !utils.IsWithinDir(cacheDirAbs, manifestFileAbs) {
+		return "", false
+	}
+
+	rel, _ := filepath.Rel(cacheDirAbs, manifestFileAbs)
+	segments := strings.Split(rel, string(filepath.Separator))
+
+	// The sources registered later on are confined to their cache directory.
+	depth := 1 + sourceRootDepth[segments[0]]
+	if depth >= len(segments) {
+		depth = len(segments) - 1
+	}
+
+	return filepath.Join(append([]string{cacheDirAbs}, segments[:depth]...)...), true
+}
+
+// Register adds a source, which takes precedence over the ones already registered.
+func (s *Sources) Register(source Source) {
+	s.sources = append([]Source{source}, s.sources...)
+}
+
+// IsRemote reports whether the reference should be resolved through a source, instead
+// of being read as a local file. E.g.: 'https://...' or 'git+file://...'.
+func IsRemote(ref string) bool {
+	scheme, _, found := strings.Cut(ref, "://")
+	return found && scheme != "" && !strings.ContainsAny(scheme, `/\`)
+}
+
+// Resolve materializes the manifest of the reference. A local file is returned as it is.
+func (s *Sources) Resolve(ref string) (*Resolved, error) {
+	if !IsRemote(ref) {
+		return &Resolved{Ref: ref, Path: ref, Pinned: true}, nil
+	}
+
+	for _, source := range s.sources {
+		if !source.Matches(ref) {
+			continue
+		}
+
+		resolved, err := source.Fetch(ref, filepath.Join(s.cacheDir, source.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot load the manifest %s through the '%s' source: %w", ref,
+				source.Name(), err)
+		}
+
+		return resolved, nil
+	}
+
+	return nil, fmt.Errorf("cannot load the manifest %s. Its scheme isn't supported. "+
+		"Should be 'https://', or 'git+file://'", ref)
+}
+
+// HTTPSource loads the manifests published through https. The content of a manifest can
+// be pinned with the 'sha256' fragment of its URL (E.g.: 'https://host/task.yml#sha256=...'),
+// so it fails if the content changes, and it's downloaded only once.
+type HTTPSource struct {
+	client *http.Client
+}
+
+// NewHTTPSource returns the https source. If the client is nil, a default one is used.
+func NewHTTPSource(client *http.Client) *HTTPSource {
+	if client == nil {
+		client = &http.Client{Timeout: httpTimeout}
+	}
+
+	return &HTTPSource{client: client}
+}
+
+func (s *HTTPSource) Name() string {
+	return "https"
+}
+
+func (s *HTTPSource) Matches(ref string) bool {
+	return strings.HasPrefix(ref, "https://")
+}
+
+func (s *HTTPSource) Fetch(ref, cacheDir string) (*Resolved, error) {
+	manifestURL, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	checksum, err := parseChecksum(manifestURL.Fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestURL.Fragment = ""
+	fileName := path.Base(manifestURL.Path)
+	if !IsManifestFile(fileName) {
+		fileName = "manifest.yml"
+	}
+
+	// A pinned manifest can't change, so the one already downloaded is reused.
+	if checksum != "" {
+		cached := filepath.Join(cacheDir, checksum, fileName)
+		if _, err := os.Stat(cached); err == nil {
+			return &Resolved{Ref: ref, Path: cached, Pinned: true, Checksum: checksum}, nil
+		}
+	}
+
+	response, err := s.client.Get(manifestURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("cannot download the manifest: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot download the manifest: %s", response.Status)
+	}
+
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot download the manifest: %w", err)
+	}
+
+	actual := sha256Hex(content)
+	if checksum != "" && actual != checksum {
+		return nil, fmt.Errorf("the checksum of the manifest doesn't match the one pinned. "+
+			"Expected sha256=%s, but got sha256=%s", checksum, actual)
+	}
+
+	cached := filepath.Join(cacheDir, actual, fileName)
+	if err := writeFileAtomically(cached, content); err != nil {
+		return nil, err
+	}
+
+	return &Resolved{Ref: ref, Path: cached, Pinned: checksum != "", Checksum: actual}, nil
+}
+
+// parseChecksum parses the 'sha256=<hex>' fragment of a URL, if any.
+func parseChecksum(fragment string) (string, error) {
+	if fragment == "" {
+		return "", nil
+	}
+
+	checksum, found := strings.CutPrefix(fragment, "sha256=")
+	if !found {
+		return "", fmt.Errorf("invalid checksum '%s'. Should be 'sha256=<hex digest>'", fragment)
+	}
+
+	checksum = strings.ToLower(checksum)
+	if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum '%s'. The sha256 digest should have 64 hex characters",
+			fragment)
+	}
+
+	return checksum, nil
+}
+
+// GitSource loads the manifests of a local git repository, at a ref, through references
+// like 'git+file:///path/to/repo//tasks/build.yml?ref=v1.2'. The ref (a tag, a branch or
+// a commit) defaults to 'HEAD', though only a tag, or a commit, pins the manifest. The
+// whole tree of the repository, at the ref, is materialized, so the manifest can extend
+// (or include) other files of the repository.
+type GitSource struct{}
+
+func NewGitSource() *GitSource {
+	return &GitSource{}
+}
+
+func (s *GitSource) Name() string {
+	return "git"
+}
+
+func (s *GitSource) Matches(ref string) bool {
+	return strings.HasPrefix(ref, "git+")
+}
+
+func (s *GitSource) Fetch(ref, cacheDir string) (*Resolved, error) {
+	repoDir, manifestPath, gitRef, err := parseGitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := runGit(repoDir, "rev-parse", "--verify", "--quiet", gitRef+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve the ref '%s' of the repository %s: %w", gitRef, repoDir, err)
+	}
+
+	commit = strings.TrimSpace(commit)
+	treeDir := filepath.Join(cacheDir, sha256Hex([]byte(repoDir))[:16], commit)
+
+	if _, err := os.Stat(treeDir); err != nil {
+		if err := extractGitTree(repoDir, commit, treeDir); err != nil {
+			return nil, err
+		}
+	}
+
+	manifestFile := filepath.Join(treeDir, filepath.FromSlash(manifestPath))
+	if _, err := os.Stat(manifestFile); err != nil {
+		return nil, fmt.Errorf("the manifest %s doesn't exist in the repository %s at '%s'",
+			manifestPath, repoDir, gitRef)
+	}
+
+	return &Resolved{Ref: ref, Path: manifestFile, Pinned: isPinnedGitRef(repoDir, gitRef, commit)}, nil
+}
+
+// isPinnedGitRef reports whether the ref is a commit, or a tag, instead of a ref that
+// moves (E.g.: a branch, or 'HEAD').
+func isPinnedGitRef(repoDir, gitRef, commit string) bool {
+	if strings.HasPrefix(commit, strings.ToLower(gitRef)) && len(gitRef) >= 7 {
+		return true
+	}
+
+	_, err := runGit(repoDir, "rev-parse", "--verify", "--quiet", "refs/tags/"+gitRef)
+
+	return err == nil
+}
+
+// parseGitRef splits a reference like 'git+file:///path/repo//tasks/x.yml?ref=v1.2' into
+// the repository, the path of the manifest within it, and the ref.
+func parseGitRef(ref string) (string, string, string, error) {
+	gitURL, err := url.Parse(strings.TrimPrefix(ref, "git+"))
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid git reference: %w", err)
+	}
+
+	if gitURL.Scheme != "file" {
+		return "", "", "", fmt.Errorf("the '%s' transport isn't supported. Only local repositories "+
+			"('git+file://') are", gitURL.Scheme)
+	}
+
+	repoDir, manifestPath, found := strings.Cut(gitURL.Path, "//")
+	if !found || repoDir == "" || manifestPath == "" {
+		return "", "", "", fmt.Errorf("the path of the manifest within the repository is required. " +
+			"E.g.: 'git+file:///path/to/repo//tasks/build.yml?ref=v1.2'")
+	}
+
+	gitRef := gitURL.Query().Get("ref")
+	if gitRef == "" {
+		gitRef = "HEAD"
+	}
+
+	if strings.HasPrefix(gitRef, "-") {
+		return "", "", "", fmt.Errorf("invalid ref '%s'", gitRef)
+	}
+
+	return filepath.FromSlash(repoDir), manifestPath, gitRef, nil
+}
+
+// extractGitTree extracts the tree of the commit into the directory. It's extracted
+// into a temporary directory first, so a directory that exists is always complete.
+func extractGitTree(repoDir, commit, treeDir string) error {
+	archive, err := runGit(repoDir, "archive", "--format=tar", commit)
+	if err != nil {
+		return fmt.Errorf("cannot read the tree of the commit %s of the repository %s: %w", commit, repoDir, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(treeDir), 0755); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(treeDir), ".tree-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	reader := tar.NewReader(strings.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("cannot extract the tree of the commit %s: %w", commit, err)
+		}
+
+		target := filepath.Join(tmpDir, filepath.FromSlash(header.Name))
+		if !utils.IsWithinDir(tmpDir, target) {
+			return fmt.Errorf("cannot extract the tree of the commit %s. The path %s is outside of it",
+				commit, header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(target, content, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.Rename(tmpDir, treeDir); err != nil && !os.IsExist(err) {
+		// Another process could have extracted the same commit in the meantime.
+		if _, statErr := os.Stat(treeDir); statErr != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runGit(repoDir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", repoDir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// writeFileAtomically writes the file through a temporary one, so a file that exists
+// is always complete.
+func writeFileAtomically(file string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("cannot cache the manifest: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(file), ".manifest-*")
+	if err != nil {
+		return fmt.Errorf("cannot cache the manifest: %w", err)
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("cannot cache the manifest: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("cannot cache the manifest: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), file); err != nil {
+		return fmt.Errorf("cannot cache the manifest: %w", err)
+	}
+
+	return nil
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sourceTaskManifest = `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: shared
+spec:
+    containerImage: alpine
+    mountDir: .
+    workDir: .
+    commandsSpec:
+        - run: echo shared
+`
+
+func TestIsRemote(t *testing.T) {
+	assert.True(t, IsRemote("https://example.com/task.yml"))
+	assert.True(t, IsRemote("git+file:///repo//task.yml?ref=v1.2"))
+	assert.False(t, IsRemote("tasks/task.yml"))
+	assert.False(t, IsRemote("/tmp/tasks/task.yml"))
+}
+
+func TestHTTPSource(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/tasks/shared.yml" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(sourceTaskManifest))
+	}))
+	defer server.Close()
+
+	newSources := func(cacheDir string) *Sources {
+		sources := NewSources(cacheDir)
+		sources.Register(NewHTTPSource(server.Client()))
+
+		return sources
+	}
+
+	checksum := sha256Hex([]byte(sourceTaskManifest))
+
+	t.Run("should download the manifest, and report that it isn't pinned", func(t *testing.T) {
+		sources := newSources(t.TempDir())
+		resolved, err := sources.Resolve(server.URL + "/tasks/shared.yml")
+
+		assert.NoError(t, err)
+		assert.False(t, resolved.Pinned)
+		assert.Equal(t, checksum, resolved.Checksum)
+		assert.Equal(t, "shared.yml", filepath.Base(resolved.Path))
+
+		content, err := os.ReadFile(resolved.Path)
+		assert.NoError(t, err)
+		assert.Equal(t, sourceTaskManifest, string(content))
+
+		// The manifest is confined to the directory of its checksum.
+		root, ok := sources.Root(resolved.Path)
+		assert.True(t, ok)
+		assert.Equal(t, filepath.Dir(resolved.Path), root)
+
+		_, ok = sources.Root("tasks/shared.yml")
+		assert.False(t, ok)
+	})
+
+	t.Run("should download a pinned manifest only once", func(t *testing.T) {
+		sources := newSources(t.TempDir())
+		requests = 0
+
+		for i := 0; i < 2; i++ {
+			resolved, err := sources.Resolve(server.URL + "/tasks/shared.yml#sha256=" + checksum)
+
+			assert.NoError(t, err)
+			assert.True(t, resolved.Pinned)
+		}
+
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("should fail if the checksum doesn't match the one pinned", func(t *testing.T) {
+		_, err := newSources(t.TempDir()).Resolve(server.URL + "/tasks/shared.yml#sha256=" +
+			strings.Repeat("0", 64))
+
+		assert.ErrorContains(t, err, "doesn't match the one pinned")
+	})
+
+	t.Run("should fail if the manifest can't be downloaded", func(t *testing.T) {
+		_, err := newSources(t.TempDir()).Resolve(server.URL + "/tasks/missing.yml")
+
+		assert.ErrorContains(t, err, "404")
+	})
+}
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("the git binary isn't available")
+	}
+
+	repoDir := t.TempDir()
+	writeSourceFile(t, repoDir, "tasks/shared.yml", sourceTaskManifest)
+	writeSourceFile(t, repoDir, "tasks/base/base.yml", "kind: Task\n")
+
+	runTestGit(t, repoDir, "init", "-q")
+	runTestGit(t, repoDir, "add", ".")
+	runTestGit(t, repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "v1")
+	runTestGit(t, repoDir, "tag", "v1.2")
+
+	writeSourceFile(t, repoDir, "tasks/shared.yml", strings.Replace(sourceTaskManifest, "echo shared",
+		"echo changed", 1))
+	runTestGit(t, repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-am", "v2")
+
+	ref := "git+file://" + filepath.ToSlash(repoDir) + "//tasks/shared.yml"
+
+	t.Run("should materialize the tree of the repository at the ref", func(t *testing.T) {
+		sources := NewSources(t.TempDir())
+		resolved, err := sources.Resolve(ref + "?ref=v1.2")
+
+		assert.NoError(t, err)
+		assert.True(t, resolved.Pinned)
+
+		content, err := os.ReadFile(resolved.Path)
+		assert.NoError(t, err)
+		assert.Equal(t, sourceTaskManifest, string(content))
+
+		// The other files of the repository are available. E.g.: to be extended.
+		assert.FileExists(t, filepath.Join(filepath.Dir(resolved.Path), "base", "base.yml"))
+
+		// The manifest is confined to the tree of the repository.
+		root, ok := sources.Root(resolved.Path)
+		assert.True(t, ok)
+		assert.Equal(t, filepath.Dir(filepath.Dir(resolved.Path)), root)
+	})
+
+	t.Run("should use HEAD by default, which isn't pinned", func(t *testing.T) {
+		resolved, err := NewSources(t.TempDir()).Resolve(ref)
+
+		assert.NoError(t, err)
+		assert.False(t, resolved.Pinned)
+
+		content, err := os.ReadFile(resolved.Path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "echo changed")
+	})
+
+	t.Run("should fail with an unknown ref, or a missing manifest", func(t *testing.T) {
+		_, err := NewSources(t.TempDir()).Resolve(ref + "?ref=v9.9")
+		assert.ErrorContains(t, err, "cannot resolve the ref 'v9.9'")
+
+		_, err = NewSources(t.TempDir()).Resolve("git+file://" + filepath.ToSlash(repoDir) + "//tasks/missing.yml")
+		assert.ErrorContains(t, err, "doesn't exist in the repository")
+	})
+
+	t.Run("should fail without the path of the manifest within the repository", func(t *testing.T) {
+		_, err := NewSources(t.TempDir()).Resolve("git+file://" + filepath.ToSlash(repoDir) + "?ref=v1.2")
+		assert.ErrorContains(t, err, "the path of the manifest within the repository is required")
+	})
+}
+
+func writeSourceFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+}
+
+func runTestGit(t *testing.T, repoDir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoDir
+
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// maxIncludeDepth limits the templates included by other templates, so a partial that
+// includes itself fails, instead of never ending.
+const maxIncludeDepth = 32
+
+// TemplateOpts are the options to render the templates of a manifest.
+type TemplateOpts struct {
+	// Name identifies the template in the errors. E.g.: the path of the manifest file.
+	Name string
+	// Dir is the directory that the paths of 'readFile', and 'include', are relative to.
+	Dir string
+	// Root is set if the manifest isn't trusted, since it's loaded from a source (E.g.: a
+	// URL). The paths of 'readFile', and 'include', should then be within it, and the
+	// functions that read the host (E.g.: 'env') fail. See Sources.Root.
+	Root string
+	// Data is the data available in the templates. E.g.: '{{ .Inputs.name }}'.
+	Data interface{}
+}
+
+type renderer struct {
+	dir      string
+	root     string
+	data     interface{}
+	template *template.Template
+	depth    int
+}
+
+// Render renders the templates of the content of a manifest in a single pass, with
+// every function of the registry (see Functions) available, unless the manifest isn't
+// trusted (see TemplateOpts.Root). A content without templates is returned as it is.
+func Render(content string, opts TemplateOpts) (string, error) {
+	if !strings.Contains(content, "{{") {
+		return content, nil
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "manifest"
+	}
+
+	r := &renderer{dir: opts.Dir, root: opts.Root, data: opts.Data}
+
+	tmpl, err := template.New(name).Funcs(funcMap(r)).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("could not parse the template: %w", err)
+	}
+
+	r.template = tmpl
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, opts.Data); err != nil {
+		return "", fmt.Errorf("could not execute the template: %w", err)
+	}
+
+	return rendered.String(), nil
+}
+
+// include renders a template declared in the manifest, or a partial file. The partial
+// files are parsed once, as templates associated to the one of the manifest, so they
+// can use its functions, and the templates it declares.
+func (r *renderer) include(name string, data ...interface{}) (string, error) {
+	if r.depth >= maxIncludeDepth {
+		return "", fmt.Errorf("cannot include '%s', since the templates are included more than %d levels deep",
+			name, maxIncludeDepth)
+	}
+
+	included := r.template.Lookup(name)
+	if included == nil {
+		partialFile, err := r.path(name)
+		if err != nil {
+			return "", fmt.Errorf("cannot include '%s': %w", name, err)
+		}
+
+		content, err := os.ReadFile(partialFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot include '%s'. It's not a template declared in the manifest, "+
+				"nor a partial file: %w", name, err)
+		}
+
+		included, err = r.template.New(name).Parse(string(content))
+		if err != nil {
+			return "", fmt.Errorf("could not parse the partial '%s': %w", name, err)
+		}
+	}
+
+	includeData := r.data
+	if len(data) != 0 {
+		includeData = data[0]
+	}
+
+	r.depth++
+	defer func() { r.depth-- }()
+
+	var rendered bytes.Buffer
+	if err := included.Execute(&rendered, includeData); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("should render every function in a single pass", func(t *testing.T) {
+		t.Setenv("STILETTO_TEST_HOST", "my-host")
+		t.Setenv("STILETTO_TEST_TARGETS", "vpc,eks")
+
+		rendered, err := Render(`host: {{ readEnv "STILETTO_TEST_HOST" | toUpper }}
+path: {{ replace (readEnv "STILETTO_TEST_HOST") "-" "/" }}
+region: {{ env "STILETTO_TEST_MISSING" "us-east-1" }}
+targets: {{ env "STILETTO_TEST_TARGETS" | split "," | join " " }}
+version: {{ .Inputs.version | regexReplaceAll "^v" "" }}
+stable: {{ regexMatch "^v[0-9.]+$" .Inputs.version }}
+labels: {{ toJson .Inputs.labels }}
+secret: {{ "token" | base64Encode }}
+`, TemplateOpts{Data: map[string]interface{}{"Inputs": map[string]interface{}{
+			"version": "v1.2.3",
+			"labels":  map[string]string{"team": "iac"},
+		}}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, `host: MY-HOST
+path: my/host
+region: us-east-1
+targets: vpc eks
+version: 1.2.3
+stable: true
+labels: {"team":"iac"}
+secret: dG9rZW4=
+`, rendered)
+	})
+
+	t.Run("should fail with the message of a required value that's empty", func(t *testing.T) {
+		_, err := Render(`token: {{ env "STILETTO_TEST_MISSING" | required "STILETTO_TEST_MISSING is required" }}`,
+			TemplateOpts{Data: map[string]interface{}{}})
+
+		assert.ErrorContains(t, err, "STILETTO_TEST_MISSING is required")
+	})
+
+	t.Run("should read the files, and include the partials, relative to the manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "partials"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), []byte("sum"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "partials", "image.tpl"),
+			[]byte(`{{ .Image }}:{{ include "tag" . }}`), 0644))
+
+		rendered, err := Render(`{{ define "tag" }}{{ .Tag | toLower }}{{ end -}}
+image: {{ include "partials/image.tpl" }}
+checksum: {{ readFile "go.sum" | sha256 }}
+`, TemplateOpts{Dir: dir, Data: map[string]interface{}{"Image": "alpine", "Tag": "LATEST"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, `image: alpine:latest
+checksum: 09f5ffef28309853265c4a98d0e56e1be522b6b402d8193594fd05103064fc6a
+`, rendered)
+	})
+
+	t.Run("should fail when a partial includes itself", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "loop.tpl"), []byte(`{{ include "loop.tpl" }}`), 0644))
+
+		_, err := Render(`{{ include "loop.tpl" }}`, TemplateOpts{Dir: dir, Data: map[string]interface{}{}})
+
+		assert.ErrorContains(t, err, "more than 32 levels deep")
+	})
+
+	t.Run("should not read the host, nor the files out of the root, if the manifest isn't trusted", func(t *testing.T) {
+		root := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "tasks"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, "go.sum"), []byte("sum"), 0644))
+
+		opts := TemplateOpts{Dir: filepath.Join(root, "tasks"), Root: root, Data: map[string]interface{}{}}
+
+		rendered, err := Render(`sum: {{ readFile "../go.sum" }}`, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, "sum: sum", rendered)
+
+		for _, content := range []string{
+			`{{ env "HOME" }}`,
+			`{{ readEnv "HOME" }}`,
+			`{{ getHome }}`,
+			`{{ getPwd }}`,
+		} {
+			_, err := Render(content, opts)
+			assert.ErrorContains(t, err, "isn't available in the manifests loaded from a source", content)
+		}
+
+		for _, content := range []string{
+			`{{ readFile "../../go.sum" }}`,
+			`{{ readFile "/etc/hostname" }}`,
+			`{{ include "../../partial.tpl" }}`,
+		} {
+			_, err := Render(content, opts)
+			assert.ErrorContains(t, err, "is outside of the source the manifest is loaded from", content)
+		}
+	})
+
+	t.Run("should return the content without templates as it is", func(t *testing.T) {
+		rendered, err := Render("kind: Task\n", TemplateOpts{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "kind: Task\n", rendered)
+	})
+}
@@ -1 +1,336 @@
 package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/utils"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Function is a function available in the templates of the manifests.
+type Function struct {
+	Name string
+	// Usage shows the arguments of the function. E.g.: 'env NAME [DEFAULT]'.
+	Usage       string
+	Description string
+	// ReadsHost is set if the function reads the environment, or the files, of the host,
+	// so it's not available in the manifests that aren't trusted. See TemplateOpts.Root.
+	ReadsHost bool
+	// fn returns the implementation of the function, for the given renderer.
+	fn func(r *renderer) interface{}
+}
+
+// functions is the registry of the functions available in the templates of the
+// manifests. The ones whose last argument is the value they work on can be used in
+// pipelines. E.g.: '{{ env "TARGETS" | split "," | join " " }}'.
+var functions = []Function{
+	{
+		Name:        "env",
+		Usage:       "env NAME [DEFAULT]",
+		Description: "The value of the environment variable, or the default if it's not set, or empty.",
+		ReadsHost:   true,
+		fn: func(*renderer) interface{} {
+			return func(name string, defaultValue ...string) string {
+				if value := os.Getenv(name); value != "" || len(defaultValue) == 0 {
+					return value
+				}
+
+				return defaultValue[0]
+			}
+		},
+	},
+	{
+		Name:        "readEnv",
+		Usage:       "readEnv NAME",
+		Description: "The value of the environment variable. Kept for compatibility, see 'env'.",
+		ReadsHost:   true,
+		fn:          func(*renderer) interface{} { return os.Getenv },
+	},
+	{
+		Name:        "getPwd",
+		Usage:       "getPwd",
+		Description: "The current directory.",
+		ReadsHost:   true,
+		fn:          func(*renderer) interface{} { return os.Getwd },
+	},
+	{
+		Name:        "getHome",
+		Usage:       "getHome",
+		Description: "The home directory of the current user.",
+		ReadsHost:   true,
+		fn:          func(*renderer) interface{} { return os.UserHomeDir },
+	},
+	{
+		Name:        "required",
+		Usage:       "required MESSAGE VALUE",
+		Description: "The value, if it's not empty. Otherwise, the manifest fails with the message.",
+		fn: func(*renderer) interface{} {
+			return func(message string, value interface{}) (interface{}, error) {
+				if value == nil || fmt.Sprint(value) == "" {
+					return nil, fmt.Errorf("%s", message)
+				}
+
+				return value, nil
+			}
+		},
+	},
+	{
+		Name:        "readFile",
+		Usage:       "readFile PATH",
+		Description: "The content of the file, relative to the directory of the manifest.",
+		fn: func(r *renderer) interface{} {
+			return func(path string) (string, error) {
+				file, err := r.path(path)
+				if err != nil {
+					return "", fmt.Errorf("cannot read the file %s: %w", path, err)
+				}
+
+				content, err := os.ReadFile(file)
+				if err != nil {
+					return "", fmt.Errorf("cannot read the file %s: %w", path, err)
+				}
+
+				return string(content), nil
+			}
+		},
+	},
+	{
+		Name:  "include",
+		Usage: "include NAME [DATA]",
+		Description: "Renders a template declared in the manifest (with 'define'), or a partial file, " +
+			"relative to the directory of the manifest. It's rendered with the data of the manifest, " +
+			"unless other data is passed.",
+		fn: func(r *renderer) interface{} { return r.include },
+	},
+	{
+		Name:        "base64Encode",
+		Usage:       "base64Encode VALUE",
+		Description: "The value, encoded in base64.",
+		fn: func(*renderer) interface{} {
+			return func(value string) string {
+				return base64.StdEncoding.EncodeToString([]byte(value))
+			}
+		},
+	},
+	{
+		Name:        "base64Decode",
+		Usage:       "base64Decode VALUE",
+		Description: "The value, decoded from base64.",
+		fn: func(*renderer) interface{} {
+			return func(value string) (string, error) {
+				decoded, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return "", fmt.Errorf("cannot decode the base64 value: %w", err)
+				}
+
+				return string(decoded), nil
+			}
+		},
+	},
+	{
+		Name:        "sha256",
+		Usage:       "sha256 VALUE",
+		Description: "The hex-encoded SHA-256 checksum of the value. E.g.: '{{ readFile \"go.sum\" | sha256 }}'.",
+		fn: func(*renderer) interface{} {
+			return func(value string) string {
+				checksum := sha256.Sum256([]byte(value))
+				return hex.EncodeToString(checksum[:])
+			}
+		},
+	},
+	{
+		Name:        "toJson",
+		Usage:       "toJson VALUE",
+		Description: "The value, encoded as JSON.",
+		fn: func(*renderer) interface{} {
+			return func(value interface{}) (string, error) {
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					return "", fmt.Errorf("cannot encode the value as JSON: %w", err)
+				}
+
+				return string(encoded), nil
+			}
+		},
+	},
+	{
+		Name:        "toYaml",
+		Usage:       "toYaml VALUE",
+		Description: "The value, encoded as yaml. Use it along with 'indent' to nest it.",
+		fn: func(*renderer) interface{} {
+			return func(value interface{}) (string, error) {
+				encoded, err := yaml.Marshal(value)
+				if err != nil {
+					return "", fmt.Errorf("cannot encode the value as yaml: %w", err)
+				}
+
+				return strings.TrimSuffix(string(encoded), "\n"), nil
+			}
+		},
+	},
+	{
+		Name:        "indent",
+		Usage:       "indent SPACES VALUE",
+		Description: "The value, with each one of its lines indented with the given number of spaces.",
+		fn: func(*renderer) interface{} {
+			return func(spaces int, value string) string {
+				padding := strings.Repeat(" ", spaces)
+				return padding + strings.ReplaceAll(value, "\n", "\n"+padding)
+			}
+		},
+	},
+	{
+		Name:        "regexMatch",
+		Usage:       "regexMatch PATTERN VALUE",
+		Description: "Whether the value matches the regular expression.",
+		fn: func(*renderer) interface{} {
+			return func(pattern, value string) (bool, error) {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return false, fmt.Errorf("invalid regular expression '%s': %w", pattern, err)
+				}
+
+				return re.MatchString(value), nil
+			}
+		},
+	},
+	{
+		Name:        "regexFind",
+		Usage:       "regexFind PATTERN VALUE",
+		Description: "The first match of the regular expression in the value, or an empty string.",
+		fn: func(*renderer) interface{} {
+			return func(pattern, value string) (string, error) {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return "", fmt.Errorf("invalid regular expression '%s': %w", pattern, err)
+				}
+
+				return re.FindString(value), nil
+			}
+		},
+	},
+	{
+		Name:  "regexReplaceAll",
+		Usage: "regexReplaceAll PATTERN REPLACEMENT VALUE",
+		Description: "The value, with the matches of the regular expression replaced. The replacement " +
+			"can reference the groups of the match. E.g.: '${1}'.",
+		fn: func(*renderer) interface{} {
+			return func(pattern, replacement, value string) (string, error) {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return "", fmt.Errorf("invalid regular expression '%s': %w", pattern, err)
+				}
+
+				return re.ReplaceAllString(value, replacement), nil
+			}
+		},
+	},
+	{
+		Name:        "toUpper",
+		Usage:       "toUpper VALUE",
+		Description: "The value, in upper case.",
+		fn:          func(*renderer) interface{} { return strings.ToUpper },
+	},
+	{
+		Name:        "toLower",
+		Usage:       "toLower VALUE",
+		Description: "The value, in lower case.",
+		fn:          func(*renderer) interface{} { return strings.ToLower },
+	},
+	{
+		Name:        "trimspace",
+		Usage:       "trimspace VALUE",
+		Description: "The value, without its leading and trailing white spaces.",
+		fn:          func(*renderer) interface{} { return strings.TrimSpace },
+	},
+	{
+		Name:        "replace",
+		Usage:       "replace VALUE OLD NEW",
+		Description: "The value, with every occurrence of OLD replaced by NEW.",
+		fn: func(*renderer) interface{} {
+			return func(value, old, new string) string {
+				return strings.ReplaceAll(value, old, new)
+			}
+		},
+	},
+	{
+		Name:        "join",
+		Usage:       "join SEPARATOR LIST",
+		Description: "The items of the list (E.g.: an input of type 'list'), joined with the separator.",
+		fn: func(*renderer) interface{} {
+			return func(separator string, list interface{}) (string, error) {
+				switch items := list.(type) {
+				case []string:
+					return strings.Join(items, separator), nil
+				case []interface{}:
+					var values []string
+					for _, item := range items {
+						values = append(values, fmt.Sprint(item))
+					}
+
+					return strings.Join(values, separator), nil
+				default:
+					return "", fmt.Errorf("cannot join '%v', since it's not a list", list)
+				}
+			}
+		},
+	},
+	{
+		Name:        "split",
+		Usage:       "split SEPARATOR VALUE",
+		Description: "The list of the parts of the value, split by the separator.",
+		fn: func(*renderer) interface{} {
+			return func(separator, value string) []string {
+				return strings.Split(value, separator)
+			}
+		},
+	},
+}
+
+// Functions returns the functions available in the templates of the manifests.
+func Functions() []Function {
+	return append([]Function{}, functions...)
+}
+
+// funcMap returns the implementations of the functions, for the given renderer. If the
+// manifest isn't trusted, the functions that read the host fail.
+func funcMap(r *renderer) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, function := range functions {
+		if function.ReadsHost && r.root != "" {
+			name := function.Name
+			funcs[name] = func(...interface{}) (string, error) {
+				return "", fmt.Errorf("the function '%s' isn't available in the manifests loaded from a "+
+					"source (E.g.: a URL), since it reads the host", name)
+			}
+
+			continue
+		}
+
+		funcs[function.Name] = function.fn(r)
+	}
+
+	return funcs
+}
+
+// path resolves a path relative to the directory of the manifest. If the manifest isn't
+// trusted, the path should be within its root.
+func (r *renderer) path(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, path)
+	}
+
+	if r.root != "" && !utils.IsWithinDir(r.root, filepath.Clean(path)) {
+		return "", fmt.Errorf("the path %s is outside of the source the manifest is loaded from", path)
+	}
+
+	return path, nil
+}
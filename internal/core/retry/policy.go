@@ -0,0 +1,99 @@
+// Package retry decides whether, and when, a failed command (or task) runs again.
+package retry
+
+import (
+	"regexp"
+	"time"
+)
+
+// Backoff strategies between the attempts.
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+// DefaultDelay is the delay before the first retry, if the policy doesn't set one.
+const DefaultDelay = time.Second
+
+// Policy sets how many times a command (or task) is attempted, how long to wait
+// between the attempts, and which failures are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// Backoff is either BackoffFixed, or BackoffExponential (the delay doubles after each attempt).
+	Backoff string
+	// Delay is the delay before the first retry.
+	Delay time.Duration
+	// MaxDelay caps the delay between the attempts. If zero, there's no cap.
+	MaxDelay time.Duration
+	// OnExitCodes retries only the failures with one of these exit codes.
+	OnExitCodes []int
+	// OnOutputPatterns retries only the failures whose output matches one of these
+	// regular expressions. If both filters are set, a failure that matches either is retried.
+	OnOutputPatterns []string
+}
+
+// Attempts returns the total number of attempts allowed by the policy. A nil policy
+// allows a single attempt.
+func (p *Policy) Attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// DelayBefore returns how long to wait before the given attempt (starting at 2, the first retry).
+func (p *Policy) DelayBefore(attempt int) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	delay := p.Delay
+	if delay <= 0 {
+		delay = DefaultDelay
+	}
+
+	if p.Backoff == BackoffExponential {
+		for i := 2; i < attempt; i++ {
+			delay *= 2
+
+			if p.MaxDelay > 0 && delay >= p.MaxDelay {
+				break
+			}
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// ShouldRetry reports whether a failure, with its exit code (or -1, if the command
+// didn't exit) and output, is retried by the policy. If the policy has no filters,
+// every failure is.
+func (p *Policy) ShouldRetry(exitCode int, output string) bool {
+	if p == nil {
+		return false
+	}
+
+	if len(p.OnExitCodes) == 0 && len(p.OnOutputPatterns) == 0 {
+		return true
+	}
+
+	for _, code := range p.OnExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+
+	for _, pattern := range p.OnOutputPatterns {
+		if matched, err := regexp.MatchString(pattern, output); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package retry
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Run("should compute the delays of each backoff", func(t *testing.T) {
+		fixed := &Policy{MaxAttempts: 4, Backoff: BackoffFixed, Delay: 2 * time.Second}
+		assert.Equal(t, 2*time.Second, fixed.DelayBefore(2))
+		assert.Equal(t, 2*time.Second, fixed.DelayBefore(4))
+
+		exponential := &Policy{MaxAttempts: 5, Backoff: BackoffExponential, Delay: time.Second,
+			MaxDelay: 5 * time.Second}
+		assert.Equal(t, time.Second, exponential.DelayBefore(2))
+		assert.Equal(t, 2*time.Second, exponential.DelayBefore(3))
+		assert.Equal(t, 4*time.Second, exponential.DelayBefore(4))
+		assert.Equal(t, 5*time.Second, exponential.DelayBefore(5))
+	})
+
+	t.Run("should only retry the failures that match its filters", func(t *testing.T) {
+		policy := &Policy{MaxAttempts: 3, OnExitCodes: []int{137},
+			OnOutputPatterns: []string{`(?i)tls handshake timeout`}}
+
+		assert.True(t, policy.ShouldRetry(137, ""))
+		assert.True(t, policy.ShouldRetry(1, "Error: net/http: TLS handshake timeout"))
+		assert.False(t, policy.ShouldRetry(1, "Error: invalid configuration"))
+		assert.True(t, (&Policy{MaxAttempts: 2}).ShouldRetry(1, ""))
+	})
+
+	t.Run("should allow a single attempt without a policy", func(t *testing.T) {
+		var policy *Policy
+
+		assert.Equal(t, 1, policy.Attempts())
+		assert.False(t, policy.ShouldRetry(1, ""))
+	})
+}
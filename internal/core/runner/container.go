@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"dagger.io/dagger"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exitCodeFile is the file of the container the exit code of a command is recorded in,
+// when the container it leaves is kept even if it fails.
+const exitCodeFile = "/tmp/.stiletto-exit-code"
+
+// commandContainer is the container the commands of a task run on. Each command leaves
+// a new container, so the next ones see its changes.
+type commandContainer interface {
+	// Exec runs the command, and returns the container it leaves. If it fails, the failure
+	// is returned, along with the container it leaves if keepOnFailure is set (and the
+	// command exited). E.g.: it didn't time out.
+	Exec(ctx context.Context, args []string, keepOnFailure bool) (commandContainer, *execFailure, error)
+}
+
+// daggerContainer is a commandContainer run by Dagger.
+type daggerContainer struct {
+	container *dagger.Container
+}
+
+func (c *daggerContainer) Exec(ctx context.Context, args []string,
+	keepOnFailure bool) (commandContainer, *execFailure, error) {
+	if !keepOnFailure {
+		next, err := c.container.WithExec(args).Sync(ctx)
+		if err != nil {
+			return nil, newExecFailure(err), err
+		}
+
+		return &daggerContainer{container: next}, nil, nil
+	}
+
+	// Dagger drops the container of a command that fails, so the command exits with 0, and
+	// its exit code is read from the file it's recorded in.
+	next, err := c.container.WithExec(exitCodeRecorder(args, exitCodeFile)).Sync(ctx)
+	if err != nil {
+		return nil, newExecFailure(err), err
+	}
+
+	recorded, err := next.File(exitCodeFile).Contents(ctx)
+	if err != nil {
+		return nil, newExecFailure(err), err
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(recorded))
+	if err != nil {
+		err = fmt.Errorf("cannot read the exit code of the command %s: %w", strings.Join(args, " "), err)
+		return nil, newExecFailure(err), err
+	}
+
+	if exitCode == 0 {
+		return &daggerContainer{container: next}, nil, nil
+	}
+
+	stdout, _ := next.Stdout(ctx)
+	stderr, _ := next.Stderr(ctx)
+	failure, err := newExitCodeFailure(args, exitCode, stdout, stderr)
+
+	return &daggerContainer{container: next}, failure, err
+}
+
+// exitCodeRecorder wraps the command in a shell that records its exit code in the file
+// passed, and exits with 0. The image needs a 'sh' shell.
+func exitCodeRecorder(args []string, file string) []string {
+	script := fmt.Sprintf(`"$@"; echo $? > '%s'`, file)
+
+	return append([]string{"sh", "-c", script, "stiletto"}, args...)
+}
+
+// newExitCodeFailure returns the failure of a command whose exit code was recorded, along
+// with its error, as Dagger describes a command that failed.
+func newExitCodeFailure(args []string, exitCode int, stdout, stderr string) (*execFailure, error) {
+	err := fmt.Errorf("process %q did not complete successfully: exit code: %d\nStdout:\n%s\nStderr:\n%s",
+		strings.Join(args, " "), exitCode, stdout, stderr)
+
+	return &execFailure{exitCode: exitCode, output: stdout + "\n" + stderr}, err
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hostContainer is a commandContainer whose filesystem is a directory of the host. Each
+// command runs on a copy of it, like on a new container.
+type hostContainer struct {
+	t   *testing.T
+	dir string
+}
+
+func (c *hostContainer) Exec(_ context.Context, args []string,
+	keepOnFailure bool) (commandContainer, *execFailure, error) {
+	next := &hostContainer{t: c.t, dir: c.t.TempDir()}
+
+	entries, err := os.ReadDir(c.dir)
+	assert.NoError(c.t, err)
+
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(c.dir, entry.Name()))
+		assert.NoError(c.t, err)
+		assert.NoError(c.t, os.WriteFile(filepath.Join(next.dir, entry.Name()), content, 0644))
+	}
+
+	exitCodeFile := filepath.Join(c.t.TempDir(), "exit-code")
+	if keepOnFailure {
+		args = exitCodeRecorder(args, exitCodeFile)
+	}
+
+	command := exec.Command(args[0], args[1:]...)
+	command.Dir = next.dir
+
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return nil, &execFailure{exitCode: command.ProcessState.ExitCode(), output: string(output)}, err
+	}
+
+	if keepOnFailure {
+		recorded, err := os.ReadFile(exitCodeFile)
+		assert.NoError(c.t, err)
+
+		exitCode, err := strconv.Atoi(strings.TrimSpace(string(recorded)))
+		assert.NoError(c.t, err)
+
+		if exitCode != 0 {
+			failure, err := newExitCodeFailure(args, exitCode, string(output), "")
+			return next, failure, err
+		}
+	}
+
+	return next, nil, nil
+}
+
+func TestExitCodeRecorder(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("the sh binary isn't available")
+	}
+
+	t.Run("should record the exit code of the command, and exit with 0", func(t *testing.T) {
+		exitCodeFile := filepath.Join(t.TempDir(), "exit-code")
+		args := exitCodeRecorder([]string{"sh", "-c", "echo 'it failed'; exit 2"}, exitCodeFile)
+
+		output, err := exec.Command(args[0], args[1:]...).Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "it failed\n", string(output))
+
+		recorded, err := os.ReadFile(exitCodeFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "2\n", string(recorded))
+	})
+
+	t.Run("should pass the arguments of the command as they are", func(t *testing.T) {
+		exitCodeFile := filepath.Join(t.TempDir(), "exit-code")
+		args := exitCodeRecorder([]string{"echo", "it's", "a $HOME"}, exitCodeFile)
+
+		output, err := exec.Command(args[0], args[1:]...).Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "it's a $HOME\n", string(output))
+
+		recorded, err := os.ReadFile(exitCodeFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "0\n", string(recorded))
+	})
+}
@@ -5,13 +5,18 @@ import (
 	"dagger.io/dagger"
 	"fmt"
 	"github.com/excoriate/stiletto/internal/core/adapters"
+	"github.com/excoriate/stiletto/internal/core/commands"
 	"github.com/excoriate/stiletto/internal/core/daggerio"
 	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/expr"
+	"github.com/excoriate/stiletto/internal/core/imagelock"
 	"github.com/excoriate/stiletto/internal/core/scheduler"
 	"github.com/excoriate/stiletto/internal/errors"
 	"github.com/excoriate/stiletto/internal/utils"
 	"go.uber.org/zap"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 type DaggerRunner struct {
@@ -41,17 +46,36 @@ type DaggerRunnerBuilder struct {
 
 type DaggerRunnerOptions struct {
 	ShowEnvVars bool
+
+	// FailFast stops the whole run as soon as a job fails. Otherwise, only the jobs that
+	// need the failed one are skipped.
+	FailFast bool
+
+	// Lockfile pins the container images of the tasks to their digests. Images that
+	// aren't pinned are pulled by their tag, unless Frozen is set.
+	Lockfile *imagelock.Lockfile
+
+	// Frozen fails the run (before any job runs) if the image of a task isn't pinned.
+	Frozen bool
 }
 
-func (r *DaggerRunner) RunInDagger(jobs []entities.Job) error {
+// RunInDagger runs the jobs in Dagger, and returns the outcome of each one of them,
+// even if some of them failed.
+func (r *DaggerRunner) RunInDagger(jobs []entities.Job) (*ExecutionResult, error) {
+	result := &ExecutionResult{}
+
 	if len(jobs) == 0 {
-		return errors.NewRunnerConfigurationError("No jobs to run", nil)
+		return result, errors.NewRunnerConfigurationError("No jobs to run", nil)
+	}
+
+	if err := r.validatePinnedImages(jobs); err != nil {
+		return result, err
 	}
 
 	daggerClient := r.DaggerClient
 
 	if daggerClient == nil && r.Client.CfgDagger.Client == nil {
-		return errors.NewRunnerConfigurationError("No Dagger engine ("+
+		return result, errors.NewRunnerConfigurationError("No Dagger engine ("+
 			"or client) found in either the client instance or the scheduler.", nil)
 	}
 
@@ -62,86 +86,444 @@ func (r *DaggerRunner) RunInDagger(jobs []entities.Job) error {
 		Build()
 
 	if err != nil {
-		return errors.NewRunnerConfigurationError("Failed to run jobs in Dagger", err)
+		return result, errors.NewRunnerConfigurationError("Failed to run jobs in Dagger", err)
 	}
 
 	defer daggerClient.Close()
 
+	// Jobs that didn't succeed, either because they failed, or because they were skipped.
+	failedJobs := map[string]bool{}
+	skippedJobs := map[string]bool{}
+	var failedJobsErrs []string
+
 	for _, job := range jobs {
-		if len(job.Tasks) == 0 {
-			errMsg := fmt.Sprintf("Job %s with id %s has no tasks. Continuing... ", job.Name,
-				job.Id)
-			r.Logger.Warn(errMsg)
+		if need, ok := r.unsuccessfulNeed(job, failedJobs, skippedJobs); ok {
+			r.Logger.Warn(fmt.Sprintf("Job %s with id %s is skipped, since the job it needs '%s' did"+
+				" not succeed", job.Name, job.Id, need))
+			skippedJobs[job.Name] = true
+			result.Jobs = append(result.Jobs, JobResult{
+				Name:   job.Name,
+				Status: StatusSkipped,
+				Reason: fmt.Sprintf("the job it needs '%s' did not succeed", need),
+			})
 
 			continue
 		}
 
-		// Get the current host directory.
-		baseDirAbs := job.BaseDirAbs
-		r.Logger.Info(fmt.Sprintf("Job %s will be executed from base directory %s", job.Name, baseDirAbs))
+		jobResult, err := r.runJob(job, daggerClient, daggerFs)
+		result.Jobs = append(result.Jobs, jobResult)
 
-		for _, task := range job.Tasks {
-			// Directory to copy to the container, aka 'mount directory'.
-			mountDirPathAbs := filepath.Join(baseDirAbs, task.MountDir)
-			r.Logger.Info(fmt.Sprintf("Task %s with id %s will be executed from mount directory %s", task.Name, task.Id, mountDirPathAbs))
+		if err != nil {
+			if r.Options.FailFast {
+				return result, err
+			}
+
+			r.Logger.Error(fmt.Sprintf("Job %s with id %s failed: %s", job.Name, job.Id, err))
+			failedJobs[job.Name] = true
+			failedJobsErrs = append(failedJobsErrs, fmt.Sprintf("%s (%s)", job.Name, err))
+		}
+	}
+
+	if len(failedJobsErrs) != 0 {
+		return result, errors.NewRunnerExecutionError(fmt.Sprintf("%d job(s) failed, and %d job(s) were skipped"+
+			": %s", len(failedJobsErrs), len(skippedJobs), strings.Join(failedJobsErrs, ", ")), nil)
+	}
+
+	if result.Status() == StatusWarning {
+		r.Logger.Warn("All jobs were executed, and they succeeded with warnings")
+		return result, nil
+	}
 
-			if err := daggerFs.ValidateEntries(mountDirPathAbs); err != nil {
-				return errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
+	r.Logger.Info("All jobs were executed successfully")
+	return result, nil
+
+}
+
+// validatePinnedImages checks, in frozen mode, that the image of each task is pinned in
+// the lockfile. Otherwise, the tasks would run on images that could drift.
+func (r *DaggerRunner) validatePinnedImages(jobs []entities.Job) error {
+	if !r.Options.Frozen {
+		return nil
+	}
+
+	if r.Options.Lockfile == nil {
+		return errors.NewRunnerConfigurationError("The run is frozen, but no lockfile was found. "+
+			"Run 'stiletto lock' to create it", nil)
+	}
+
+	var unpinned []string
+	for _, job := range jobs {
+		for _, task := range job.Tasks {
+			if _, ok := r.Options.Lockfile.Pinned(task.ContainerImage); !ok {
+				unpinned = append(unpinned, fmt.Sprintf("%s (task %s)", task.ContainerImage, task.Name))
 			}
+		}
+	}
+
+	if len(unpinned) != 0 {
+		return errors.NewRunnerConfigurationError(fmt.Sprintf("The run is frozen, but some images aren't "+
+			"pinned in the lockfile: %s. Run 'stiletto lock' to pin them", strings.Join(unpinned, ", ")), nil)
+	}
+
+	return nil
+}
+
+// containerImage returns the image the task runs on, pinned to its digest if it's in the lockfile.
+func (r *DaggerRunner) containerImage(task entities.Task) string {
+	if r.Options.Lockfile == nil {
+		return task.ContainerImage
+	}
+
+	image, ok := r.Options.Lockfile.Pinned(task.ContainerImage)
+	if ok {
+		r.Logger.Info(fmt.Sprintf("Task %s with id %s will run on the pinned image %s", task.Name, task.Id, image))
+	}
+
+	return image
+}
+
+// unsuccessfulNeed returns the first job needed by the given job that either failed, or was skipped.
+func (r *DaggerRunner) unsuccessfulNeed(job entities.Job, failedJobs, skippedJobs map[string]bool) (string, bool) {
+	for _, need := range job.Needs {
+		if failedJobs[need] || skippedJobs[need] {
+			return need, true
+		}
+	}
+
+	return "", false
+}
+
+// runJob runs every task of the job, in the order they're declared. Once a task fails,
+// the next ones are skipped, unless their 'if' condition uses a status function (E.g.:
+// 'failure()', or 'always()').
+func (r *DaggerRunner) runJob(job entities.Job, daggerClient *dagger.Client, daggerFs *daggerio.Fs) (JobResult,
+	error) {
+	result := JobResult{Name: job.Name, Status: StatusSuccess}
+
+	if len(job.Tasks) == 0 {
+		errMsg := fmt.Sprintf("Job %s with id %s has no tasks. Continuing... ", job.Name,
+			job.Id)
+		r.Logger.Warn(errMsg)
+
+		return result, nil
+	}
+
+	// Get the current host directory.
+	baseDirAbs := job.BaseDirAbs
+	r.Logger.Info(fmt.Sprintf("Job %s will be executed from base directory %s", job.Name, baseDirAbs))
 
-			mountDir, _ := daggerFs.GetDaggerDir(mountDirPathAbs)
+	// The timeouts of the job, its tasks and their commands are enforced through contexts
+	// derived from each other.
+	jobScope := newTimeoutScope(*job.Client.Ctx, "job", job.Name, job.Timeout)
+	defer jobScope.cancel()
 
-			// Mounting/copying the directory to the container.
-			container := daggerClient.Container().From(task.ContainerImage)
-			container = container.WithDirectory(daggerFs.GetMntDir(), mountDir)
+	// Status of the tasks that already ran, by name. They're available in the conditions.
+	taskStatuses := map[string]string{}
+	var jobErr error
 
-			_ = daggerFs.PrintEntries(mountDir)
+	for _, task := range job.Tasks {
+		conditionCtx := r.conditionContext(task, jobErr != nil, taskStatuses)
 
-			// WorkDir validation within dagger.
-			workDirPathAbs := filepath.Join(mountDirPathAbs, task.Workdir)
-			r.Logger.Info(fmt.Sprintf("Task %s with id %s will be executed from work directory %s", task.Name, task.Id, workDirPathAbs))
+		taskResult, err := r.runTask(job, task, jobScope, conditionCtx, daggerClient, daggerFs)
+		result.Tasks = append(result.Tasks, taskResult)
+		taskStatuses[task.Name] = string(taskResult.Status)
 
-			if err := daggerFs.ValidateEntries(workDirPathAbs); err != nil {
-				return errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
+		if err != nil && jobErr == nil {
+			jobErr = err
+		}
+	}
+
+	if jobErr != nil {
+		result.Status = StatusFailure
+		result.Reason = jobErr.Error()
+	} else {
+		for _, taskResult := range result.Tasks {
+			if taskResult.Status == StatusWarning {
+				result.Status = StatusWarning
 			}
+		}
+	}
+
+	return result, jobErr
+}
+
+// runTask runs the commands of the task, if its condition is met. If the task has a
+// retry policy, it's run again, in a new container, when it fails.
+func (r *DaggerRunner) runTask(job entities.Job, task entities.Task, jobScope *timeoutScope,
+	conditionCtx expr.Context, daggerClient *dagger.Client, daggerFs *daggerio.Fs) (TaskResult, error) {
+	result := TaskResult{Name: task.Name, Status: StatusSuccess}
 
-			workDir, _ := daggerFs.GetDaggerDir(workDirPathAbs)
-			_ = daggerFs.PrintEntries(workDir)
+	shouldRun, err := expr.Evaluate(task.Condition, conditionCtx)
+	if err != nil {
+		result.Status = StatusFailure
+		result.Reason = err.Error()
+
+		return result, errors.NewTaskExecutionError(fmt.Sprintf("Cannot evaluate the condition of task %s"+
+			" with id %s", task.Name, task.Id), err)
+	}
+
+	if !shouldRun {
+		result.Status = StatusSkipped
+		result.Reason = skipReason(task.Condition, "task")
+		r.Logger.Warn(fmt.Sprintf("Task %s with id %s is skipped, since %s", task.Name, task.Id, result.Reason))
 
-			if !utils.MapIsNulOrEmpty(task.EnvVars) {
-				container, _ = daggerio.SetEnvVarsInContainer(container, task.EnvVars)
+		return result, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		startedAt := time.Now()
+		commandResults, failure, err := r.runTaskAttempt(job, task, jobScope, conditionCtx, daggerClient, daggerFs)
+		result.Commands = commandResults
+		result.Attempts = append(result.Attempts, newAttemptResult(attempt, time.Since(startedAt), failure, err))
+
+		if err == nil {
+			if hasWarnings(commandResults) {
+				result.Status = StatusWarning
 			}
 
-			if r.Options.ShowEnvVars {
-				envVars, err := daggerio.GetEnvVarsSetInContainer(container, r.Ctx)
-				if err != nil {
-					return errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
-				}
-
-				for _, envVar := range envVars {
-					name, _ := envVar.Name(*r.Ctx)
-					value, _ := envVar.Value(*r.Ctx)
-					r.Logger.Info(fmt.Sprintf("EnvVar: %s=%s", name, value))
-				}
+			return result, nil
+		}
+
+		if !canRetry(jobScope.ctx, task.Retry, attempt, failure) ||
+			r.waitForRetry(jobScope.ctx, "task", task.Name, task.Retry, attempt, err) != nil {
+			result.Status = StatusFailure
+			result.Reason = err.Error()
+
+			return result, err
+		}
+	}
+}
+
+// runTaskAttempt runs the commands of the task in a new container. It returns the
+// failure of the first command that failed, if any.
+func (r *DaggerRunner) runTaskAttempt(job entities.Job, task entities.Task, jobScope *timeoutScope,
+	conditionCtx expr.Context, daggerClient *dagger.Client, daggerFs *daggerio.Fs) ([]CommandResult,
+	*execFailure, error) {
+	baseDirAbs := job.BaseDirAbs
+
+	// Directory to copy to the container, aka 'mount directory'.
+	mountDirPathAbs := filepath.Join(baseDirAbs, task.MountDir)
+	r.Logger.Info(fmt.Sprintf("Task %s with id %s will be executed from mount directory %s", task.Name, task.Id, mountDirPathAbs))
+
+	if err := daggerFs.ValidateEntries(mountDirPathAbs); err != nil {
+		return nil, nil, errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
+	}
+
+	mountDir, _ := daggerFs.GetDaggerDir(mountDirPathAbs)
+
+	// Mounting/copying the directory to the container.
+	container := daggerClient.Container().From(r.containerImage(task))
+	container = container.WithDirectory(daggerFs.GetMntDir(), mountDir)
+
+	_ = daggerFs.PrintEntries(mountDir)
+
+	// WorkDir validation within dagger.
+	workDirPathAbs := filepath.Join(mountDirPathAbs, task.Workdir)
+	r.Logger.Info(fmt.Sprintf("Task %s with id %s will be executed from work directory %s", task.Name, task.Id, workDirPathAbs))
+
+	if err := daggerFs.ValidateEntries(workDirPathAbs); err != nil {
+		return nil, nil, errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
+	}
+
+	workDir, _ := daggerFs.GetDaggerDir(workDirPathAbs)
+	_ = daggerFs.PrintEntries(workDir)
+
+	// The git metadata is set first, so the env vars of the task can override it.
+	if gitEnvVars := r.Client.CfgDir.GitEnvVars(); !utils.MapIsNulOrEmpty(gitEnvVars) {
+		container, _ = daggerio.SetEnvVarsInContainer(container, gitEnvVars)
+	}
+
+	if !utils.MapIsNulOrEmpty(task.EnvVars) {
+		container, _ = daggerio.SetEnvVarsInContainer(container, task.EnvVars)
+	}
+
+	if r.Options.ShowEnvVars {
+		envVars, err := daggerio.GetEnvVarsSetInContainer(container, r.Ctx)
+		if err != nil {
+			return nil, nil, errors.NewTaskExecutionError(fmt.Sprintf("Failed to run task %s with id %s", task.Name, task.Id), err)
+		}
+
+		for _, envVar := range envVars {
+			name, _ := envVar.Name(*r.Ctx)
+			value, _ := envVar.Value(*r.Ctx)
+			r.Logger.Info(fmt.Sprintf("EnvVar: %s=%s", name, value))
+		}
+	}
+
+	workDirPath := filepath.Join(daggerFs.GetMntDir(), task.Workdir)
+	container = container.WithWorkdir(workDirPath)
+
+	// The script files are copied out of the base directory, next to the mount directory.
+	for _, cmd := range task.CommandsCfg {
+		if cmd.ScriptFile != "" {
+			scriptFile := daggerClient.Host().File(filepath.Join(baseDirAbs, cmd.ScriptFile))
+			container = container.WithFile(commands.ScriptPath(cmd.ScriptFile), scriptFile,
+				dagger.ContainerWithFileOpts{Permissions: 0755})
+		}
+	}
+
+	taskScope := newTimeoutScope(jobScope.ctx, "task", task.Name, task.Timeout)
+	defer taskScope.cancel()
+
+	return r.runCommands(task, &daggerContainer{container: container}, conditionCtx, jobScope, taskScope)
+}
+
+// runCommands runs the commands of the task, starting on the container passed. It returns
+// the failure of the first command that failed, if any.
+func (r *DaggerRunner) runCommands(task entities.Task, container commandContainer, conditionCtx expr.Context,
+	jobScope, taskScope *timeoutScope) ([]CommandResult, *execFailure, error) {
+	// Run specific set of commands per task. Each command runs on the container left by
+	// the previous one (unless it's isolated), like a shell session. Once a command fails,
+	// the next ones are skipped, unless their condition says otherwise.
+	var commandResults []CommandResult
+	var taskFailure *execFailure
+	var taskErr error
+
+	for _, cmd := range task.CommandsCfg {
+		cmdConditionCtx := conditionCtx
+		cmdConditionCtx.Failed = taskErr != nil
+
+		var cmdResult CommandResult
+		var failure *execFailure
+
+		shouldRun, err := expr.Evaluate(cmd.Condition, cmdConditionCtx)
+		switch {
+		case err != nil:
+			cmdResult = CommandResult{Command: cmd.String(), Status: StatusFailure,
+				Reason: err.Error()}
+			err = errors.NewTaskExecutionError(fmt.Sprintf("Cannot evaluate the condition of a command of"+
+				" task %s with id %s", task.Name, task.Id), err)
+		case !shouldRun:
+			cmdResult = CommandResult{Command: cmd.String(), Status: StatusSkipped,
+				Reason: skipReason(cmd.Condition, "command")}
+			r.Logger.Warn(fmt.Sprintf("Command '%s' of task %s with id %s is skipped, since %s",
+				cmdResult.Command, task.Name, task.Id, cmdResult.Reason))
+		default:
+			var next commandContainer
+			cmdResult, next, failure, err = r.runCommand(task, cmd, container, jobScope, taskScope)
+
+			if next != nil && !cmd.Isolated {
+				container = next
 			}
+		}
+
+		commandResults = append(commandResults, cmdResult)
+
+		if err != nil && taskErr == nil {
+			taskErr = err
+			taskFailure = failure
+		}
+	}
+
+	return commandResults, taskFailure, taskErr
+}
+
+// runCommand runs a command of the task, and returns the container it leaves, if it
+// succeeded, exited with an allowed exit code, or failed but continues on error. If the
+// command has a retry policy, it's run again (on the same container) when it fails, and
+// each attempt is recorded.
+func (r *DaggerRunner) runCommand(task entities.Task, cmd *commands.CMD, container commandContainer,
+	jobScope, taskScope *timeoutScope) (CommandResult, commandContainer, *execFailure, error) {
+	result := CommandResult{Command: cmd.String(), Status: StatusSuccess}
+
+	for attempt := 1; ; attempt++ {
+		cmdScope := newTimeoutScope(taskScope.ctx, "command", result.Command, cmd.Timeout)
+		startedAt := time.Now()
+
+		// The container a command that fails leaves is only kept if the failure is recorded
+		// as a warning. E.g.: the plan that 'terraform plan -detailed-exitcode' writes.
+		keepOnFailure := cmd.ContinueOnError || len(cmd.AllowedExitCodes) != 0
+		next, failure, err := container.Exec(cmdScope.ctx, cmd.Commands, keepOnFailure)
+		duration := time.Since(startedAt)
+		cmdScope.cancel()
+
+		result.Duration += duration
+
+		if err == nil {
+			result.Attempts = append(result.Attempts, newAttemptResult(attempt, duration, nil, nil))
+			return result, next, nil, nil
+		}
+
+		if failure.exitCode > 0 && cmd.AllowsExitCode(failure.exitCode) {
+			result.Attempts = append(result.Attempts, newAttemptResult(attempt, duration, nil, nil))
+			result.Status = StatusWarning
+			result.Reason = fmt.Sprintf("it exited with the allowed exit code %d", failure.exitCode)
+			r.Logger.Warn(fmt.Sprintf("Command '%s' of task %s with id %s succeeded with a warning, since %s",
+				result.Command, task.Name, task.Id, result.Reason))
+
+			return result, next, nil, nil
+		}
+
+		r.Logger.Error(fmt.Sprintf("Task %s with id %s failed to run", task.Name, task.Id))
+
+		if scope, ok := expiredScope(jobScope, taskScope, cmdScope); ok {
+			err = errors.NewTaskExecutionError(fmt.Sprintf("Command '%s' of task %s with id %s timed out"+
+				" after %s, since the timeout of the %s '%s' is %s", result.Command, task.Name, task.Id,
+				duration.Round(time.Millisecond), scope.kind, scope.name, scope.timeout), err)
+		} else {
+			err = errors.NewTaskExecutionError(fmt.Sprintf("Task %s with id %s failed to run", task.Name, task.Id), err)
+		}
 
-			workDirPath := filepath.Join(daggerFs.GetMntDir(), task.Workdir)
-			container = container.WithWorkdir(workDirPath)
+		result.Attempts = append(result.Attempts, newAttemptResult(attempt, duration, failure, err))
 
-			// Run specific set of commands per task.
-			for _, cmd := range task.CommandsCfg {
-				_, err := container.WithExec(cmd.Commands).Sync(*job.Client.Ctx)
-				if err != nil {
-					r.Logger.Error(fmt.Sprintf("Task %s with id %s failed to run", task.Name, task.Id))
-					return errors.NewTaskExecutionError(fmt.Sprintf("Task %s with id %s failed to run", task.Name, task.Id), err)
-				}
+		if !canRetry(taskScope.ctx, cmd.Retry, attempt, failure) ||
+			r.waitForRetry(taskScope.ctx, "command", result.Command, cmd.Retry, attempt, err) != nil {
+			result.Reason = err.Error()
+
+			if cmd.ContinueOnError {
+				result.Status = StatusWarning
+				r.Logger.Warn(fmt.Sprintf("Command '%s' of task %s with id %s failed, but the task continues, "+
+					"since the command is marked with 'continueOnError'", result.Command, task.Name, task.Id))
+
+				return result, next, nil, nil
 			}
+
+			result.Status = StatusFailure
+
+			return result, nil, failure, err
 		}
 	}
+}
 
-	r.Logger.Info("All jobs were executed successfully")
-	return nil
+// conditionContext returns the variables available in the 'if' conditions of the task,
+// and of its commands.
+func (r *DaggerRunner) conditionContext(task entities.Task, failed bool,
+	taskStatuses map[string]string) expr.Context {
+	hostEnvVars := utils.HostEnvVars()
+
+	git := map[string]interface{}{"isRepo": false}
+	if dirCfg := r.Client.CfgDir; dirCfg != nil {
+		git = map[string]interface{}{
+			"isRepo": dirCfg.IsGitRepo,
+			"branch": dirCfg.GitBranch,
+			"sha":    dirCfg.GitSHA,
+			"tag":    dirCfg.GitTag,
+			"remote": dirCfg.GitRemote,
+			"dirty":  expr.Lazy(func() interface{} { return dirCfg.IsGitDirty() }),
+		}
+	}
+
+	statuses := map[string]string{}
+	for name, status := range taskStatuses {
+		statuses[name] = status
+	}
+
+	return expr.Context{
+		Env:    utils.MergeEnvVars(hostEnvVars, task.EnvVars),
+		Inputs: task.Inputs,
+		Git:    git,
+		Tasks:  statuses,
+		Failed: failed,
+	}
+}
+
+// skipReason explains why a task, or a command, was skipped.
+func skipReason(condition, step string) string {
+	if strings.TrimSpace(condition) == "" {
+		return fmt.Sprintf("a previous %s failed", step)
+	}
 
+	return fmt.Sprintf("the condition '%s' is false", condition)
 }
 
 func (b *DaggerRunnerBuilder) WithOptions(opt DaggerRunnerOptions) *DaggerRunnerBuilder {
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"github.com/excoriate/stiletto/internal/core/commands"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/expr"
+	"github.com/excoriate/stiletto/internal/core/imagelock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPinnedImages(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	lock := imagelock.New()
+	lock.Images["alpine/terragrunt"] = imagelock.LockedImage{Digest: digest}
+
+	jobs := []entities.Job{{Name: "plan", Tasks: []entities.Task{
+		{Name: "init", ContainerImage: "alpine/terragrunt"},
+		{Name: "lint", ContainerImage: "golang:1.20"},
+	}}}
+
+	t.Run("should run the tasks on the pinned images", func(t *testing.T) {
+		r := &DaggerRunner{Logger: zap.NewNop(), Options: DaggerRunnerOptions{Lockfile: lock}}
+
+		assert.Equal(t, "alpine/terragrunt@"+digest, r.containerImage(jobs[0].Tasks[0]))
+		assert.Equal(t, "golang:1.20", r.containerImage(jobs[0].Tasks[1]))
+		assert.NoError(t, r.validatePinnedImages(jobs))
+	})
+
+	t.Run("should fail a frozen run if an image isn't pinned", func(t *testing.T) {
+		r := &DaggerRunner{Logger: zap.NewNop(), Options: DaggerRunnerOptions{Lockfile: lock, Frozen: true}}
+		err := r.validatePinnedImages(jobs)
+
+		assert.ErrorContains(t, err, "golang:1.20 (task lint)")
+		assert.NotContains(t, err.Error(), "alpine/terragrunt")
+
+		r.Options.Lockfile = nil
+		assert.ErrorContains(t, r.validatePinnedImages(jobs), "no lockfile was found")
+	})
+}
+
+func TestConditionContext(t *testing.T) {
+	t.Run("should keep the env vars of the host whose values contain '='", func(t *testing.T) {
+		t.Setenv("STILETTO_TEST_TOKEN", "dG9rZW4=")
+
+		r := &DaggerRunner{Logger: zap.NewNop(), Client: &entities.Client{}}
+		ctx := r.conditionContext(entities.Task{Name: "deploy"}, false, map[string]string{})
+
+		assert.Equal(t, "dG9rZW4=", ctx.Env["STILETTO_TEST_TOKEN"])
+	})
+}
+
+func TestRunCommands(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("the sh binary isn't available")
+	}
+
+	r := &DaggerRunner{Logger: zap.NewNop(), Client: &entities.Client{}}
+
+	runCommands := func(t *testing.T, first *commands.CMD) ([]CommandResult, error) {
+		task := entities.Task{Name: "plan", Id: "plan", CommandsCfg: []*commands.CMD{
+			first,
+			{Script: "cat plan", Commands: []string{"sh", "-c", "cat plan"}},
+		}}
+
+		jobScope := newTimeoutScope(context.Background(), "job", "terraform", 0)
+		taskScope := newTimeoutScope(jobScope.ctx, "task", task.Name, 0)
+
+		results, _, err := r.runCommands(task, &hostContainer{t: t, dir: t.TempDir()}, expr.Context{},
+			jobScope, taskScope)
+
+		return results, err
+	}
+
+	t.Run("should pass on the container of a command that exited with an allowed exit code", func(t *testing.T) {
+		results, err := runCommands(t, &commands.CMD{Script: "terraform plan", AllowedExitCodes: []int{2},
+			Commands: []string{"sh", "-c", "echo changes > plan; exit 2"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusWarning, results[0].Status)
+		assert.Equal(t, StatusSuccess, results[1].Status)
+	})
+
+	t.Run("should pass on the container of a command that failed, but continues on error", func(t *testing.T) {
+		results, err := runCommands(t, &commands.CMD{Script: "terraform plan", ContinueOnError: true,
+			Commands: []string{"sh", "-c", "echo changes > plan; exit 1"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusWarning, results[0].Status)
+		assert.Equal(t, StatusSuccess, results[1].Status)
+	})
+
+	t.Run("should fail with an exit code that isn't allowed", func(t *testing.T) {
+		results, err := runCommands(t, &commands.CMD{Script: "terraform plan", AllowedExitCodes: []int{2},
+			Commands: []string{"sh", "-c", "echo changes > plan; exit 1"}})
+
+		assert.Error(t, err)
+		assert.Equal(t, StatusFailure, results[0].Status)
+		assert.Equal(t, StatusSkipped, results[1].Status)
+	})
+}
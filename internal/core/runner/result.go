@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status is the outcome of a job, a task, or a command.
+type Status string
+
+const (
+	StatusSuccess Status = "success"
+	StatusFailure Status = "failure"
+	StatusSkipped Status = "skipped"
+	// StatusWarning means it succeeded with warnings. E.g.: a command failed, but it was
+	// marked with 'continueOnError', or it exited with one of its 'allowedExitCodes'.
+	StatusWarning Status = "warning"
+)
+
+// ExecutionResult is the outcome of each one of the jobs run by the runner, in the
+// order they ran. The skipped ones are recorded as well, along with the reason.
+type ExecutionResult struct {
+	Jobs []JobResult
+}
+
+type JobResult struct {
+	Name   string
+	Status Status
+	// Reason explains why the job was skipped, or why it failed.
+	Reason string
+	Tasks  []TaskResult
+}
+
+type TaskResult struct {
+	Name     string
+	Status   Status
+	Reason   string
+	Commands []CommandResult
+	// Attempts are the runs of the task. There's more than one if the task was retried.
+	Attempts []AttemptResult
+}
+
+type CommandResult struct {
+	// Command is the command run, with its binary and arguments.
+	Command string
+	Status  Status
+	Reason  string
+	// Duration is how long the command ran, adding up all its attempts.
+	Duration time.Duration
+	// Attempts are the runs of the command. There's more than one if the command was retried.
+	Attempts []AttemptResult
+}
+
+// AttemptResult is a run of a command, or a task.
+type AttemptResult struct {
+	// Number is the number of the attempt, starting at 1.
+	Number   int
+	Status   Status
+	Duration time.Duration
+	// ExitCode is the exit code of the command that failed, or -1 if it didn't exit (E.g.: it timed out).
+	ExitCode int
+	Reason   string
+}
+
+// Status returns the status of the whole run: a failure if any of its jobs failed,
+// a warning if any of them succeeded with warnings, and a success otherwise.
+func (r *ExecutionResult) Status() Status {
+	status := StatusSuccess
+
+	for _, job := range r.Jobs {
+		switch job.Status {
+		case StatusFailure:
+			return StatusFailure
+		case StatusWarning:
+			status = StatusWarning
+		}
+	}
+
+	return status
+}
+
+// Warnings describes each one of the commands that succeeded with a warning.
+// E.g.: "command 'terraform plan -detailed-exitcode' of task 'plan' (it exited with the allowed exit code 2)".
+func (r *ExecutionResult) Warnings() []string {
+	var warnings []string
+
+	for _, job := range r.Jobs {
+		for _, task := range job.Tasks {
+			for _, cmd := range task.Commands {
+				if cmd.Status == StatusWarning {
+					warnings = append(warnings, fmt.Sprintf("command '%s' of task '%s' (%s)", cmd.Command,
+						task.Name, cmd.Reason))
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// Retried describes each one of the tasks and commands that needed more than one attempt.
+// E.g.: "command 'terraform init' of task 'plan' (succeeded after 2 attempt(s))".
+func (r *ExecutionResult) Retried() []string {
+	var retried []string
+
+	for _, job := range r.Jobs {
+		for _, task := range job.Tasks {
+			if len(task.Attempts) > 1 {
+				retried = append(retried, fmt.Sprintf("task '%s' of job '%s' (%s)", task.Name, job.Name,
+					describeAttempts(task.Status, task.Attempts)))
+			}
+
+			for _, cmd := range task.Commands {
+				if len(cmd.Attempts) > 1 {
+					retried = append(retried, fmt.Sprintf("command '%s' of task '%s' (%s)", cmd.Command, task.Name,
+						describeAttempts(cmd.Status, cmd.Attempts)))
+				}
+			}
+		}
+	}
+
+	return retried
+}
+
+func describeAttempts(status Status, attempts []AttemptResult) string {
+	if status == StatusFailure {
+		return fmt.Sprintf("failed after %d attempt(s)", len(attempts))
+	}
+
+	return fmt.Sprintf("succeeded after %d attempt(s)", len(attempts))
+}
+
+// Skipped describes each one of the jobs, tasks and commands that were skipped.
+// E.g.: "task 'deploy' of job 'release' (the condition 'git.branch == \"main\"' is false)".
+func (r *ExecutionResult) Skipped() []string {
+	var skipped []string
+
+	for _, job := range r.Jobs {
+		if job.Status == StatusSkipped {
+			skipped = append(skipped, fmt.Sprintf("job '%s' (%s)", job.Name, job.Reason))
+			continue
+		}
+
+		for _, task := range job.Tasks {
+			if task.Status == StatusSkipped {
+				skipped = append(skipped, fmt.Sprintf("task '%s' of job '%s' (%s)", task.Name, job.Name,
+					task.Reason))
+				continue
+			}
+
+			for _, cmd := range task.Commands {
+				if cmd.Status == StatusSkipped {
+					skipped = append(skipped, fmt.Sprintf("command '%s' of task '%s' (%s)", cmd.Command,
+						task.Name, cmd.Reason))
+				}
+			}
+		}
+	}
+
+	return skipped
+}
+
+func hasWarnings(commands []CommandResult) bool {
+	for _, cmd := range commands {
+		if cmd.Status == StatusWarning {
+			return true
+		}
+	}
+
+	return false
+}
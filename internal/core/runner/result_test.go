@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExecutionResult(t *testing.T) {
+	t.Run("should succeed with warnings if a command was allowed to fail", func(t *testing.T) {
+		result := &ExecutionResult{Jobs: []JobResult{
+			{Name: "build", Status: StatusSuccess},
+			{Name: "plan", Status: StatusWarning, Tasks: []TaskResult{
+				{Name: "plan", Status: StatusWarning, Commands: []CommandResult{
+					{Command: "terraform init", Status: StatusSuccess},
+					{Command: "terraform plan -detailed-exitcode", Status: StatusWarning,
+						Reason: "it exited with the allowed exit code 2"},
+				}},
+			}},
+			{Name: "deploy", Status: StatusSkipped, Reason: "the job it needs 'approve' did not succeed"},
+		}}
+
+		assert.Equal(t, StatusWarning, result.Status())
+		assert.Equal(t, []string{"command 'terraform plan -detailed-exitcode' of task 'plan' " +
+			"(it exited with the allowed exit code 2)"}, result.Warnings())
+		assert.Equal(t, []string{"job 'deploy' (the job it needs 'approve' did not succeed)"}, result.Skipped())
+	})
+
+	t.Run("should fail if any of the jobs failed", func(t *testing.T) {
+		result := &ExecutionResult{Jobs: []JobResult{
+			{Name: "plan", Status: StatusWarning},
+			{Name: "apply", Status: StatusFailure},
+		}}
+
+		assert.Equal(t, StatusFailure, result.Status())
+	})
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"dagger.io/dagger"
+	goerrors "errors"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/retry"
+	"time"
+)
+
+// execFailure describes why a command failed, so the retry policy can decide whether
+// to run it again.
+type execFailure struct {
+	// exitCode is -1 if the command didn't exit. E.g.: it timed out.
+	exitCode int
+	output   string
+}
+
+func newExecFailure(err error) *execFailure {
+	var execErr *dagger.ExecError
+	if goerrors.As(err, &execErr) {
+		return &execFailure{exitCode: execErr.ExitCode, output: execErr.Stdout + "\n" + execErr.Stderr}
+	}
+
+	return &execFailure{exitCode: -1, output: err.Error()}
+}
+
+func newAttemptResult(number int, duration time.Duration, failure *execFailure, err error) AttemptResult {
+	if err == nil {
+		return AttemptResult{Number: number, Status: StatusSuccess, Duration: duration}
+	}
+
+	attempt := AttemptResult{Number: number, Status: StatusFailure, Duration: duration, ExitCode: -1,
+		Reason: err.Error()}
+	if failure != nil {
+		attempt.ExitCode = failure.exitCode
+	}
+
+	return attempt
+}
+
+// canRetry reports whether the failed attempt is run again. Only the failures of the
+// commands are retried (not the ones of the configuration, E.g.: a missing directory),
+// and only while the scope that contains the command, or the task, has time left.
+func canRetry(parentCtx context.Context, policy *retry.Policy, attempt int, failure *execFailure) bool {
+	return failure != nil && attempt < policy.Attempts() && parentCtx.Err() == nil &&
+		policy.ShouldRetry(failure.exitCode, failure.output)
+}
+
+// waitForRetry waits for the delay of the policy before the next attempt. It fails if
+// the context is done first.
+func (r *DaggerRunner) waitForRetry(ctx context.Context, kind, name string, policy *retry.Policy, attempt int,
+	err error) error {
+	delay := policy.DelayBefore(attempt + 1)
+	r.Logger.Warn(fmt.Sprintf("The %s '%s' failed (attempt %d of %d). Retrying in %s: %s", kind, name,
+		attempt, policy.Attempts(), delay, err))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
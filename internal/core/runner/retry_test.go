@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+	"dagger.io/dagger"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/retry"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanRetry(t *testing.T) {
+	policy := &retry.Policy{MaxAttempts: 3, OnExitCodes: []int{1}}
+
+	t.Run("should retry the failures of the commands that match the policy", func(t *testing.T) {
+		failure := newExecFailure(fmt.Errorf("failed: %w", &dagger.ExecError{ExitCode: 1, Stderr: "timeout"}))
+
+		assert.Equal(t, 1, failure.exitCode)
+		assert.True(t, canRetry(context.Background(), policy, 1, failure))
+		assert.True(t, canRetry(context.Background(), policy, 2, failure))
+		assert.False(t, canRetry(context.Background(), policy, 3, failure))
+		assert.False(t, canRetry(context.Background(), policy, 1, &execFailure{exitCode: 2}))
+		assert.False(t, canRetry(context.Background(), nil, 1, failure))
+	})
+
+	t.Run("should not retry the failures that aren't of a command", func(t *testing.T) {
+		assert.False(t, canRetry(context.Background(), &retry.Policy{MaxAttempts: 3}, 1, nil))
+	})
+
+	t.Run("should not retry once the parent scope is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.False(t, canRetry(ctx, policy, 1, &execFailure{exitCode: 1}))
+	})
+}
@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// timeoutScope is the context of a job, a task, or a command, with its timeout. The
+// context of a scope is derived from the one of the scope that contains it, so the
+// shortest timeout wins.
+type timeoutScope struct {
+	// kind is either 'job', 'task', or 'command'.
+	kind    string
+	name    string
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newTimeoutScope(parent context.Context, kind, name string, timeout time.Duration) *timeoutScope {
+	scope := &timeoutScope{kind: kind, name: name, timeout: timeout}
+
+	if timeout > 0 {
+		scope.ctx, scope.cancel = context.WithTimeout(parent, timeout)
+	} else {
+		scope.ctx, scope.cancel = context.WithCancel(parent)
+	}
+
+	return scope
+}
+
+// expiredScope returns the outermost scope whose timeout expired. The scopes are
+// passed from the outermost to the innermost one.
+func expiredScope(scopes ...*timeoutScope) (*timeoutScope, bool) {
+	for _, scope := range scopes {
+		if scope.timeout > 0 && scope.ctx.Err() == context.DeadlineExceeded {
+			return scope, true
+		}
+	}
+
+	return nil, false
+}
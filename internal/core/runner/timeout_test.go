@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExpiredScope(t *testing.T) {
+	t.Run("should blame the scope whose timeout expired", func(t *testing.T) {
+		jobScope := newTimeoutScope(context.Background(), "job", "release", time.Hour)
+		defer jobScope.cancel()
+
+		taskScope := newTimeoutScope(jobScope.ctx, "task", "apply", time.Millisecond)
+		defer taskScope.cancel()
+
+		cmdScope := newTimeoutScope(taskScope.ctx, "command", "terragrunt apply", 0)
+		defer cmdScope.cancel()
+
+		<-cmdScope.ctx.Done()
+
+		scope, ok := expiredScope(jobScope, taskScope, cmdScope)
+		assert.True(t, ok)
+		assert.Equal(t, "task", scope.kind)
+		assert.Equal(t, "apply", scope.name)
+	})
+
+	t.Run("should not blame any scope if there's no timeout", func(t *testing.T) {
+		jobScope := newTimeoutScope(context.Background(), "job", "release", 0)
+		jobScope.cancel()
+
+		_, ok := expiredScope(jobScope)
+		assert.False(t, ok)
+	})
+}
@@ -54,6 +54,10 @@ func (r *Builder) Build() (*ScheduledJobs, error) {
 }
 
 func (r *Builder) WithDaggerEngine() *Builder {
+	if r.error != nil {
+		return r
+	}
+
 	ctx := r.client.Ctx
 
 	daggerEngine, err := adapters.NewDaggerClient(ctx)
@@ -89,6 +93,44 @@ func (r *Builder) WithJobsToRun(job []entities.Job) *Builder {
 	return r
 }
 
+// WithDependencyGraph builds the dependency graph out of the 'needs' of the jobs to run, and
+// sorts them so every job runs after the jobs it needs.
+func (r *Builder) WithDependencyGraph() *Builder {
+	if r.error != nil {
+		return r
+	}
+
+	graph, err := NewDAG(r.jobs)
+	if err != nil {
+		r.logger.Error(err.Error())
+		r.error = err
+
+		return r
+	}
+
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		r.logger.Error(err.Error())
+		r.error = err
+
+		return r
+	}
+
+	jobsByName := map[string]entities.Job{}
+	for _, job := range r.jobs {
+		jobsByName[job.Name] = job
+	}
+
+	var sortedJobs []entities.Job
+	for _, name := range order {
+		sortedJobs = append(sortedJobs, jobsByName[name])
+	}
+
+	r.jobs = sortedJobs
+
+	return r
+}
+
 func (r *Builder) WithClient(c *entities.Client) *Builder {
 	r.client = c
 	r.logger = c.Logger
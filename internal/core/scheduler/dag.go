@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"strings"
+)
+
+// DAG is the dependency graph between jobs, built out of the 'needs' declared in each job.
+type DAG struct {
+	// names keeps the order in which the jobs were declared.
+	names []string
+	needs map[string][]string
+}
+
+// NewDAG builds the dependency graph of the given jobs. It fails if a job is declared
+// more than once, or if it needs a job that doesn't exist.
+func NewDAG(jobs []entities.Job) (*DAG, error) {
+	dag := &DAG{
+		needs: map[string][]string{},
+	}
+
+	for _, job := range jobs {
+		if _, ok := dag.needs[job.Name]; ok {
+			return nil, errors.NewConfigurationError(fmt.Sprintf(
+				"The job '%s' is declared more than once", job.Name), nil)
+		}
+
+		dag.names = append(dag.names, job.Name)
+		dag.needs[job.Name] = job.Needs
+	}
+
+	for _, name := range dag.names {
+		for _, need := range dag.needs[name] {
+			if need == name {
+				return nil, errors.NewConfigurationError(fmt.Sprintf(
+					"The job '%s' cannot need itself", name), nil)
+			}
+
+			if _, ok := dag.needs[need]; !ok {
+				return nil, errors.NewConfigurationError(fmt.Sprintf(
+					"The job '%s' needs the job '%s', but it doesn't exist", name, need), nil)
+			}
+		}
+	}
+
+	return dag, nil
+}
+
+// TopologicalOrder returns the name of the jobs in an order where every job comes after
+// the jobs it needs. Jobs without dependencies between them keep their declared order.
+// It fails if the graph has a cycle.
+func (d *DAG) TopologicalOrder() ([]string, error) {
+	if cycle := d.findCycle(); len(cycle) != 0 {
+		return nil, errors.NewConfigurationError(fmt.Sprintf(
+			"The jobs have a circular dependency: %s", strings.Join(cycle, " -> ")), nil)
+	}
+
+	var order []string
+	visited := map[string]bool{}
+
+	for len(order) < len(d.names) {
+		for _, name := range d.names {
+			if visited[name] || !d.allVisited(d.needs[name], visited) {
+				continue
+			}
+
+			visited[name] = true
+			order = append(order, name)
+
+			// Start over, so the earliest declared job that's ready always goes first.
+			break
+		}
+	}
+
+	return order, nil
+}
+
+func (d *DAG) allVisited(names []string, visited map[string]bool) bool {
+	for _, name := range names {
+		if !visited[name] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findCycle returns the path of the first cycle found, or nil if there's none.
+func (d *DAG) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := map[string]int{}
+	var path []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, need := range d.needs[name] {
+			switch state[need] {
+			case visiting:
+				for idx, inPath := range path {
+					if inPath == need {
+						cycle = append(append([]string{}, path[idx:]...), need)
+						break
+					}
+				}
+
+				return true
+			case unvisited:
+				if visit(need) {
+					return true
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = done
+
+		return false
+	}
+
+	for _, name := range d.names {
+		if state[name] == unvisited && visit(name) {
+			return cycle
+		}
+	}
+
+	return nil
+}
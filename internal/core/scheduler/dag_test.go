@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDAG(t *testing.T) {
+	t.Run("should sort the jobs so every job comes after the jobs it needs", func(t *testing.T) {
+		dag, err := NewDAG([]entities.Job{
+			{Name: "publish", Needs: []string{"build", "test"}},
+			{Name: "test", Needs: []string{"build"}},
+			{Name: "lint"},
+			{Name: "build"},
+		})
+
+		assert.NoError(t, err)
+
+		order, err := dag.TopologicalOrder()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"lint", "build", "test", "publish"}, order)
+	})
+
+	t.Run("should keep the declared order of the jobs without dependencies", func(t *testing.T) {
+		dag, err := NewDAG([]entities.Job{{Name: "c"}, {Name: "a"}, {Name: "b"}})
+
+		assert.NoError(t, err)
+
+		order, err := dag.TopologicalOrder()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"c", "a", "b"}, order)
+	})
+
+	t.Run("should detect a cycle and report its path", func(t *testing.T) {
+		dag, err := NewDAG([]entities.Job{
+			{Name: "build", Needs: []string{"publish"}},
+			{Name: "test", Needs: []string{"build"}},
+			{Name: "publish", Needs: []string{"test"}},
+		})
+
+		assert.NoError(t, err)
+
+		_, err = dag.TopologicalOrder()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "build -> publish -> test -> build")
+	})
+
+	t.Run("should fail when a job needs a job that doesn't exist", func(t *testing.T) {
+		_, err := NewDAG([]entities.Job{{Name: "test", Needs: []string{"build"}}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail when a job needs itself", func(t *testing.T) {
+		_, err := NewDAG([]entities.Job{{Name: "build", Needs: []string{"build"}}})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail when a job is declared more than once", func(t *testing.T) {
+		_, err := NewDAG([]entities.Job{{Name: "build"}, {Name: "build"}})
+
+		assert.Error(t, err)
+	})
+}
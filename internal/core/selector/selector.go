@@ -0,0 +1,210 @@
+package selector
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// labelKeyPattern is the format of a label key. E.g.: 'stage', or 'stiletto.io/team'.
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_./]*[A-Za-z0-9])?$`)
+
+// labelValuePattern is the format of a label value, which can be empty.
+var labelValuePattern = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?)?$`)
+
+type operator string
+
+const (
+	operatorEquals    operator = "="
+	operatorNotEquals operator = "!="
+	operatorExists    operator = "exists"
+	operatorNotExists operator = "!exists"
+)
+
+// requirement is a single term of a label selector. E.g.: 'stage=plan'.
+type requirement struct {
+	key      string
+	operator operator
+	value    string
+}
+
+func (r requirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+
+	switch r.operator {
+	case operatorEquals:
+		return ok && value == r.value
+	case operatorNotEquals:
+		return !ok || value != r.value
+	case operatorExists:
+		return ok
+	default:
+		return !ok
+	}
+}
+
+// Selector selects the tasks (or jobs) to run, out of all the ones loaded, by their name
+// or their labels. An empty selector selects everything.
+type Selector struct {
+	only    []string
+	exclude []string
+	labels  []requirement
+}
+
+// New returns a selector that matches the names that match any of the 'only' glob
+// patterns (if any), none of the 'exclude' glob patterns, and every label requirement.
+// The label requirements are in the form 'key=value', 'key!=value', 'key' (the label is
+// set) or '!key' (the label isn't set). E.g.: []string{"stage=plan", "team=infra"}.
+func New(only, exclude, labels []string) (*Selector, error) {
+	s := &Selector{}
+
+	for _, pattern := range only {
+		if err := validatePattern(pattern); err != nil {
+			return nil, err
+		}
+
+		s.only = append(s.only, pattern)
+	}
+
+	for _, pattern := range exclude {
+		if err := validatePattern(pattern); err != nil {
+			return nil, err
+		}
+
+		s.exclude = append(s.exclude, pattern)
+	}
+
+	for _, term := range labels {
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+
+		s.labels = append(s.labels, r)
+	}
+
+	return s, nil
+}
+
+// IsEmpty reports whether the selector selects everything.
+func (s *Selector) IsEmpty() bool {
+	return s == nil || (len(s.only) == 0 && len(s.exclude) == 0 && len(s.labels) == 0)
+}
+
+// Matches reports whether a task (or job), with the given name and labels, is selected.
+func (s *Selector) Matches(name string, labels map[string]string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+
+	if len(s.only) != 0 && !matchesAny(s.only, name) {
+		return false
+	}
+
+	if matchesAny(s.exclude, name) {
+		return false
+	}
+
+	for _, r := range s.labels {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String describes the selector. E.g.: to report that it selected nothing.
+func (s *Selector) String() string {
+	if s.IsEmpty() {
+		return "everything"
+	}
+
+	var terms []string
+	if len(s.only) != 0 {
+		terms = append(terms, fmt.Sprintf("names matching %s", strings.Join(s.only, ", ")))
+	}
+
+	if len(s.exclude) != 0 {
+		terms = append(terms, fmt.Sprintf("names not matching %s", strings.Join(s.exclude, ", ")))
+	}
+
+	if len(s.labels) != 0 {
+		var labels []string
+		for _, r := range s.labels {
+			switch r.operator {
+			case operatorExists:
+				labels = append(labels, r.key)
+			case operatorNotExists:
+				labels = append(labels, "!"+r.key)
+			default:
+				labels = append(labels, r.key+string(r.operator)+r.value)
+			}
+		}
+
+		terms = append(terms, fmt.Sprintf("labels %s", strings.Join(labels, ",")))
+	}
+
+	return strings.Join(terms, "; ")
+}
+
+// ValidateLabel returns an error if the key, or the value, of a label has an invalid
+// format. The labels are restricted to the characters a selector can express.
+func ValidateLabel(key, value string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key '%s'. It should start and end with an alphanumeric "+
+			"character, and contain only alphanumeric characters, '-', '_', '.' or '/'", key)
+	}
+
+	if !labelValuePattern.MatchString(value) {
+		return fmt.Errorf("invalid value '%s' of the label '%s'. It should be empty, or start and end "+
+			"with an alphanumeric character, and contain only alphanumeric characters, '-', '_' or '.'",
+			value, key)
+	}
+
+	return nil
+}
+
+func parseRequirement(term string) (requirement, error) {
+	term = strings.TrimSpace(term)
+
+	var r requirement
+	switch {
+	case strings.Contains(term, "!="):
+		key, value, _ := strings.Cut(term, "!=")
+		r = requirement{key: strings.TrimSpace(key), operator: operatorNotEquals, value: strings.TrimSpace(value)}
+	case strings.Contains(term, "="):
+		key, value, _ := strings.Cut(term, "=")
+		r = requirement{key: strings.TrimSpace(key), operator: operatorEquals,
+			value: strings.TrimPrefix(strings.TrimSpace(value), "=")}
+	case strings.HasPrefix(term, "!"):
+		r = requirement{key: strings.TrimSpace(term[1:]), operator: operatorNotExists}
+	default:
+		r = requirement{key: term, operator: operatorExists}
+	}
+
+	if err := ValidateLabel(r.key, r.value); err != nil {
+		return requirement{}, fmt.Errorf("invalid label selector '%s': %w", term, err)
+	}
+
+	return r, nil
+}
+
+func validatePattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid name pattern '%s': %w", pattern, err)
+	}
+
+	return nil
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
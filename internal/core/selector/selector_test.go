@@ -0,0 +1,63 @@
+package selector
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	planLabels := map[string]string{"stage": "plan", "team": "infra"}
+	applyLabels := map[string]string{"stage": "apply", "team": "infra", "manual": ""}
+
+	t.Run("should select everything if it's empty", func(t *testing.T) {
+		s, err := New(nil, nil, nil)
+
+		assert.NoError(t, err)
+		assert.True(t, s.IsEmpty())
+		assert.True(t, s.Matches("anything", nil))
+	})
+
+	t.Run("should select the names that match any of the patterns, and none of the excluded", func(t *testing.T) {
+		s, err := New([]string{"iac-*", "lint"}, []string{"*-apply"}, nil)
+
+		assert.NoError(t, err)
+		assert.True(t, s.Matches("iac-plan", nil))
+		assert.True(t, s.Matches("lint", nil))
+		assert.False(t, s.Matches("iac-apply", nil))
+		assert.False(t, s.Matches("build", nil))
+	})
+
+	t.Run("should select the labels that match every requirement", func(t *testing.T) {
+		s, err := New(nil, nil, []string{"stage=plan", "team=infra"})
+
+		assert.NoError(t, err)
+		assert.True(t, s.Matches("plan", planLabels))
+		assert.False(t, s.Matches("apply", applyLabels))
+		assert.False(t, s.Matches("unlabeled", nil))
+	})
+
+	t.Run("should support the inequality, and the existence of a label", func(t *testing.T) {
+		s, err := New(nil, nil, []string{"stage!=apply", "team", "!manual"})
+
+		assert.NoError(t, err)
+		assert.True(t, s.Matches("plan", planLabels))
+		assert.False(t, s.Matches("apply", applyLabels))
+		assert.False(t, s.Matches("unlabeled", nil))
+		assert.Equal(t, "labels stage!=apply,team,!manual", s.String())
+	})
+
+	t.Run("should fail with an invalid label selector, or name pattern", func(t *testing.T) {
+		_, err := New(nil, nil, []string{"stage=plan,apply"})
+		assert.ErrorContains(t, err, "invalid label selector 'stage=plan,apply'")
+
+		_, err = New([]string{"[plan"}, nil, nil)
+		assert.ErrorContains(t, err, "invalid name pattern '[plan'")
+	})
+}
+
+func TestValidateLabel(t *testing.T) {
+	assert.NoError(t, ValidateLabel("stiletto.io/stage", "plan"))
+	assert.NoError(t, ValidateLabel("manual", ""))
+	assert.Error(t, ValidateLabel("-stage", "plan"))
+	assert.Error(t, ValidateLabel("stage", "plan apply"))
+}
@@ -0,0 +1,115 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"strings"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem found in a manifest, along with its position in the manifest file.
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Path     string `json:"path,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String formats the diagnostic as 'file:line:column: severity: message (path)'. The
+// line and column are omitted when they're unknown.
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, d.Line)
+	}
+
+	if d.Column > 0 {
+		location = fmt.Sprintf("%s:%d", location, d.Column)
+	}
+
+	if d.Path == "" {
+		return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s (%s)", location, d.Severity, d.Message, d.Path)
+}
+
+// HasErrors returns true if any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diagnosticsFromYAMLError converts an error of the yaml parser into diagnostics, one per problem.
+func diagnosticsFromYAMLError(file string, documentLine int, err error) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, problem := range yamlparser.Problems(err) {
+		line := problem.Line
+		if line == 0 {
+			line = documentLine
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     file,
+			Line:     line,
+			Severity: SeverityError,
+			Message:  problem.Message,
+		})
+	}
+
+	return diagnostics
+}
+
+// manifestValidator collects the problems found in a document of a manifest file.
+type manifestValidator struct {
+	file        string
+	document    *yamlparser.Document
+	diagnostics []Diagnostic
+}
+
+// add records a problem of the field at the given path. The fields inherited from an
+// extended manifest are reported against the file they're declared in.
+func (v *manifestValidator) add(severity, path, format string, args ...interface{}) {
+	node, _ := v.document.Lookup(path)
+
+	file := v.file
+	if source, ok := v.document.Source(node); ok {
+		file = source
+	}
+
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:     file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *manifestValidator) error(path, format string, args ...interface{}) {
+	v.add(SeverityError, path, format, args...)
+}
+
+// joinPath joins the segments of a path, where the indexes are already formatted. E.g.: 'tasks[0]'.
+func joinPath(segments ...string) string {
+	var nonEmpty []string
+	for _, segment := range segments {
+		if segment != "" {
+			nonEmpty = append(nonEmpty, segment)
+		}
+	}
+
+	return strings.Join(nonEmpty, ".")
+}
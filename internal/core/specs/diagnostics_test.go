@@ -0,0 +1,409 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestBuilder writes the manifest into a temporary directory, and runs the
+// whole builder chain over it.
+func newTestBuilder(t *testing.T, manifestType, manifest string) *Builder {
+	return newTestBuilderInDir(t, t.TempDir(), manifestType, manifest, nil)
+}
+
+// newTestBuilderInDir is like newTestBuilder, but the manifest is written into the
+// given directory (E.g.: next to the manifests it extends), and built with the inputs.
+func newTestBuilderInDir(t *testing.T, dir, manifestType, manifest string, inputs map[string]interface{}) *Builder {
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yml"), []byte(manifest), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+
+	builder, err := NewTaskSpecBuilder(NewOpts{
+		ManifestType: manifestType,
+		ManifestFile: "manifest.yml",
+		Inputs:       inputs,
+		Client: &entities.Client{
+			Logger: zap.NewNop(),
+			CfgDir: &entities.DirCfg{BaseDir: dir, BaseDirAbs: dir},
+		},
+	})
+
+	assert.NoError(t, err)
+
+	return builder.
+		WithCompiledManifestStructure().
+		WithExtractedManifestContent().
+		WithResolvedInputs().
+		WithCompiledManifestFunctions().
+		WithResolvedInheritance().
+		WithConstructedSpec().
+		WithStrictDeepValidation()
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Run("should collect every problem, with its position", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeJob, `---
+apiVersion: v0
+kind: Job
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    tasks:
+        - name: compile
+          workDir: missing
+          commandsSpec:
+              - binary: cargo
+                commands:
+                    - build
+        - name: compile
+          workDir: src
+          commandsSpec: []
+`)
+
+		_, err := builder.BuildJobs()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 4)
+
+		assert.Equal(t, "apiVersion", diagnostics[0].Path)
+		assert.Equal(t, 2, diagnostics[0].Line)
+		assert.Equal(t, 13, diagnostics[0].Column)
+
+		assert.Equal(t, "spec.tasks[0].workDir", diagnostics[1].Path)
+		assert.Equal(t, 11, diagnostics[1].Line)
+
+		assert.Equal(t, "spec.tasks[1].name", diagnostics[2].Path)
+		assert.Equal(t, 16, diagnostics[2].Line)
+
+		assert.Equal(t, "spec.tasks[1].commandsSpec", diagnostics[3].Path)
+		assert.Equal(t, 18, diagnostics[3].Line)
+		assert.Equal(t, SeverityError, diagnostics[3].Severity)
+	})
+
+	t.Run("should report the circular dependencies of a workflow", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeWorkflow, `---
+apiVersion: v2
+kind: Workflow
+metadata:
+    name: release
+spec:
+    jobs:
+        - name: build
+          needs: [test]
+          containerImage: rust:alpine
+          mountDir: .
+          workDir: src
+          tasks:
+              - name: compile
+                commandsSpec:
+                    - commands: [cargo build]
+        - name: test
+          needs: [build]
+          containerImage: rust:alpine
+          mountDir: .
+          workDir: src
+          tasks:
+              - name: test
+                commandsSpec:
+                    - commands: [cargo test]
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, "spec.jobs", diagnostics[0].Path)
+		assert.Contains(t, diagnostics[0].Message, "build -> test -> build")
+	})
+
+	t.Run("should report the line of the values that can't be decoded", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    commandsSpec: cargo build
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, 8, diagnostics[0].Line)
+	})
+
+	t.Run("should report the invalid 'if' conditions", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    if: git.branch == 'main'
+    commandsSpec:
+        - commands: [cargo build]
+        - commands: [cargo publish]
+          if: secrets.TOKEN != ''
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, "spec.commandsSpec[1].if", diagnostics[0].Path)
+		assert.Equal(t, 14, diagnostics[0].Line)
+		assert.Contains(t, diagnostics[0].Message, "unknown variable 'secrets'")
+	})
+
+	t.Run("should report the invalid timeouts", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeJob, `---
+apiVersion: v2
+kind: Job
+metadata:
+    name: release
+spec:
+    containerImage: alpine/terragrunt
+    mountDir: .
+    workDir: src
+    timeout: 1h
+    tasks:
+        - name: apply
+          timeout: forever
+          commandsSpec:
+              - binary: terragrunt
+                commands: [apply -auto-approve]
+                timeout: -5m
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 2)
+		assert.Equal(t, "spec.tasks[0].timeout", diagnostics[0].Path)
+		assert.Equal(t, 13, diagnostics[0].Line)
+		assert.Equal(t, "spec.tasks[0].commandsSpec[0].timeout", diagnostics[1].Path)
+	})
+
+	t.Run("should report the invalid retry policies", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: publish
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    retry:
+        maxAttempts: 3
+        backoff: exponential
+        delay: 2s
+    commandsSpec:
+        - commands: [cargo publish]
+          retry:
+              maxAttempts: 0
+              backoff: linear
+              onOutputPatterns: ['(unclosed']
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 3)
+		assert.Equal(t, "spec.commandsSpec[0].retry.maxAttempts", diagnostics[0].Path)
+		assert.Equal(t, "spec.commandsSpec[0].retry.backoff", diagnostics[1].Path)
+		assert.Equal(t, 18, diagnostics[1].Line)
+		assert.Equal(t, "spec.commandsSpec[0].retry.onOutputPatterns[0]", diagnostics[2].Path)
+	})
+
+	t.Run("should report the invalid script steps", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    shell: zsh
+    commandsSpec:
+        - run: |
+              cargo build --release
+              ls target/release | grep build
+        - binary: cargo
+          run: cargo test
+        - scriptFile: scripts/missing.sh
+`)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 3)
+		assert.Equal(t, "spec.shell", diagnostics[0].Path)
+		assert.Equal(t, "spec.commandsSpec[1].binary", diagnostics[1].Path)
+		assert.Equal(t, "spec.commandsSpec[2].scriptFile", diagnostics[2].Path)
+		assert.Equal(t, 17, diagnostics[2].Line)
+	})
+
+	t.Run("should report the unknown fields, unless it's lenient", func(t *testing.T) {
+		manifest := `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: list
+spec:
+    containerImage: amazon/aws-cli
+    mountDir: .
+    workDir: src
+    envVarsSpec:
+        envVarsScanned:
+            scanAWSEnvVars:
+                RemoveEnvVarsIfFound: [AWS_SESSION_TOKEN]
+    commandsSpec:
+        - binary: aws
+          commands: [s3 ls]
+`
+		builder := newTestBuilder(t, entities.ManifestTypeTask, manifest)
+
+		_, err := builder.Build()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, SeverityError, diagnostics[0].Severity)
+		assert.Equal(t, "spec.envVarsSpec.envVarsScanned.scanAWSEnvVars.RemoveEnvVarsIfFound", diagnostics[0].Path)
+		assert.Equal(t, 13, diagnostics[0].Line)
+		assert.Equal(t, 17, diagnostics[0].Column)
+		assert.Contains(t, diagnostics[0].Message, "did you mean 'removeEnvVarsIfFound'?")
+
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.yml"), []byte(manifest), 0644))
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+
+		lenientBuilder, err := NewTaskSpecBuilder(NewOpts{
+			ManifestType: entities.ManifestTypeTask,
+			ManifestFile: "manifest.yml",
+			Lenient:      true,
+			Client: &entities.Client{
+				Logger: zap.NewNop(),
+				CfgDir: &entities.DirCfg{BaseDir: dir, BaseDirAbs: dir},
+			},
+		})
+		assert.NoError(t, err)
+
+		_, err = lenientBuilder.WithCompiledManifestStructure().WithExtractedManifestContent().
+			WithConstructedSpec().WithStrictDeepValidation().Build()
+		assert.NoError(t, err)
+
+		diagnostics = lenientBuilder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, SeverityWarning, diagnostics[0].Severity)
+	})
+
+	t.Run("should report the inherited fields against the manifest they're declared in", func(t *testing.T) {
+		dir := t.TempDir()
+		baseFile := filepath.Join(dir, "base", "task.yml")
+
+		assert.NoError(t, os.MkdirAll(filepath.Dir(baseFile), 0755))
+		assert.NoError(t, os.WriteFile(baseFile, []byte(`---
+apiVersion: v1
+kind: Task
+metadata:
+    name: base
+spec:
+    containerImage: alpine
+    mountDir: .
+    workdir: src
+    timeout: nonsense
+    commandsSpec:
+        - binary: echo
+          commands: [hello]
+`), 0644))
+
+		builder := newTestBuilderInDir(t, dir, entities.ManifestTypeTask, `---
+apiVersion: v1
+kind: Task
+extends: ./base/task.yml
+metadata:
+    name: child
+spec:
+    tiemout: 5m
+`, nil)
+
+		_, err := builder.Build()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 3)
+
+		assert.Equal(t, "spec.workdir", diagnostics[0].Path)
+		assert.Equal(t, SeverityWarning, diagnostics[0].Severity)
+		assert.Equal(t, baseFile, diagnostics[0].File)
+		assert.Equal(t, 9, diagnostics[0].Line)
+
+		assert.Equal(t, "spec.tiemout", diagnostics[1].Path)
+		assert.Equal(t, "manifest.yml", filepath.Base(diagnostics[1].File))
+		assert.Equal(t, 8, diagnostics[1].Line)
+		assert.Equal(t, 5, diagnostics[1].Column)
+
+		assert.Equal(t, "spec.timeout", diagnostics[2].Path)
+		assert.Equal(t, baseFile, diagnostics[2].File)
+		assert.Equal(t, 10, diagnostics[2].Line)
+		assert.Equal(t, 14, diagnostics[2].Column)
+	})
+}
+
+func TestLabels(t *testing.T) {
+	t.Run("should convert the labels, and report the invalid ones", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: plan
+    labels:
+        stage: plan
+        team: infra iac
+    annotations:
+        owner: platform@example.com
+spec:
+    containerImage: alpine/terragrunt
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - binary: terragrunt
+          commands: [plan]
+`)
+
+		_, err := builder.Build()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, "metadata.labels.team", diagnostics[0].Path)
+		assert.Equal(t, 8, diagnostics[0].Line)
+		assert.Contains(t, diagnostics[0].Message, "invalid value 'infra iac' of the label 'team'")
+
+		builder = newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: plan
+    labels:
+        stage: plan
+spec:
+    containerImage: alpine/terragrunt
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - binary: terragrunt
+          commands: [plan]
+`)
+
+		taskManifest, err := builder.Build()
+		assert.NoError(t, err)
+
+		convertedTask, err := taskManifest.Convert()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"stage": "plan"}, convertedTask.Labels)
+	})
+}
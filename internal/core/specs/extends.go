@@ -0,0 +1,333 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/jsonschema"
+	"github.com/excoriate/stiletto/internal/utils"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ExtendsCommandsReplace replaces the commands of the extended manifest with the
+	// ones of the manifest that extends it (if it declares any).
+	ExtendsCommandsReplace = "replace"
+	// ExtendsCommandsAppend runs the commands of the manifest that extends another one
+	// after the ones of the extended manifest.
+	ExtendsCommandsAppend = "append"
+)
+
+// envVarsSpecPath is the path of the environment variables of a task manifest. Its
+// lists are merged (instead of replaced) when a manifest extends another one.
+const envVarsSpecPath = "spec.envVarsSpec"
+
+// ExtendsSpec is the manifest that a task manifest extends. It can be declared as
+// the path of the manifest, or as an object to also set how the commands are merged.
+type ExtendsSpec struct {
+	Manifest     string `yaml:"manifest" required:"true" description:"Path of the manifest to extend, relative to the manifest that extends it."`
+	CommandsSpec string `yaml:"commandsSpec" enum:"replace,append" description:"How the commandsSpec are merged. It defaults to 'replace'."`
+}
+
+// UnmarshalYAML accepts both the short form (the path of the manifest) and the object form.
+func (e *ExtendsSpec) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		e.Manifest = value.Value
+		return nil
+	}
+
+	type extendsSpec ExtendsSpec
+
+	return value.Decode((*extendsSpec)(e))
+}
+
+// JSONSchema describes both forms of the 'extends' field.
+func (ExtendsSpec) JSONSchema() *jsonschema.Schema {
+	type extendsSpec ExtendsSpec
+
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "string", Description: "Path of the manifest to extend, relative to the manifest that extends it."},
+			jsonschema.Reflect(extendsSpec{}),
+		},
+	}
+}
+
+// WithResolvedInheritance resolves the manifests that extend another one through the
+// 'extends' field, by deep-merging each one of them on top of the manifest it extends
+// (which can also extend another one). The merge rules are:
+//   - Objects are merged field by field, and any other value of the extending manifest
+//     replaces the one of the extended manifest.
+//   - The lists of the 'envVarsSpec' are merged, without duplicates, and so are its 'envVars'.
+//   - The 'commandsSpec' are replaced, or appended if 'extends.commandsSpec' is 'append'.
+func (b *Builder) WithResolvedInheritance() *Builder {
+	if b.manifestFileBufferContent.String() == "" {
+		errMsg := "Cannot resolve the manifests extended. " +
+			"The manifest file content is required in" +
+			" order to resolve the manifests it extends, but it was passed as empty"
+
+		b.logger.Error(errMsg)
+		b.err = errors.NewArgumentError(errMsg, nil)
+		return b
+	}
+
+	documents, err := yamlparser.DocumentsFromContent(b.manifestFileBufferContent.String())
+	if err != nil {
+		errMsg := fmt.Sprintf("Cannot resolve the manifests extended. Cannot parse yaml file %s", b.manifestFile)
+
+		b.logger.Error(errMsg)
+		b.err = errors.NewArgumentError(errMsg, err)
+		b.failureDiagnostics = diagnosticsFromYAMLError(b.manifestFile, 0, err)
+		return b
+	}
+
+	manifestFileAbs, err := filepath.Abs(b.manifestFile)
+	if err != nil {
+		manifestFileAbs = b.manifestFile
+	}
+
+	for _, document := range documents {
+		extendsKey := document.LookupKey("", "extends")
+		if extendsKey == nil {
+			continue
+		}
+
+		if err := resolveExtends(document, []string{manifestFileAbs}, b.getTemplateData()); err != nil {
+			errMsg := fmt.Sprintf("Cannot resolve the manifest extended by the %s of the manifest file %s",
+				document, b.manifestFile)
+
+			b.logger.Error(errMsg)
+			b.err = errors.NewManifestError(errMsg, err)
+			// Kept along with the problems found later on, since the next stages still run.
+			b.diagnostics = append(b.diagnostics, Diagnostic{
+				File:     b.manifestFile,
+				Line:     extendsKey.Line,
+				Column:   extendsKey.Column,
+				Path:     "extends",
+				Severity: SeverityError,
+				Message:  err.Error(),
+			})
+			return b
+		}
+	}
+
+	b.resolvedDocuments = documents
+	b.logger.Info("manifests extended resolved successfully")
+
+	return b
+}
+
+// resolveExtends merges, in place, the document on top of the manifest it extends. The
+// chain holds the (absolute) manifest files already extended, to detect the cycles.
+func resolveExtends(document *yamlparser.Document, chain []string, data *TemplateData) error {
+	extendsKey := document.LookupKey("", "extends")
+	if extendsKey == nil {
+		return nil
+	}
+
+	root, _ := document.Lookup("")
+	extendsNode, _ := document.Lookup("extends")
+
+	var extends ExtendsSpec
+	if err := extendsNode.Decode(&extends); err != nil {
+		return fmt.Errorf("the 'extends' field is invalid: %w", err)
+	}
+
+	if kind, found := document.Lookup("kind"); !found || kind.Value != entities.ManifestKindTask {
+		return fmt.Errorf("the 'extends' field is only supported in '%s' manifests", entities.ManifestKindTask)
+	}
+
+	if extends.Manifest == "" {
+		return fmt.Errorf("the manifest to extend is required, E.g.: 'extends: ./base/task.yml'")
+	}
+
+	switch extends.CommandsSpec {
+	case "":
+		extends.CommandsSpec = ExtendsCommandsReplace
+	case ExtendsCommandsReplace, ExtendsCommandsAppend:
+	default:
+		return fmt.Errorf("invalid 'extends.commandsSpec' value '%s'. Should be '%s' or '%s'",
+			extends.CommandsSpec, ExtendsCommandsReplace, ExtendsCommandsAppend)
+	}
+
+	manifestFile := chain[len(chain)-1]
+	baseFile := extends.Manifest
+	if !filepath.IsAbs(baseFile) {
+		baseFile = filepath.Join(filepath.Dir(manifestFile), baseFile)
+	}
+
+	baseFile = filepath.Clean(baseFile)
+
+	if data.sourceRoot != "" && !utils.IsWithinDir(data.sourceRoot, baseFile) {
+		return fmt.Errorf("cannot extend %s, since it's outside of the source the manifest is loaded from",
+			extends.Manifest)
+	}
+
+	for _, file := range chain {
+		if file == baseFile {
+			return fmt.Errorf("circular 'extends' found: %s", describeExtendsChain(append(chain, baseFile)))
+		}
+	}
+
+	base, err := loadExtendedDocument(baseFile, data)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveExtends(base, append(chain, baseFile), data); err != nil {
+		return err
+	}
+
+	// The extended manifest is aligned with the version of the spec of the one that extends it.
+	baseVersion, _ := base.Lookup("apiVersion")
+	apiVersion, _ := document.Lookup("apiVersion")
+	if apiVersionIndex(baseVersion.Value) != -1 && apiVersionIndex(apiVersion.Value) != -1 {
+		if _, err := ConvertDocument(base, entities.ManifestKindTask, baseVersion.Value, apiVersion.Value); err != nil {
+			return err
+		}
+	}
+
+	removeMappingKey(root, "extends")
+
+	// The fields inherited keep the position they have in the extended manifest, so
+	// they're reported against it.
+	document.MergeSource(base, baseFile)
+
+	baseRoot, _ := base.Lookup("")
+	document.Node.Content[0] = mergeNodes(baseRoot, root, "", extends.CommandsSpec)
+
+	return nil
+}
+
+// loadExtendedDocument loads the manifest extended, which should be a single task
+// manifest. Its template functions are compiled as the ones of the manifest that extends it.
+func loadExtendedDocument(manifestFile string, data *TemplateData) (*yamlparser.Document, error) {
+	content, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the manifest to extend %s: %w", manifestFile, err)
+	}
+
+	compiled, err := compileManifestFunctions(manifestFile, string(content), data.forManifest(manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile the template functions of the manifest to extend %s: %w",
+			manifestFile, err)
+	}
+
+	documents, err := yamlparser.DocumentsFromContent(compiled)
+	if err != nil {
+		return nil, fmt.Errorf("the manifest to extend %s is invalid: %w", manifestFile, err)
+	}
+
+	if len(documents) != 1 {
+		return nil, fmt.Errorf("the manifest to extend %s should have a single document, but it has %d",
+			manifestFile, len(documents))
+	}
+
+	if kind, found := documents[0].Lookup("kind"); !found || kind.Value != entities.ManifestKindTask {
+		return nil, fmt.Errorf("the manifest to extend %s should be a '%s' manifest",
+			manifestFile, entities.ManifestKindTask)
+	}
+
+	return documents[0], nil
+}
+
+// mergeNodes deep-merges the override node on top of the base one, following the
+// rules described in WithResolvedInheritance. The path is the one of the nodes merged.
+func mergeNodes(base, override *yaml.Node, path, commandsStrategy string) *yaml.Node {
+	if base == nil {
+		return override
+	}
+
+	if base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode {
+		// The mapping is declared by both manifests, so it's positioned where the
+		// extending one declares it.
+		merged := *base
+		merged.Line, merged.Column = override.Line, override.Column
+		merged.Content = append([]*yaml.Node{}, base.Content...)
+
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key, value := override.Content[i], override.Content[i+1]
+
+			found := false
+			for j := 0; j+1 < len(merged.Content); j += 2 {
+				if merged.Content[j].Value == key.Value {
+					merged.Content[j+1] = mergeNodes(merged.Content[j+1], value, joinPath(path, key.Value),
+						commandsStrategy)
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				merged.Content = append(merged.Content, key, value)
+			}
+		}
+
+		return &merged
+	}
+
+	if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+		switch {
+		case path == "spec.commandsSpec" && commandsStrategy == ExtendsCommandsAppend:
+			merged := *override
+			merged.Content = append(append([]*yaml.Node{}, base.Content...), override.Content...)
+			return &merged
+		case strings.HasPrefix(path, envVarsSpecPath+"."):
+			return mergeSequencesUnique(base, override)
+		}
+	}
+
+	return override
+}
+
+// mergeSequencesUnique appends to the base sequence the items of the override one that
+// it doesn't have yet.
+func mergeSequencesUnique(base, override *yaml.Node) *yaml.Node {
+	merged := *override
+	merged.Content = append([]*yaml.Node{}, base.Content...)
+
+	for _, item := range override.Content {
+		duplicated := false
+		for _, existing := range base.Content {
+			if item.Kind == yaml.ScalarNode && existing.Kind == yaml.ScalarNode && item.Value == existing.Value {
+				duplicated = true
+				break
+			}
+		}
+
+		if !duplicated {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+
+	return &merged
+}
+
+func removeMappingKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// describeExtendsChain describes the manifests extended, relative to the first one.
+func describeExtendsChain(chain []string) string {
+	baseDir := filepath.Dir(chain[0])
+
+	var files []string
+	for _, file := range chain {
+		if rel, err := filepath.Rel(baseDir, file); err == nil {
+			file = rel
+		}
+
+		files = append(files, file)
+	}
+
+	return strings.Join(files, " -> ")
+}
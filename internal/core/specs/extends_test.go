@@ -0,0 +1,286 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseTaskManifest = `---
+apiVersion: v1
+kind: Task
+metadata:
+    name: base
+spec:
+    containerImage: alpine/terragrunt
+    mountDir: .
+    workdir: src
+    envVarsSpec:
+        envVars:
+            TF_LOG: INFO
+            REGION: us-east-1
+        dotFiles:
+            - .env
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - init
+`
+
+func writeTestManifest(t *testing.T, dir, name, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestWithResolvedInheritance(t *testing.T) {
+	t.Run("should deep-merge the manifest on top of the one it extends", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestManifest(t, dir, "base/terragrunt.yml", baseTaskManifest)
+
+		builder := newTestBuilderInDir(t, dir, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+extends: ./base/terragrunt.yml
+metadata:
+    name: plan
+spec:
+    envVarsSpec:
+        envVars:
+            TF_LOG: DEBUG
+        dotFiles:
+            - .env
+            - .env.local
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - plan
+`, nil)
+
+		tasks, err := builder.BuildTasks()
+
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+
+		task := tasks[0]
+		assert.Equal(t, "plan", task.Metadata.Name)
+		assert.Equal(t, "alpine/terragrunt", task.Spec.ContainerImage)
+		assert.Equal(t, "src", task.Spec.Workdir, "the base manifest should be converted to v2")
+		assert.Equal(t, map[string]string{"TF_LOG": "DEBUG", "REGION": "us-east-1"}, task.Spec.EnvVarsSpec.EnvVars)
+		assert.Equal(t, []string{".env", ".env.local"}, task.Spec.EnvVarsSpec.DotFiles)
+		assert.Len(t, task.Spec.CommandsSpec, 1)
+		assert.Equal(t, []string{"plan"}, task.Spec.CommandsSpec[0].Commands)
+		assert.Empty(t, task.Extends.Manifest)
+	})
+
+	t.Run("should append the commands when it's set so", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestManifest(t, dir, "base.yml", baseTaskManifest)
+		writeTestManifest(t, dir, "middle.yml", `---
+apiVersion: v2
+kind: Task
+extends:
+    manifest: base.yml
+    commandsSpec: append
+metadata:
+    name: middle
+spec:
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - validate
+`)
+
+		builder := newTestBuilderInDir(t, dir, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+extends:
+    manifest: middle.yml
+    commandsSpec: append
+metadata:
+    name: plan
+spec:
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - plan
+`, nil)
+
+		tasks, err := builder.BuildTasks()
+
+		assert.NoError(t, err)
+
+		var commands []string
+		for _, commandsSpec := range tasks[0].Spec.CommandsSpec {
+			commands = append(commands, commandsSpec.Commands...)
+		}
+
+		assert.Equal(t, []string{"init", "validate", "plan"}, commands)
+	})
+
+	t.Run("should detect the cycles", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestManifest(t, dir, "other.yml", `---
+apiVersion: v2
+kind: Task
+extends: manifest.yml
+metadata:
+    name: other
+`)
+
+		builder := newTestBuilderInDir(t, dir, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+extends: other.yml
+metadata:
+    name: plan
+`, nil)
+
+		_, err := builder.BuildTasks()
+		assert.Error(t, err)
+
+		diagnostic := builder.Diagnostics()[0]
+		assert.Equal(t, "extends", diagnostic.Path)
+		assert.Equal(t, 4, diagnostic.Line)
+		assert.Contains(t, diagnostic.Message, "manifest.yml -> other.yml -> manifest.yml")
+	})
+
+	t.Run("should fail if the extended manifest doesn't exist", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+extends: missing.yml
+metadata:
+    name: plan
+`)
+
+		_, err := builder.BuildTasks()
+
+		assert.Error(t, err)
+		assert.Contains(t, builder.Diagnostics()[0].Message, "cannot read the manifest to extend")
+	})
+}
+
+func TestManifestSources(t *testing.T) {
+	t.Run("should build the manifest of a git repository, extending another one of it", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("the git binary isn't available")
+		}
+
+		repoDir := t.TempDir()
+		writeTestManifest(t, repoDir, "tasks/base/terragrunt.yml", baseTaskManifest)
+		writeTestManifest(t, repoDir, "tasks/plan.yml", `---
+apiVersion: v2
+kind: Task
+extends: ./base/terragrunt.yml
+metadata:
+    name: plan
+spec:
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - plan
+`)
+
+		commitManifestsRepo(t, repoDir)
+
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+
+		builder, err := NewTaskSpecBuilder(NewOpts{
+			ManifestType: entities.ManifestTypeTask,
+			ManifestFile: "git+file://" + filepath.ToSlash(repoDir) + "//tasks/plan.yml?ref=v1.2",
+			Sources:      manifest.NewSources(t.TempDir()),
+			Client: &entities.Client{
+				Logger: zap.NewNop(),
+				CfgDir: &entities.DirCfg{BaseDir: dir, BaseDirAbs: dir},
+			},
+		})
+		assert.NoError(t, err)
+
+		task, err := builder.WithCompiledManifestStructure().WithExtractedManifestContent().
+			WithResolvedInputs().WithCompiledManifestFunctions().WithResolvedInheritance().
+			WithConstructedSpec().WithStrictDeepValidation().Build()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "plan", task.Metadata.Name)
+		assert.Equal(t, "alpine/terragrunt", task.Spec.ContainerImage)
+		assert.Equal(t, []string{"plan"}, task.Spec.CommandsSpec[0].Commands)
+	})
+
+	t.Run("should not read the host in the manifest of a git repository", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("the git binary isn't available")
+		}
+
+		t.Setenv("STILETTO_TEST_SECRET", "s3cr3t")
+
+		hostDir := t.TempDir()
+		writeTestManifest(t, hostDir, "base.yml", baseTaskManifest)
+
+		repoDir := t.TempDir()
+		writeTestManifest(t, repoDir, "tasks/env.yml", strings.Replace(baseTaskManifest, "name: base",
+			"name: 'base{{ with .Env.STILETTO_TEST_SECRET }}-{{ . }}{{ end }}'", 1))
+		writeTestManifest(t, repoDir, "tasks/env-function.yml", strings.Replace(baseTaskManifest, "name: base",
+			`name: '{{ env "STILETTO_TEST_SECRET" }}'`, 1))
+		writeTestManifest(t, repoDir, "tasks/extends.yml", `---
+apiVersion: v2
+kind: Task
+extends: `+filepath.Join(hostDir, "base.yml")+`
+metadata:
+    name: plan
+`)
+		commitManifestsRepo(t, repoDir)
+
+		dir := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+
+		build := func(manifestPath string) (*TaskManifestSpec, *Builder) {
+			builder, err := NewTaskSpecBuilder(NewOpts{
+				ManifestType: entities.ManifestTypeTask,
+				ManifestFile: "git+file://" + filepath.ToSlash(repoDir) + "//" + manifestPath + "?ref=v1.2",
+				Sources:      manifest.NewSources(t.TempDir()),
+				Client: &entities.Client{
+					Logger: zap.NewNop(),
+					CfgDir: &entities.DirCfg{BaseDir: dir, BaseDirAbs: dir},
+				},
+			})
+			assert.NoError(t, err)
+
+			task, _ := builder.WithCompiledManifestStructure().WithExtractedManifestContent().
+				WithResolvedInputs().WithCompiledManifestFunctions().WithResolvedInheritance().
+				WithConstructedSpec().WithStrictDeepValidation().Build()
+
+			return task, builder
+		}
+
+		task, _ := build("tasks/env.yml")
+		assert.Equal(t, "base", task.Metadata.Name)
+
+		_, builder := build("tasks/env-function.yml")
+		assert.Contains(t, builder.Diagnostics()[0].Message, "the function 'env' isn't available")
+
+		_, builder = build("tasks/extends.yml")
+		assert.Contains(t, builder.Diagnostics()[0].Message, "outside of the source the manifest is loaded from")
+	})
+}
+
+// commitManifestsRepo commits the files of the repository, and tags the commit as 'v1.2'.
+func commitManifestsRepo(t *testing.T, repoDir string) {
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		{"tag", "v1.2"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+}
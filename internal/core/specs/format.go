@@ -0,0 +1,369 @@
+package specs
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"gopkg.in/yaml.v3"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// formatIndent is the indentation of the formatted manifests.
+const formatIndent = 4
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// manifestField is a field of a spec, as it's declared in yaml.
+type manifestField struct {
+	name string
+	typ  reflect.Type
+}
+
+// manifestFormatter formats the documents of a given kind and version of the spec.
+type manifestFormatter struct {
+	kind string
+
+	// renamed are the names of the fields (by their name in the latest version) that
+	// were renamed since the version of the document, along with the paths they're renamed at.
+	renamed map[string]fieldRename
+}
+
+// FormatManifest formats every document of a manifest file in the canonical way: the
+// fields are sorted as they're declared in the spec (E.g.: 'apiVersion', 'kind',
+// 'metadata' and 'spec'), the casing of their names is fixed (E.g.: 'containerimage' is
+// 'containerImage'), and every object or list is indented with 4 spaces from the key it's
+// nested in. The items of a list are objects that start right after their '- '. The
+// comments are preserved, along with the order of the items of the lists, and of the
+// keys of the objects whose keys are free-form (E.g.: 'envVars', or the axes of a matrix).
+func FormatManifest(content []byte) ([]byte, error) {
+	documents, err := yamlparser.DocumentsFromContent(string(content))
+	if err != nil {
+		return nil, errors.NewManifestError("Cannot format the manifest", err)
+	}
+
+	var formatted bytes.Buffer
+	formatted.WriteString("---\n")
+
+	encoder := yaml.NewEncoder(&formatted)
+	encoder.SetIndent(formatIndent)
+
+	for _, document := range documents {
+		kind, _ := document.Lookup("kind")
+		spec, ok := manifestSpecs[kind.Value]
+		if !ok {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot format the %s. Invalid manifest kind: '%s'",
+				document, kind.Value), nil)
+		}
+
+		apiVersion, _ := document.Lookup("apiVersion")
+		versionIdx := apiVersionIndex(apiVersion.Value)
+		if versionIdx == -1 {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot format the %s. Its apiVersion '%s' isn't "+
+				"supported. Should be one of: %s", document, apiVersion.Value,
+				strings.Join(SupportedAPIVersions(), ", ")), nil)
+		}
+
+		formatter := &manifestFormatter{kind: kind.Value, renamed: map[string]fieldRename{}}
+		for idx := len(specVersions) - 1; idx > versionIdx; idx-- {
+			for _, rename := range specVersions[idx].renames {
+				formatter.renamed[rename.to] = rename
+			}
+		}
+
+		root := document.Node.Content[0]
+
+		// The comment on top of the document stays there, even if its first field is moved.
+		var headComment string
+		if root.Kind == yaml.MappingNode && len(root.Content) != 0 {
+			headComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+
+		formatter.formatNode(root, reflect.TypeOf(spec), "")
+
+		if headComment != "" {
+			root.Content[0].HeadComment = strings.TrimSpace(headComment + "\n" + root.Content[0].HeadComment)
+		}
+
+		if err := encoder.Encode(document.Node); err != nil {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot format the %s", document), err)
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, errors.NewManifestError("Cannot format the manifest", err)
+	}
+
+	indented, err := indentManifest(formatted.String())
+	if err != nil {
+		return nil, errors.NewManifestError("Cannot format the manifest", err)
+	}
+
+	return []byte(indented), nil
+}
+
+// lineIndent is how a line that starts a key, or an item of a list, is indented again.
+type lineIndent struct {
+	shift int
+	// column is the one of the key (or of the '- ' of the item) the line starts. The lines
+	// below that are indented deeper continue its value. E.g.: a 'run' script.
+	column int
+	// continued is set if the value can continue in the lines below. E.g.: a scalar.
+	continued bool
+	// block is set if the value is a literal, or folded, scalar. E.g.: 'run: |'. Its
+	// lines are indented with 4 spaces from the key too.
+	block bool
+}
+
+// blockIndicatorPattern matches the explicit indentation indicator of a block scalar
+// (E.g.: '|2', if its first line starts with spaces), which is relative to the key.
+var blockIndicatorPattern = regexp.MustCompile(`[|>][+-]?[0-9]`)
+
+// indentManifest indents the collections nested in the items of a list with 4 spaces
+// from their key, as any other one. yaml.v3 aligns them to a multiple of the indentation
+// instead, so the 'commands' of a 'commandsSpec' item would be indented with 2 spaces.
+// The yaml encoded is parsed again, to find the column each line should start at. The
+// lines of a value that continue below its key are moved along with it, and the
+// comments along with the key below them.
+func indentManifest(encoded string) (string, error) {
+	indents := map[int]lineIndent{}
+
+	decoder := yaml.NewDecoder(strings.NewReader(encoded))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return "", err
+		}
+
+		if len(document.Content) != 0 {
+			indentNode(document.Content[0], 0, indents)
+		}
+	}
+
+	lines := strings.Split(encoded, "\n")
+
+	var current lineIndent
+	var blockShift *int
+	for idx, line := range lines {
+		lineNumber := idx + 1
+		indent, starts := indents[lineNumber]
+		column := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case starts:
+			current, blockShift = indent, nil
+			current.block = current.block && !blockIndicatorPattern.MatchString(line)
+		case strings.TrimSpace(line) == "":
+			continue
+		case current.continued && column > current.column:
+			indent = current
+
+			// The lines of a block scalar are moved as its first one.
+			if current.block {
+				if blockShift == nil {
+					shift := current.column + current.shift + formatIndent - column
+					blockShift = &shift
+				}
+
+				indent.shift = *blockShift
+			}
+		default:
+			indent = nextLineIndent(indents, lineNumber, len(lines))
+		}
+
+		lines[idx] = shiftLine(line, indent.shift)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// indentNode records the indentation of the lines that start the keys, or the items, of
+// the node. The column is the one the node should start at.
+func indentNode(node *yaml.Node, column int, indents map[int]lineIndent) {
+	if node.Style&yaml.FlowStyle != 0 {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			indents[key.Line] = lineIndent{shift: column - (key.Column - 1), column: key.Column - 1,
+				continued: !isBlockCollection(value), block: isBlockScalar(value)}
+
+			if isBlockCollection(value) {
+				indentNode(value, column+formatIndent, indents)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			indents[item.Line] = lineIndent{shift: column - (node.Column - 1), column: node.Column - 1,
+				continued: !isBlockCollection(item), block: isBlockScalar(item)}
+
+			// The first key of an object (or the first item of a list) follows the '- '.
+			if isBlockCollection(item) {
+				indentNode(item, column+2, indents)
+			}
+		}
+	}
+}
+
+func isBlockCollection(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && node.Style&yaml.FlowStyle == 0
+}
+
+func isBlockScalar(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0
+}
+
+// nextLineIndent returns the indentation of the next line that starts a key, or an item.
+func nextLineIndent(indents map[int]lineIndent, lineNumber, lines int) lineIndent {
+	for next := lineNumber + 1; next <= lines; next++ {
+		if indent, ok := indents[next]; ok {
+			return indent
+		}
+	}
+
+	return lineIndent{}
+}
+
+func shiftLine(line string, shift int) string {
+	if shift > 0 {
+		return strings.Repeat(" ", shift) + line
+	}
+
+	return strings.TrimPrefix(line, strings.Repeat(" ", -shift))
+}
+
+// manifestSpecs are the specs of each manifest kind, whose fields set the canonical order.
+var manifestSpecs = map[string]interface{}{
+	entities.ManifestKindTask:     TaskManifestSpec{},
+	entities.ManifestKindJob:      JobManifestSpec{},
+	entities.ManifestKindWorkflow: WorkflowManifestSpec{},
+}
+
+// formatNode sorts the fields of the node (and of its children), following the type it's
+// decoded into. The path is the one of the node, as the renames declare it. E.g.: 'spec.tasks[*]'.
+func (f *manifestFormatter) formatNode(node *yaml.Node, t reflect.Type, path string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// The types that decode themselves (E.g.: a matrix, whose axes are run in the order
+	// they're declared) are kept as they are.
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		f.formatMapping(node, t, path)
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			f.formatNode(item, t.Elem(), path+"[*]")
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			f.formatNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value))
+		}
+	}
+}
+
+// formatMapping sorts the fields of an object as they're declared in its type. The
+// unknown fields (which fail the validation, unless it's lenient) are kept at the end.
+func (f *manifestFormatter) formatMapping(node *yaml.Node, t reflect.Type, path string) {
+	fields := manifestFields(t)
+
+	type pair struct {
+		key, value *yaml.Node
+		position   int
+	}
+
+	keys := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys[node.Content[i].Value] = true
+	}
+
+	var pairs []pair
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		position := len(fields)
+
+		// The casing is only fixed if the field isn't declared already, so no key is duplicated.
+		for idx, field := range fields {
+			name := f.versionName(path, field.name)
+			if key.Value == name || (!keys[name] && strings.EqualFold(key.Value, name)) {
+				position = idx
+				break
+			}
+		}
+
+		if position < len(fields) {
+			key.Value = f.versionName(path, fields[position].name)
+			f.formatNode(value, fields[position].typ, joinPath(path, fields[position].name))
+		}
+
+		pairs = append(pairs, pair{key: key, value: value, position: position})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].position < pairs[j].position
+	})
+
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+}
+
+// versionName returns the name of the field in the version of the document, since it
+// could have been renamed since then. E.g.: 'workDir' is 'workdir' in 'v1'.
+func (f *manifestFormatter) versionName(path, name string) string {
+	rename, ok := f.renamed[name]
+	if ok && contains(rename.paths[f.kind], path) {
+		return rename.from
+	}
+
+	return name
+}
+
+// manifestFields returns the fields of a spec, in the order they're declared, where the
+// inlined ones (E.g.: the task spec of a job's task) are flattened into their parent.
+func manifestFields(t reflect.Type) []manifestField {
+	var fields []manifestField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+
+		if contains(tag[1:], "inline") {
+			fields = append(fields, manifestFields(field.Type)...)
+			continue
+		}
+
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fields = append(fields, manifestField{name: name, typ: field.Type})
+	}
+
+	return fields
+}
@@ -0,0 +1,206 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatManifest(t *testing.T) {
+	t.Run("should sort the fields, fix their casing and indent them, preserving the comments", func(t *testing.T) {
+		content := `# The lint task.
+kind: Task
+apiVersion: v2
+spec:
+  # The commands run in order.
+  commandsSpec:
+  - commands: [cargo clippy]
+    Binary: cargo
+  envVarsSpec:
+    envVars:
+      RUST_LOG: debug
+      CARGO_TERM_COLOR: always
+  mountDir: .
+  containerimage: rust:alpine # pinned by 'stiletto lock'
+  workDir: src
+metadata:
+  name: lint
+`
+
+		formatted, err := FormatManifest([]byte(content))
+
+		assert.NoError(t, err)
+		assert.Equal(t, `---
+# The lint task.
+apiVersion: v2
+kind: Task
+metadata:
+    name: lint
+spec:
+    containerImage: rust:alpine # pinned by 'stiletto lock'
+    workDir: src
+    mountDir: .
+    # The commands run in order.
+    commandsSpec:
+        - binary: cargo
+          commands: [cargo clippy]
+    envVarsSpec:
+        envVars:
+            RUST_LOG: debug
+            CARGO_TERM_COLOR: always
+`, string(formatted))
+
+		again, err := FormatManifest(formatted)
+		assert.NoError(t, err)
+		assert.Equal(t, string(formatted), string(again))
+	})
+
+	t.Run("should follow the version of each document, and keep the matrix as it is", func(t *testing.T) {
+		content := `---
+apiVersion: v1
+kind: Job
+metadata:
+    name: build
+spec:
+    tasks:
+        - WorkDir: src
+          name: compile
+          strategy:
+              matrix:
+                  target: [linux, darwin]
+                  exclude:
+                      - target: darwin
+                  arch: [amd64]
+    containerImage: rust:alpine
+---
+apiVersion: v2
+kind: Workflow
+spec:
+    jobs:
+        - needs: [build]
+          name: publish
+          tasks:
+              - workdir: src
+                name: push
+metadata:
+    name: release
+`
+
+		formatted, err := FormatManifest([]byte(content))
+
+		assert.NoError(t, err)
+		assert.Equal(t, `---
+apiVersion: v1
+kind: Job
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    tasks:
+        - name: compile
+          workdir: src
+          strategy:
+              matrix:
+                  target: [linux, darwin]
+                  exclude:
+                      - target: darwin
+                  arch: [amd64]
+---
+apiVersion: v2
+kind: Workflow
+metadata:
+    name: release
+spec:
+    jobs:
+        - name: publish
+          needs: [build]
+          tasks:
+              - name: push
+                workDir: src
+`, string(formatted))
+	})
+
+	t.Run("should indent what's nested in the items of a list with 4 spaces, as anything else", func(t *testing.T) {
+		content := `apiVersion: v2
+kind: Task
+spec:
+  commandsSpec:
+  - binary: cargo
+    commands:
+    # The release build.
+    - build --release
+  - run: |
+      cargo test
+        --all-features
+`
+
+		formatted, err := FormatManifest([]byte(content))
+
+		assert.NoError(t, err)
+		assert.Equal(t, `---
+apiVersion: v2
+kind: Task
+spec:
+    commandsSpec:
+        - binary: cargo
+          commands:
+              # The release build.
+              - build --release
+        - run: |
+              cargo test
+                --all-features
+`, string(formatted))
+
+		again, err := FormatManifest(formatted)
+		assert.NoError(t, err)
+		assert.Equal(t, string(formatted), string(again))
+	})
+
+	t.Run("should keep the examples, and the scaffolds, as they are", func(t *testing.T) {
+		repoDir := filepath.Join("..", "..", "..")
+		examples, err := manifest.FindFiles(repoDir, []string{"examples"})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, examples)
+
+		for _, example := range examples {
+			content, err := os.ReadFile(filepath.Join(repoDir, example))
+			assert.NoError(t, err)
+
+			formatted, err := FormatManifest(content)
+			assert.NoError(t, err, example)
+			assert.Equal(t, string(content), string(formatted), example)
+		}
+
+		for _, name := range manifest.ScaffoldTemplates(entities.ManifestTypeTask) {
+			opts, err := manifest.ScaffoldDefaults(entities.ManifestTypeTask, name)
+			assert.NoError(t, err)
+
+			content, err := manifest.Scaffold(opts)
+			assert.NoError(t, err)
+
+			formatted, err := FormatManifest(content)
+			assert.NoError(t, err, name)
+			assert.Equal(t, string(content), string(formatted), name)
+		}
+	})
+
+	t.Run("should keep the unknown fields at the end", func(t *testing.T) {
+		formatted, err := FormatManifest([]byte("spec:\n    custom: value\n    mountDir: .\n" +
+			"kind: Task\napiVersion: v2\n"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "---\napiVersion: v2\nkind: Task\nspec:\n    mountDir: .\n    custom: value\n",
+			string(formatted))
+	})
+
+	t.Run("should fail with an unknown kind, or apiVersion", func(t *testing.T) {
+		_, err := FormatManifest([]byte("apiVersion: v2\nkind: Pipeline\n"))
+		assert.ErrorContains(t, err, "Invalid manifest kind: 'Pipeline'")
+
+		_, err = FormatManifest([]byte("apiVersion: v9\nkind: Task\n"))
+		assert.ErrorContains(t, err, "apiVersion 'v9' isn't supported")
+	})
+}
@@ -0,0 +1,285 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	InputTypeString = "string"
+	InputTypeBool   = "bool"
+	InputTypeInt    = "int"
+	InputTypeList   = "list"
+	InputTypeEnum   = "enum"
+)
+
+// inputsPath is the path where the inputs are declared, in every manifest kind.
+const inputsPath = "spec.inputs"
+
+// InputSpec is a typed parameter of a manifest. Its value is set with '--set name=value',
+// or in a '--vars-file', and it's available in the templates as '{{ .Inputs.name }}'.
+type InputSpec struct {
+	Type        string        `yaml:"type" enum:"string,bool,int,list,enum" description:"Type of the input. It defaults to 'string'."`
+	Description string        `yaml:"description" description:"Description of the input."`
+	Required    bool          `yaml:"required" description:"Fail if the input isn't set, and it has no default."`
+	Default     interface{}   `yaml:"default" description:"Value of the input when it isn't set."`
+	Values      []interface{} `yaml:"values" description:"Values allowed, for the 'enum' inputs."`
+}
+
+// ParseInputAssignments parses the inputs set as 'name=value'. The values are kept as
+// strings, and converted into the type of the input once it's resolved.
+func ParseInputAssignments(assignments []string) (map[string]interface{}, error) {
+	inputs := map[string]interface{}{}
+
+	for _, assignment := range assignments {
+		name, value, found := strings.Cut(assignment, "=")
+		name = strings.TrimSpace(name)
+
+		if !found || name == "" {
+			return nil, errors.NewArgumentError(fmt.Sprintf("Invalid input '%s'. "+
+				"It should be set as 'name=value'", assignment), nil)
+		}
+
+		inputs[name] = value
+	}
+
+	return inputs, nil
+}
+
+// LoadInputsFile loads the inputs declared in a yaml file, as 'name: value'.
+func LoadInputsFile(varsFile string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(varsFile)
+	if err != nil {
+		return nil, errors.NewArgumentError(fmt.Sprintf("Cannot read the vars file %s", varsFile), err)
+	}
+
+	inputs := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &inputs); err != nil {
+		return nil, errors.NewArgumentError(fmt.Sprintf("Cannot parse the vars file %s. "+
+			"It should declare the inputs as 'name: value'", varsFile), err)
+	}
+
+	return inputs, nil
+}
+
+// WithResolvedInputs resolves the value of the inputs declared in the manifest file,
+// out of the ones passed to the builder (see NewOpts), or their defaults. Each value is
+// validated against the type of its input, and the resolved inputs are available to
+// the templates compiled by WithCompiledManifestFunctions.
+func (b *Builder) WithResolvedInputs() *Builder {
+	b.templateData = b.newTemplateData()
+
+	// A manifest file that isn't a valid yaml is reported by the other stages.
+	documents, err := yamlparser.DocumentsFromContent(b.manifestFileBufferContent.String())
+	if err != nil {
+		return b
+	}
+
+	declared := map[string]*InputSpec{}
+
+	for _, document := range documents {
+		v := &manifestValidator{file: b.manifestFile, document: document}
+
+		var manifest struct {
+			Spec struct {
+				Inputs map[string]*InputSpec `yaml:"inputs"`
+			} `yaml:"spec"`
+		}
+
+		if err := document.Decode(&manifest); err != nil {
+			v.error(inputsPath, "The inputs are invalid: %s", err)
+			b.diagnostics = append(b.diagnostics, v.diagnostics...)
+			continue
+		}
+
+		var names []string
+		for name := range manifest.Spec.Inputs {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			input := manifest.Spec.Inputs[name]
+			if input == nil {
+				input = &InputSpec{}
+			}
+
+			if input.Type == "" {
+				input.Type = InputTypeString
+			}
+
+			if previous, ok := declared[name]; ok {
+				if previous.Type != input.Type {
+					inputError(v, name, "The input '%s' is declared more than once, with different types", name)
+				}
+
+				continue
+			}
+
+			declared[name] = input
+
+			value, err := b.resolveInput(name, input)
+			if err != nil {
+				inputError(v, name, "%s", err)
+				continue
+			}
+
+			b.templateData.Inputs[name] = value
+		}
+
+		b.diagnostics = append(b.diagnostics, v.diagnostics...)
+	}
+
+	for name := range b.inputs {
+		if _, ok := declared[name]; !ok {
+			b.logger.Warn(fmt.Sprintf("The input '%s' isn't declared in the manifest %s, so it's ignored",
+				name, b.manifestFile))
+		}
+	}
+
+	if HasErrors(b.diagnostics) {
+		errMsg := fmt.Sprintf("Cannot resolve the inputs of the manifest %s", b.manifestFile)
+
+		b.logger.Error(errMsg)
+		b.err = errors.NewManifestError(errMsg, nil)
+	}
+
+	return b
+}
+
+// inputError reports a problem of an input, at the position of its name.
+func inputError(v *manifestValidator, name, format string, args ...interface{}) {
+	v.error(joinPath(inputsPath, name), format, args...)
+
+	if key := v.document.LookupKey(inputsPath, name); key != nil {
+		diagnostic := &v.diagnostics[len(v.diagnostics)-1]
+		diagnostic.Line, diagnostic.Column = key.Line, key.Column
+	}
+}
+
+// resolveInput returns the value of the input, converted into its type.
+func (b *Builder) resolveInput(name string, input *InputSpec) (interface{}, error) {
+	switch input.Type {
+	case InputTypeString, InputTypeBool, InputTypeInt, InputTypeList:
+	case InputTypeEnum:
+		if len(input.Values) == 0 {
+			return nil, fmt.Errorf("the enum input '%s' should declare the values it allows", name)
+		}
+	default:
+		return nil, fmt.Errorf("invalid type '%s' of the input '%s'. Should be one of: %s", input.Type, name,
+			strings.Join([]string{InputTypeString, InputTypeBool, InputTypeInt, InputTypeList, InputTypeEnum}, ", "))
+	}
+
+	if input.Default != nil {
+		if _, err := input.convert(input.Default); err != nil {
+			return nil, fmt.Errorf("invalid default of the input '%s': %w", name, err)
+		}
+	}
+
+	value, ok := b.inputs[name]
+	if !ok {
+		if input.Default == nil && input.Required {
+			return nil, fmt.Errorf("the input '%s' is required. Set it with '--set %s=<value>', "+
+				"or in a '--vars-file'", name, name)
+		}
+
+		value = input.Default
+	}
+
+	converted, err := input.convert(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of the input '%s': %w", name, err)
+	}
+
+	return converted, nil
+}
+
+// convert converts the value into the type of the input. A nil value is converted into
+// the zero value of the type.
+func (s *InputSpec) convert(value interface{}) (interface{}, error) {
+	switch s.Type {
+	case InputTypeBool:
+		switch v := value.(type) {
+		case nil:
+			return false, nil
+		case bool:
+			return v, nil
+		case string:
+			converted, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not a bool", v)
+			}
+
+			return converted, nil
+		}
+	case InputTypeInt:
+		switch v := value.(type) {
+		case nil:
+			return 0, nil
+		case int:
+			return v, nil
+		case string:
+			converted, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not an int", v)
+			}
+
+			return converted, nil
+		}
+	case InputTypeList:
+		switch v := value.(type) {
+		case nil:
+			return []string{}, nil
+		case []interface{}:
+			list := []string{}
+			for _, item := range v {
+				list = append(list, fmt.Sprint(item))
+			}
+
+			return list, nil
+		case string:
+			// A list set as 'name=a,b,c'.
+			list := []string{}
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+
+			return list, nil
+		}
+	case InputTypeEnum:
+		if value == nil {
+			return "", nil
+		}
+
+		var allowed []string
+		for _, allowedValue := range s.Values {
+			if fmt.Sprint(allowedValue) == fmt.Sprint(value) {
+				return fmt.Sprint(value), nil
+			}
+
+			allowed = append(allowed, fmt.Sprint(allowedValue))
+		}
+
+		return nil, fmt.Errorf("'%v' is not one of: %s", value, strings.Join(allowed, ", "))
+	default:
+		switch v := value.(type) {
+		case nil:
+			return "", nil
+		case []interface{}, map[string]interface{}:
+			return nil, fmt.Errorf("'%v' is not a string", v)
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+
+	return nil, fmt.Errorf("'%v' is not a %s", value, s.Type)
+}
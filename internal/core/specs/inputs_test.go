@@ -0,0 +1,135 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const inputsTaskManifest = `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: deploy
+spec:
+    inputs:
+        environment:
+            type: enum
+            values: [dev, prod]
+            default: dev
+        replicas:
+            type: int
+            default: 1
+        dryRun:
+            type: bool
+        regions:
+            type: list
+            default: [us-east-1]
+        version:
+            required: true
+    containerImage: alpine
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - binary: echo
+          commands:
+              - '{{ .Inputs.environment }} {{ .Inputs.replicas }} {{ .Inputs.dryRun }} {{ .Inputs.version }}'
+              - '{{ range .Inputs.regions }}{{ . }} {{ end }}'
+`
+
+func TestWithResolvedInputs(t *testing.T) {
+	t.Run("should resolve the inputs set, and the defaults", func(t *testing.T) {
+		builder := newTestBuilderInDir(t, t.TempDir(), entities.ManifestTypeTask, inputsTaskManifest,
+			map[string]interface{}{"environment": "prod", "dryRun": "true", "version": 2,
+				"regions": []interface{}{"eu-west-1", "us-east-1"}})
+
+		tasks, err := builder.BuildTasks()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"prod 1 true 2", "eu-west-1 us-east-1 "}, tasks[0].Spec.CommandsSpec[0].Commands)
+		assert.Equal(t, InputTypeEnum, tasks[0].Spec.Inputs["environment"].Type)
+	})
+
+	t.Run("should report the inputs that are missing or invalid", func(t *testing.T) {
+		builder := newTestBuilderInDir(t, t.TempDir(), entities.ManifestTypeTask, inputsTaskManifest,
+			map[string]interface{}{"environment": "staging", "replicas": "many"})
+
+		_, err := builder.BuildTasks()
+		assert.Error(t, err)
+
+		var messages []string
+		for _, diagnostic := range builder.Diagnostics() {
+			messages = append(messages, diagnostic.Path+": "+diagnostic.Message)
+
+			if diagnostic.Path == "spec.inputs.environment" {
+				assert.Equal(t, 8, diagnostic.Line)
+				assert.Equal(t, 9, diagnostic.Column)
+			}
+		}
+
+		assert.Contains(t, messages, "spec.inputs.environment: invalid value of the input 'environment': "+
+			"'staging' is not one of: dev, prod")
+		assert.Contains(t, messages, "spec.inputs.replicas: invalid value of the input 'replicas': "+
+			"'many' is not an int")
+		assert.Contains(t, messages, "spec.inputs.version: the input 'version' is required. "+
+			"Set it with '--set version=<value>', or in a '--vars-file'")
+	})
+
+	t.Run("should only accept the inputs at the spec of the manifest", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeJob, `---
+apiVersion: v2
+kind: Job
+metadata:
+    name: build
+spec:
+    containerImage: alpine
+    mountDir: .
+    workDir: src
+    tasks:
+        - name: build
+          inputs:
+              version: {}
+          commandsSpec:
+              - binary: echo
+                commands:
+                    - build
+`)
+
+		_, err := builder.BuildJobs()
+
+		assert.Error(t, err)
+		assert.Equal(t, "spec.tasks[0].inputs", builder.Diagnostics()[0].Path)
+	})
+}
+
+func TestParseInputAssignments(t *testing.T) {
+	t.Run("should split the name and the value", func(t *testing.T) {
+		inputs, err := ParseInputAssignments([]string{"version=1.2.3", "query=a=b", "empty="})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"version": "1.2.3", "query": "a=b", "empty": ""}, inputs)
+	})
+
+	t.Run("should fail without a name", func(t *testing.T) {
+		_, err := ParseInputAssignments([]string{"=value"})
+		assert.Error(t, err)
+
+		_, err = ParseInputAssignments([]string{"version"})
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadInputsFile(t *testing.T) {
+	t.Run("should load the typed values", func(t *testing.T) {
+		varsFile := filepath.Join(t.TempDir(), "vars.yaml")
+		assert.NoError(t, os.WriteFile(varsFile, []byte("replicas: 3\nregions:\n    - eu-west-1\n"), 0644))
+
+		inputs, err := LoadInputsFile(varsFile)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, inputs["replicas"])
+		assert.Equal(t, []interface{}{"eu-west-1"}, inputs["regions"])
+	})
+}
@@ -0,0 +1,72 @@
+package specs
+
+// JobManifestSpec groups several tasks that share the same settings (image,
+// directories and env vars) into a single manifest.
+type JobManifestSpec struct {
+	APIVersion string      `yaml:"apiVersion" required:"true" description:"Version of the manifest spec."`
+	Kind       string      `yaml:"kind" required:"true" description:"Kind of the manifest."`
+	Metadata   JobMetadata `yaml:"metadata" required:"true" description:"Metadata that identifies the job."`
+	Spec       JobSpec     `yaml:"spec" required:"true" description:"Specification of the job."`
+}
+
+type JobMetadata struct {
+	Name               string            `yaml:"name" required:"true" description:"Name of the job. E.g.: 'my-job'."`
+	MinStilettoVersion string            `yaml:"minStilettoVersion" description:"Minimum version of Stiletto required to run the manifest. E.g.: '0.1.0'."`
+	Labels             map[string]string `yaml:"labels" description:"Labels of the job, used to select what runs. E.g.: 'stage: plan', selected with '--labels stage=plan'."`
+	Annotations        map[string]string `yaml:"annotations" description:"Free-form metadata of the job (E.g.: its owner), which doesn't affect how it runs."`
+}
+
+// JobSpec holds the job-level defaults, and the ordered list of tasks to run.
+// A task that doesn't set its own containerImage, workDir, mountDir or baseDir
+// inherits the one set at the job level.
+type JobSpec struct {
+	ContainerImage string                `yaml:"containerImage" description:"Default container image of the tasks."`
+	Workdir        string                `yaml:"workDir" description:"Default workDir of the tasks."`
+	MountDir       string                `yaml:"mountDir" description:"Default mountDir of the tasks."`
+	BaseDir        string                `yaml:"baseDir" description:"Default baseDir of the tasks."`
+	EnvVarsSpec    EnvVarsSpec           `yaml:"envVarsSpec" description:"Environment variables passed to the tasks that inherit them from the job."`
+	Tasks          []*JobTaskSpec        `yaml:"tasks" required:"true" description:"Tasks of the job, executed in the order they're declared."`
+	Inputs         map[string]*InputSpec `yaml:"inputs" description:"Typed parameters of the manifest, available in its templates as '{{ .Inputs.name }}'."`
+	Timeout        string                `yaml:"timeout" description:"Maximum duration of the whole job. E.g.: '1h'."`
+}
+
+// JobTaskSpec is a task declared inline in a job manifest.
+type JobTaskSpec struct {
+	Name                  string `yaml:"name" required:"true" description:"Name of the task, unique within the job."`
+	InheritEnvVarsFromJob bool   `yaml:"inheritEnvVarsFromJob" description:"Pass the environment variables of the job to this task."`
+	TaskSpec              `yaml:",inline"`
+}
+
+// applyDefaults fills the task's empty fields with the job-level defaults.
+func (s *JobSpec) applyDefaults() {
+	for _, task := range s.Tasks {
+		if task == nil {
+			continue
+		}
+
+		if task.ContainerImage == "" {
+			task.ContainerImage = s.ContainerImage
+		}
+
+		if task.Workdir == "" {
+			task.Workdir = s.Workdir
+		}
+
+		if task.MountDir == "" {
+			task.MountDir = s.MountDir
+		}
+
+		if task.BaseDir == "" {
+			task.BaseDir = s.BaseDir
+		}
+	}
+}
+
+// setInputValues sets the values of the inputs of the manifest in each one of the tasks.
+func (s *JobSpec) setInputValues(values map[string]interface{}) {
+	for _, task := range s.Tasks {
+		if task != nil {
+			task.InputValues = values
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package specs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJobManifestSpecConvert(t *testing.T) {
+	t.Run("should apply the job defaults to the tasks that don't override them", func(t *testing.T) {
+		spec := JobSpec{
+			ContainerImage: "alpine/terragrunt",
+			Workdir:        "examples/terragrunt",
+			MountDir:       ".",
+			Tasks: []*JobTaskSpec{
+				{Name: "init"},
+				{Name: "plan", TaskSpec: TaskSpec{ContainerImage: "alpine", Workdir: "examples"}},
+			},
+		}
+
+		spec.applyDefaults()
+
+		assert.Equal(t, "alpine/terragrunt", spec.Tasks[0].ContainerImage)
+		assert.Equal(t, "examples/terragrunt", spec.Tasks[0].Workdir)
+		assert.Equal(t, ".", spec.Tasks[0].MountDir)
+		assert.Equal(t, "alpine", spec.Tasks[1].ContainerImage)
+		assert.Equal(t, "examples", spec.Tasks[1].Workdir)
+		assert.Equal(t, ".", spec.Tasks[1].MountDir)
+	})
+
+	t.Run("should convert every task keeping its order and env vars options", func(t *testing.T) {
+		manifest := &JobManifestSpec{
+			APIVersion: "v1",
+			Kind:       "Job",
+			Metadata:   JobMetadata{Name: "iac"},
+			Spec: JobSpec{
+				EnvVarsSpec: EnvVarsSpec{EnvVars: map[string]string{"JOB_VAR": "job"}},
+				Tasks: []*JobTaskSpec{
+					{
+						Name:                  "init",
+						InheritEnvVarsFromJob: true,
+						TaskSpec: TaskSpec{
+							CommandsSpec: []*CommandsSpec{
+								{Binary: "terragrunt", Commands: []string{"init", "plan"}},
+							},
+						},
+					},
+					{
+						Name: "apply",
+						TaskSpec: TaskSpec{
+							EnvVarsSpec: EnvVarsSpec{EnvVars: map[string]string{"TASK_VAR": "task"}},
+						},
+					},
+				},
+			},
+		}
+
+		converted, err := manifest.Convert()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "iac", converted.Job.Name)
+		assert.Equal(t, "job", converted.JobEnvCfg.EnvVarsExplicit["JOB_VAR"])
+		assert.Len(t, converted.Tasks, 2)
+		assert.Equal(t, "init", converted.Tasks[0].Task.Name)
+		assert.True(t, converted.Tasks[0].TaskEnvCfg.InheritEnvVarsFromJob)
+		assert.Len(t, converted.Tasks[0].Task.Commands, 2)
+		assert.Equal(t, "plan", converted.Tasks[0].Task.Commands[1].CommandArgs)
+		assert.Equal(t, "apply", converted.Tasks[1].Task.Name)
+		assert.False(t, converted.Tasks[1].TaskEnvCfg.InheritEnvVarsFromJob)
+		assert.Equal(t, "task", converted.Tasks[1].TaskEnvCfg.EnvVarsExplicit["TASK_VAR"])
+	})
+
+	t.Run("should return an error when the manifest is nil", func(t *testing.T) {
+		var manifest *JobManifestSpec
+
+		_, err := manifest.Convert()
+
+		assert.Error(t, err)
+	})
+}
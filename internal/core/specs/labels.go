@@ -0,0 +1,33 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/selector"
+	"sort"
+)
+
+// validateLabels validates the labels, and the keys of the annotations, of a manifest.
+// The labels are restricted to what a selector (E.g.: '--labels stage=plan') can express.
+func validateLabels(v *manifestValidator, labels, annotations map[string]string) {
+	for _, key := range sortedKeys(labels) {
+		if err := selector.ValidateLabel(key, labels[key]); err != nil {
+			v.error(joinPath("metadata.labels", key), "%s", err)
+		}
+	}
+
+	for _, key := range sortedKeys(annotations) {
+		if err := selector.ValidateLabel(key, ""); err != nil {
+			v.error(joinPath("metadata.annotations", key), "%s", err)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,141 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"sort"
+	"strings"
+)
+
+// Migration is the result of migrating the content of a manifest file to another version of the spec.
+type Migration struct {
+	// Content is the migrated content. Only the renamed fields and the 'apiVersion' of
+	// the documents are changed, so the formatting and comments are preserved.
+	Content []byte
+
+	// Changes are the changes applied, including the ones to the 'apiVersion' fields.
+	Changes []FieldChange
+}
+
+// textEdit replaces the text found at a given position of the content.
+type textEdit struct {
+	line   int
+	column int
+	from   string
+	to     string
+}
+
+// MigrateManifest migrates every document of a manifest file to the given version of the spec.
+func MigrateManifest(content []byte, to string) (*Migration, error) {
+	if apiVersionIndex(to) == -1 {
+		return nil, errors.NewArgumentError(fmt.Sprintf("invalid manifest api version: %s. Should be one of: %s",
+			to, strings.Join(SupportedAPIVersions(), ", ")), nil)
+	}
+
+	documents, err := yamlparser.DocumentsFromContent(string(content))
+	if err != nil {
+		return nil, errors.NewManifestError("Cannot migrate the manifest", err)
+	}
+
+	migration := &Migration{}
+	var edits []textEdit
+
+	for _, document := range documents {
+		apiVersion, found := document.Lookup("apiVersion")
+		if !found || apiVersionIndex(apiVersion.Value) == -1 {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot migrate the %s. Its apiVersion '%s' isn't "+
+				"supported. Should be one of: %s", document, apiVersion.Value,
+				strings.Join(SupportedAPIVersions(), ", ")), nil)
+		}
+
+		if apiVersion.Value == to {
+			continue
+		}
+
+		kind, _ := document.Lookup("kind")
+		if !isManifestKind(kind.Value) {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot migrate the %s. Invalid manifest kind: '%s'",
+				document, kind.Value), nil)
+		}
+
+		changes, err := ConvertDocument(document, kind.Value, apiVersion.Value, to)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, FieldChange{
+			Path:   "apiVersion",
+			Line:   apiVersion.Line,
+			Column: apiVersion.Column,
+			From:   apiVersion.Value,
+			To:     to,
+			Since:  to,
+		})
+
+		for _, change := range changes {
+			edits = append(edits, textEdit{line: change.Line, column: change.Column, from: change.From, to: change.To})
+		}
+
+		migration.Changes = append(migration.Changes, changes...)
+	}
+
+	migrated, err := applyTextEdits(string(content), edits)
+	if err != nil {
+		return nil, err
+	}
+
+	migration.Content = []byte(migrated)
+
+	return migration, nil
+}
+
+func isManifestKind(kind string) bool {
+	for _, manifestKind := range entities.ManifestKindByType {
+		if manifestKind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyTextEdits applies the edits to the content. The positions are the ones
+// reported by the yaml parser: 1-based lines and columns (in characters). A
+// quoted value is expected right after its opening quote.
+func applyTextEdits(content string, edits []textEdit) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	// Applying the edits from the end of each line keeps the columns of the other ones valid.
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line < edits[j].line
+		}
+
+		return edits[i].column > edits[j].column
+	})
+
+	for _, edit := range edits {
+		if edit.line < 1 || edit.line > len(lines) {
+			return "", errors.NewManifestError(fmt.Sprintf("Cannot migrate the manifest. "+
+				"The line %d doesn't exist", edit.line), nil)
+		}
+
+		line := []rune(lines[edit.line-1])
+		start := edit.column - 1
+		if start >= 0 && start < len(line) && (line[start] == '"' || line[start] == '\'') {
+			start++
+		}
+
+		end := start + len([]rune(edit.from))
+		if start < 0 || end > len(line) || string(line[start:end]) != edit.from {
+			return "", errors.NewManifestError(fmt.Sprintf("Cannot migrate the manifest. "+
+				"Expected '%s' at line %d, column %d", edit.from, edit.line, edit.column), nil)
+		}
+
+		lines[edit.line-1] = string(line[:start]) + edit.to + string(line[end:])
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,73 @@
+package specs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestMigrateManifest(t *testing.T) {
+	t.Run("should migrate every document, preserving the comments and the formatting", func(t *testing.T) {
+		content := `---
+# The build job.
+apiVersion: v1
+kind: Job
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workdir: src # relative to the mountDir
+    tasks:
+        - name: compile
+          "workdir": src
+          commandsSpec:
+              - commands: [cargo build]
+---
+apiVersion: "v2"
+kind: Task
+metadata:
+    name: lint
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - commands: [cargo clippy]
+`
+
+		migration, err := MigrateManifest([]byte(content), "v2")
+
+		assert.NoError(t, err)
+		assert.Len(t, migration.Changes, 3)
+
+		expected := strings.NewReplacer(
+			"apiVersion: v1", "apiVersion: v2",
+			"    workdir: src # relative", "    workDir: src # relative",
+			`"workdir": src`, `"workDir": src`,
+		).Replace(content)
+
+		assert.Equal(t, expected, string(migration.Content))
+	})
+
+	t.Run("should downgrade a manifest", func(t *testing.T) {
+		migration, err := MigrateManifest([]byte(jobManifestV1), "v1")
+
+		assert.NoError(t, err)
+		assert.Empty(t, migration.Changes)
+
+		upgraded, err := MigrateManifest([]byte(jobManifestV1), "v2")
+		assert.NoError(t, err)
+
+		downgraded, err := MigrateManifest(upgraded.Content, "v1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, jobManifestV1, string(downgraded.Content))
+	})
+
+	t.Run("should fail with an unsupported version", func(t *testing.T) {
+		_, err := MigrateManifest([]byte("apiVersion: v0\nkind: Task\n"), "v2")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/retry"
+	"regexp"
+	"time"
+)
+
+// RetrySpec sets how a failed command, or task, is retried. E.g.:
+//
+//	retry:
+//	    maxAttempts: 3
+//	    backoff: exponential
+//	    delay: 5s
+//	    onOutputPatterns: ['TLS handshake timeout']
+type RetrySpec struct {
+	MaxAttempts      int      `yaml:"maxAttempts" required:"true" description:"Total number of attempts, including the first one."`
+	Backoff          string   `yaml:"backoff" enum:"fixed,exponential" description:"Backoff between the attempts: 'fixed', or 'exponential' (the delay doubles after each attempt). It defaults to 'fixed'."`
+	Delay            string   `yaml:"delay" description:"Delay before the first retry. E.g.: '5s'. It defaults to '1s'."`
+	MaxDelay         string   `yaml:"maxDelay" description:"Maximum delay between the attempts. E.g.: '1m'."`
+	OnExitCodes      []int    `yaml:"onExitCodes" description:"Retry only the failures with one of these exit codes."`
+	OnOutputPatterns []string `yaml:"onOutputPatterns" description:"Retry only the failures whose output matches one of these regular expressions."`
+}
+
+// convertRetrySpec converts the retry spec into the policy applied by the runner.
+func convertRetrySpec(spec *RetrySpec) *retry.Policy {
+	if spec == nil {
+		return nil
+	}
+
+	backoff := spec.Backoff
+	if backoff == "" {
+		backoff = retry.BackoffFixed
+	}
+
+	return &retry.Policy{
+		MaxAttempts:      spec.MaxAttempts,
+		Backoff:          backoff,
+		Delay:            parseTimeout(spec.Delay),
+		MaxDelay:         parseTimeout(spec.MaxDelay),
+		OnExitCodes:      spec.OnExitCodes,
+		OnOutputPatterns: spec.OnOutputPatterns,
+	}
+}
+
+// validateRetrySpec validates the retry spec of a command, or a task.
+func validateRetrySpec(v *manifestValidator, path string, spec *RetrySpec) {
+	if spec == nil {
+		return
+	}
+
+	if spec.MaxAttempts < 1 {
+		v.error(joinPath(path, "maxAttempts"), "The retry maxAttempts should be at least 1, since "+
+			"it includes the first attempt")
+	}
+
+	if spec.Backoff != "" && spec.Backoff != retry.BackoffFixed && spec.Backoff != retry.BackoffExponential {
+		v.error(joinPath(path, "backoff"), "invalid retry backoff: %s. Should be '%s' or '%s'", spec.Backoff,
+			retry.BackoffFixed, retry.BackoffExponential)
+	}
+
+	for _, field := range []struct{ name, value string }{{"delay", spec.Delay}, {"maxDelay", spec.MaxDelay}} {
+		if field.value == "" {
+			continue
+		}
+
+		if duration, err := time.ParseDuration(field.value); err != nil || duration < 0 {
+			v.error(joinPath(path, field.name), "The retry %s '%s' is invalid. It should be a duration. "+
+				"E.g.: '5s'", field.name, field.value)
+		}
+	}
+
+	for idx, pattern := range spec.OnOutputPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.error(joinPath(path, fmt.Sprintf("onOutputPatterns[%d]", idx)),
+				"The retry output pattern '%s' isn't a valid regular expression: %s", pattern, err)
+		}
+	}
+}
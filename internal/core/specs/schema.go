@@ -0,0 +1,191 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/jsonschema"
+	"strings"
+)
+
+// SchemaKindAll is the kind used to generate a schema that accepts any manifest kind.
+const SchemaKindAll = "all"
+
+// taskFieldsInheritedFromJob are the fields of a task that, in a job, can be omitted
+// since they're inherited from the job.
+var taskFieldsInheritedFromJob = []string{"containerImage", "workDir", "mountDir"}
+
+// JSONSchema generates the JSON Schema of the manifests of the given kind ('task',
+// 'job', 'workflow' or 'all'). The required fields mirror the ones enforced by
+// WithStrictDeepValidation.
+func JSONSchema(kind string) (*jsonschema.Schema, error) {
+	var schema *jsonschema.Schema
+
+	switch kind {
+	case "task":
+		schema = manifestSchema(TaskManifestSpec{}, entities.ManifestKindTask)
+	case "job":
+		schema = manifestSchema(JobManifestSpec{}, entities.ManifestKindJob)
+	case "workflow":
+		schema = manifestSchema(WorkflowManifestSpec{}, entities.ManifestKindWorkflow)
+	case SchemaKindAll:
+		schema = &jsonschema.Schema{
+			Description: "A Stiletto manifest (Task, Job or Workflow).",
+			OneOf: []*jsonschema.Schema{
+				manifestSchema(TaskManifestSpec{}, entities.ManifestKindTask),
+				manifestSchema(JobManifestSpec{}, entities.ManifestKindJob),
+				manifestSchema(WorkflowManifestSpec{}, entities.ManifestKindWorkflow),
+			},
+		}
+	default:
+		return nil, errors.NewArgumentError(fmt.Sprintf("invalid manifest kind: %s. "+
+			"Should be 'task', 'job', 'workflow' or 'all'", kind), nil)
+	}
+
+	schema.Schema = jsonschema.Draft
+	schema.Title = "Stiletto manifest"
+	if kind != SchemaKindAll {
+		schema.Title = fmt.Sprintf("Stiletto %s manifest", kind)
+	}
+
+	return schema, nil
+}
+
+// manifestSchema returns the schema of the manifests of the given kind, which
+// accepts any of the supported versions of the spec.
+func manifestSchema(spec interface{}, kind string) *jsonschema.Schema {
+	schema := &jsonschema.Schema{
+		Description: fmt.Sprintf("A Stiletto '%s' manifest.", kind),
+	}
+
+	for idx := len(specVersions) - 1; idx >= 0; idx-- {
+		schema.OneOf = append(schema.OneOf, manifestVersionSchema(spec, kind, idx))
+	}
+
+	return schema
+}
+
+// manifestVersionSchema returns the schema of the manifests of the given kind, and
+// version. The schema is reflected out of the spec (which follows the latest version)
+// and then the fields renamed since the given version are reverted.
+func manifestVersionSchema(spec interface{}, kind string, versionIdx int) *jsonschema.Schema {
+	apiVersion := specVersions[versionIdx].name
+
+	schema := jsonschema.Reflect(spec)
+	schema.Description = fmt.Sprintf("A Stiletto '%s' manifest (apiVersion '%s').", kind, apiVersion)
+	schema.Properties["kind"].Enum = []interface{}{kind}
+	schema.Properties["apiVersion"].Enum = []interface{}{apiVersion}
+
+	specSchema := schema.Properties["spec"]
+
+	switch kind {
+	case entities.ManifestKindJob:
+		relaxJobTasks(specSchema)
+	case entities.ManifestKindWorkflow:
+		relaxJobTasks(specSchema.Properties["jobs"].Items)
+
+		// The inputs are only declared at the spec of the manifest.
+		delete(specSchema.Properties["jobs"].Items.Properties, "inputs")
+	}
+
+	for idx := len(specVersions) - 1; idx > versionIdx; idx-- {
+		for _, rename := range specVersions[idx].renames {
+			for _, pattern := range rename.paths[kind] {
+				if object := lookupSchema(schema, pattern); object != nil {
+					renameSchemaProperty(object, rename.to, rename.from)
+				}
+			}
+		}
+	}
+
+	if kind == entities.ManifestKindTask {
+		relaxExtendingTask(schema)
+	}
+
+	return schema
+}
+
+// relaxExtendingTask makes the spec of a task manifest (and its fields) optional when
+// it extends another manifest, since they can be declared in the extended one.
+func relaxExtendingTask(schema *jsonschema.Schema) {
+	specSchema := schema.Properties["spec"]
+
+	var required []string
+	for _, field := range schema.Required {
+		if field != "spec" {
+			required = append(required, field)
+		}
+	}
+
+	schema.Required = required
+	schema.If = &jsonschema.Schema{Required: []string{"extends"}}
+	schema.Else = &jsonschema.Schema{
+		Required: []string{"spec"},
+		Properties: map[string]*jsonschema.Schema{
+			"spec": {Required: specSchema.Required},
+		},
+	}
+
+	specSchema.Required = nil
+}
+
+// lookupSchema returns the schema of the object at the given path pattern, where '[*]'
+// refers to the items of an array. See yamlparser.Document.LookupAll.
+func lookupSchema(schema *jsonschema.Schema, pattern string) *jsonschema.Schema {
+	current := schema
+	for _, segment := range strings.Split(strings.ReplaceAll(pattern, "[*]", ".[*]"), ".") {
+		if segment == "[*]" {
+			current = current.Items
+		} else {
+			current = current.Properties[segment]
+		}
+
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+func renameSchemaProperty(object *jsonschema.Schema, from, to string) {
+	property, ok := object.Properties[from]
+	if !ok {
+		return
+	}
+
+	delete(object.Properties, from)
+	object.Properties[to] = property
+
+	for idx, field := range object.Required {
+		if field == from {
+			object.Required[idx] = to
+		}
+	}
+}
+
+// relaxJobTasks makes optional the fields that the tasks of a job inherit from it. The
+// inputs aren't accepted in the tasks, since they're only declared at the spec of the manifest.
+func relaxJobTasks(jobSchema *jsonschema.Schema) {
+	taskSchema := jobSchema.Properties["tasks"].Items
+	delete(taskSchema.Properties, "inputs")
+
+	var required []string
+	for _, field := range taskSchema.Required {
+		if !contains(taskFieldsInheritedFromJob, field) {
+			required = append(required, field)
+		}
+	}
+
+	taskSchema.Required = required
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
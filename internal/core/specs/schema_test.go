@@ -0,0 +1,77 @@
+package specs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Run("should restrict the kind and apiVersion of a task manifest", func(t *testing.T) {
+		schema, err := JSONSchema("task")
+
+		assert.NoError(t, err)
+		assert.Len(t, schema.OneOf, 2)
+
+		latest := schema.OneOf[0]
+		assert.Equal(t, []interface{}{"Task"}, latest.Properties["kind"].Enum)
+		assert.Equal(t, []interface{}{"v2"}, latest.Properties["apiVersion"].Enum)
+		assert.ElementsMatch(t, []string{"containerImage", "workDir", "mountDir", "commandsSpec"},
+			latest.Else.Properties["spec"].Required)
+	})
+
+	t.Run("should only require the spec of a task manifest that doesn't extend another one", func(t *testing.T) {
+		schema, err := JSONSchema("task")
+
+		assert.NoError(t, err)
+
+		for _, versionSchema := range schema.OneOf {
+			assert.NotContains(t, versionSchema.Required, "spec")
+			assert.Empty(t, versionSchema.Properties["spec"].Required)
+			assert.Equal(t, []string{"extends"}, versionSchema.If.Required)
+			assert.Equal(t, []string{"spec"}, versionSchema.Else.Required)
+			assert.Len(t, versionSchema.Properties["extends"].OneOf, 2)
+		}
+
+		v1 := schema.OneOf[1]
+		assert.Contains(t, v1.Else.Properties["spec"].Required, "workdir")
+	})
+
+	t.Run("should keep the fields renamed since the previous versions", func(t *testing.T) {
+		schema, err := JSONSchema("job")
+
+		assert.NoError(t, err)
+
+		v1 := schema.OneOf[1]
+		assert.Equal(t, []interface{}{"v1"}, v1.Properties["apiVersion"].Enum)
+		assert.Contains(t, v1.Properties["spec"].Properties, "workdir")
+		assert.NotContains(t, v1.Properties["spec"].Properties, "workDir")
+		assert.Contains(t, v1.Properties["spec"].Properties["tasks"].Items.Properties, "workdir")
+	})
+
+	t.Run("should not require in the tasks of a job the fields inherited from the job", func(t *testing.T) {
+		schema, err := JSONSchema("workflow")
+
+		assert.NoError(t, err)
+
+		for _, versionSchema := range schema.OneOf {
+			task := versionSchema.Properties["spec"].Properties["jobs"].Items.Properties["tasks"].Items
+			assert.ElementsMatch(t, []string{"name", "commandsSpec"}, task.Required)
+			assert.Contains(t, task.Properties, "inheritEnvVarsFromJob")
+			assert.NotContains(t, task.Properties, "inputs")
+			assert.Contains(t, versionSchema.Properties["spec"].Properties, "inputs")
+		}
+	})
+
+	t.Run("should accept any kind of manifest", func(t *testing.T) {
+		schema, err := JSONSchema(SchemaKindAll)
+
+		assert.NoError(t, err)
+		assert.Len(t, schema.OneOf, 3)
+	})
+
+	t.Run("should fail with an unknown kind", func(t *testing.T) {
+		_, err := JSONSchema("pipeline")
+
+		assert.Error(t, err)
+	})
+}
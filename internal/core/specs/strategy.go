@@ -0,0 +1,229 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/job"
+	"github.com/excoriate/stiletto/internal/jsonschema"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strings"
+)
+
+var (
+	// matrixVariablePattern finds the matrix variables used in the templates of a manifest.
+	matrixVariablePattern = regexp.MustCompile(`\.Matrix\.([A-Za-z0-9_]+)`)
+
+	// templateActionPattern finds the actions of the templates of a manifest. E.g.: '{{ .Matrix.dir }}'.
+	templateActionPattern = regexp.MustCompile(`(?s){{-?(.*?)-?}}`)
+
+	// bareMatrixVariablePattern matches an action that only references a matrix variable.
+	bareMatrixVariablePattern = regexp.MustCompile(`^\s*\.Matrix\.[A-Za-z0-9_]+\s*$`)
+
+	matrixReferencePattern = regexp.MustCompile(`\.Matrix\b`)
+)
+
+// StrategySpec sets how a task runs.
+type StrategySpec struct {
+	Matrix *MatrixSpec `yaml:"matrix" description:"Run the task once per combination of the values of the matrix."`
+}
+
+// MatrixSpec fans a task out over the combinations of the values of its axes. Each
+// field, other than 'include' and 'exclude', is an axis: a list of values, or an
+// object with a 'glob' to compute them out of the paths it matches. E.g.:
+//
+//	matrix:
+//	    image: [alpine:3.18, alpine:3.19]
+//	    dir:
+//	        glob: live/**/terragrunt.hcl
+//	        dirname: true
+//	    exclude:
+//	        - image: alpine:3.18
+type MatrixSpec struct {
+	Axes    []*MatrixAxisSpec
+	Include []map[string]string
+	Exclude []map[string]string
+}
+
+// MatrixAxisSpec is an axis of the matrix.
+type MatrixAxisSpec struct {
+	Name    string   `yaml:"-"`
+	Values  []string `yaml:"-"`
+	Glob    string   `yaml:"glob" required:"true" description:"Glob pattern, relative to the mountDir, whose matches are the values of the axis. '**' matches any number of directories."`
+	Dirname bool     `yaml:"dirname" description:"Use the directories of the paths matched, instead of the paths."`
+}
+
+// UnmarshalYAML decodes the axes in the order they're declared.
+func (m *MatrixSpec) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: the matrix should be an object, with an axis per field", value.Line)
+	}
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, node := value.Content[i], value.Content[i+1]
+
+		switch key.Value {
+		case "include":
+			if err := node.Decode(&m.Include); err != nil {
+				return err
+			}
+		case "exclude":
+			if err := node.Decode(&m.Exclude); err != nil {
+				return err
+			}
+		default:
+			axis := &MatrixAxisSpec{Name: key.Value}
+
+			var err error
+			if node.Kind == yaml.SequenceNode {
+				err = node.Decode(&axis.Values)
+			} else {
+				type matrixAxisSpec MatrixAxisSpec
+				err = node.Decode((*matrixAxisSpec)(axis))
+			}
+
+			if err != nil {
+				return err
+			}
+
+			m.Axes = append(m.Axes, axis)
+		}
+	}
+
+	return nil
+}
+
+// JSONSchema describes the axes of the matrix, as its additional properties.
+func (MatrixSpec) JSONSchema() *jsonschema.Schema {
+	type matrixAxisSpec MatrixAxisSpec
+
+	combinations := &jsonschema.Schema{
+		Type:  "array",
+		Items: &jsonschema.Schema{Type: "object", AdditionalProperties: &jsonschema.Schema{Type: "string"}},
+	}
+
+	return &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			"include": withDescription(combinations, "Variables added to the combinations that "+
+				"match them, or new combinations if there's none."),
+			"exclude": withDescription(combinations, "Combinations removed from the matrix."),
+		},
+		AdditionalProperties: &jsonschema.Schema{
+			Description: "Axis of the matrix: its values, or a glob to compute them.",
+			OneOf: []*jsonschema.Schema{
+				{Type: "array", Items: &jsonschema.Schema{Type: "string"}, MinItems: intPtr(1)},
+				jsonschema.Reflect(matrixAxisSpec{}),
+			},
+		},
+	}
+}
+
+// convertMatrixSpec converts the matrix into the arguments required by the job builder.
+func convertMatrixSpec(spec *MatrixSpec) *job.MatrixArgs {
+	if spec == nil {
+		return nil
+	}
+
+	matrix := &job.MatrixArgs{
+		Include: spec.Include,
+		Exclude: spec.Exclude,
+	}
+
+	for _, axis := range spec.Axes {
+		matrix.Axes = append(matrix.Axes, job.MatrixAxis{
+			Name:    axis.Name,
+			Values:  axis.Values,
+			Glob:    axis.Glob,
+			Dirname: axis.Dirname,
+		})
+	}
+
+	return matrix
+}
+
+// validateMatrixSpec validates the matrix of a task.
+func validateMatrixSpec(v *manifestValidator, path string, spec *MatrixSpec) {
+	if len(spec.Axes) == 0 && len(spec.Include) == 0 {
+		v.error(path, "The matrix has no axes. It should declare at least one axis, or include")
+	}
+
+	axisNames := map[string]bool{}
+	for _, axis := range spec.Axes {
+		axisPath := joinPath(path, axis.Name)
+		axisNames[axis.Name] = true
+
+		if axis.Glob == "" && len(axis.Values) == 0 {
+			v.error(axisPath, "The matrix axis '%s' has no values. It should declare its values, or a glob",
+				axis.Name)
+		}
+	}
+
+	for idx, exclude := range spec.Exclude {
+		for key := range exclude {
+			if !axisNames[key] {
+				v.error(joinPath(path, fmt.Sprintf("exclude[%d]", idx)),
+					"The matrix exclude uses '%s', which isn't an axis of the matrix", key)
+			}
+		}
+	}
+}
+
+// matrixPlaceholders returns, for each matrix variable used in the content, the template
+// that references it. They're used as the data of the templates of the manifest, so the
+// matrix variables are kept as they are until the job builder expands the matrix.
+func matrixPlaceholders(content string) map[string]string {
+	placeholders := map[string]string{}
+	for _, match := range matrixVariablePattern.FindAllStringSubmatch(content, -1) {
+		placeholders[match[1]] = fmt.Sprintf("{{ .Matrix.%s }}", match[1])
+	}
+
+	return placeholders
+}
+
+// validateMatrixReferences checks that the matrix variables are referenced as they are
+// (E.g.: '{{ .Matrix.env }}'), since they're only known once the matrix is expanded: a
+// function, or a condition, on them (E.g.: '{{ toUpper .Matrix.env }}') would run on the
+// template of the variable, instead of its value.
+func validateMatrixReferences(file, content string) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	for _, match := range templateActionPattern.FindAllStringSubmatchIndex(content, -1) {
+		action := content[match[2]:match[3]]
+		if !matrixReferencePattern.MatchString(action) || bareMatrixVariablePattern.MatchString(action) {
+			continue
+		}
+
+		line := strings.Count(content[:match[0]], "\n") + 1
+		column := match[0] - strings.LastIndex(content[:match[0]], "\n")
+
+		diagnostics = append(diagnostics, Diagnostic{
+			File:     file,
+			Line:     line,
+			Column:   column,
+			Severity: SeverityError,
+			Message: fmt.Sprintf("The matrix variables can only be referenced as they are (E.g.: "+
+				"'{{ .Matrix.env }}'), but '%s' uses them in an expression. The functions and conditions "+
+				"aren't supported on them, since their values are only known once the matrix is expanded",
+				content[match[0]:match[1]]),
+		})
+	}
+
+	return diagnostics
+}
+
+// usesMatrixVariables reports whether the value references the matrix variables, so
+// it can only be validated once the matrix is expanded.
+func usesMatrixVariables(value string) bool {
+	return strings.Contains(value, ".Matrix.")
+}
+
+func withDescription(schema *jsonschema.Schema, description string) *jsonschema.Schema {
+	described := *schema
+	described.Description = description
+
+	return &described
+}
+
+func intPtr(v int) *int {
+	return &v
+}
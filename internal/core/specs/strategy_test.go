@@ -0,0 +1,136 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestMatrixSpec(t *testing.T) {
+	t.Run("should decode the axes in order, and keep the matrix variables", func(t *testing.T) {
+		builder := newTestBuilderInDir(t, t.TempDir(), entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: plan
+spec:
+    inputs:
+        command:
+            default: plan
+    containerImage: 'alpine/terragrunt:{{ .Matrix.version }}'
+    mountDir: .
+    workDir: '{{ .Matrix.dir }}'
+    strategy:
+        matrix:
+            version: ['1.5', '1.6']
+            dir:
+                glob: '**/terragrunt.hcl'
+                dirname: true
+            exclude:
+                - version: '1.5'
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - '{{ .Inputs.command }} -out={{ .Matrix.version }}.tfplan'
+`, nil)
+
+		tasks, err := builder.BuildTasks()
+
+		assert.NoError(t, err)
+
+		spec := tasks[0].Spec
+		assert.Equal(t, "alpine/terragrunt:{{ .Matrix.version }}", spec.ContainerImage)
+		assert.Equal(t, []string{"plan -out={{ .Matrix.version }}.tfplan"}, spec.CommandsSpec[0].Commands)
+
+		matrix := convertTaskSpec("plan", &spec).Matrix
+		assert.Len(t, matrix.Axes, 2)
+		assert.Equal(t, "version", matrix.Axes[0].Name)
+		assert.Equal(t, []string{"1.5", "1.6"}, matrix.Axes[0].Values)
+		assert.Equal(t, "dir", matrix.Axes[1].Name)
+		assert.Equal(t, "**/terragrunt.hcl", matrix.Axes[1].Glob)
+		assert.True(t, matrix.Axes[1].Dirname)
+		assert.Equal(t, []map[string]string{{"version": "1.5"}}, matrix.Exclude)
+	})
+
+	t.Run("should report the excludes that don't use the axes", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: plan
+spec:
+    containerImage: alpine
+    mountDir: .
+    workDir: src
+    strategy:
+        matrix:
+            version: []
+            exclude:
+                - image: alpine
+    commandsSpec:
+        - binary: terragrunt
+          commands:
+              - plan
+`)
+
+		_, err := builder.BuildTasks()
+		assert.Error(t, err)
+
+		var paths []string
+		for _, diagnostic := range builder.Diagnostics() {
+			paths = append(paths, diagnostic.Path)
+		}
+
+		assert.Equal(t, []string{"spec.strategy.matrix.version", "spec.strategy.matrix.exclude[0]"}, paths)
+	})
+}
+
+func TestMatrixReferences(t *testing.T) {
+	manifest := `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: deploy
+spec:
+    containerImage: alpine
+    mountDir: .
+    workDir: src
+    strategy:
+        matrix:
+            env: [dev, prod]
+    envVarsSpec:
+        envVars:
+            ENV: '{{ .Matrix.env }}'
+            ENV_NAME: '%s'
+    commandsSpec:
+        - run: echo deploying
+`
+
+	t.Run("should reject the functions on the matrix variables", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask,
+			strings.Replace(manifest, "%s", "{{ toUpper .Matrix.env }}", 1))
+
+		_, err := builder.BuildTasks()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, 16, diagnostics[0].Line)
+		assert.Equal(t, 24, diagnostics[0].Column)
+		assert.Contains(t, diagnostics[0].Message, "'{{ toUpper .Matrix.env }}' uses them in an expression")
+	})
+
+	t.Run("should reject the conditions on the matrix variables", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeTask,
+			strings.Replace(manifest, "%s", `{{ if eq .Matrix.env "prod" }}production{{ end }}`, 1))
+
+		_, err := builder.BuildTasks()
+		assert.Error(t, err)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 1)
+		assert.Equal(t, 16, diagnostics[0].Line)
+		assert.Contains(t, diagnostics[0].Message, `'{{ if eq .Matrix.env "prod" }}' uses them in an expression`)
+	})
+}
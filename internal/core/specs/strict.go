@@ -0,0 +1,42 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/jsonschema"
+)
+
+// validateKnownFields reports the fields of the manifest that its spec doesn't declare
+// (E.g.: a typo, or a field with the wrong case), suggesting the closest declared one.
+// They're errors, unless the manifest is decoded in lenient mode, where they're only
+// warnings, and are ignored.
+func (b *Builder) validateKnownFields(v *manifestValidator) {
+	severity := SeverityError
+	if b.lenient {
+		severity = SeverityWarning
+	}
+
+	for _, field := range v.document.UnknownFields(jsonschema.Reflect(b.newManifestSpec())) {
+		message := fmt.Sprintf("Unknown field '%s'", field.Name)
+		if field.Suggestion != "" {
+			message = fmt.Sprintf("%s, did you mean '%s'?", message, field.Suggestion)
+		}
+
+		if b.lenient {
+			message += " It's ignored, since the manifest is decoded in lenient mode"
+		}
+
+		file := v.file
+		if field.File != "" {
+			file = field.File
+		}
+
+		v.diagnostics = append(v.diagnostics, Diagnostic{
+			File:     file,
+			Line:     field.Line,
+			Column:   field.Column,
+			Path:     field.Path,
+			Severity: severity,
+			Message:  message,
+		})
+	}
+}
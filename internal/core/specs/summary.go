@@ -0,0 +1,75 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"strings"
+)
+
+// ManifestSummary describes a manifest (one per document of the file), without
+// compiling it. E.g.: to list the manifests of a repository.
+type ManifestSummary struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Image is the container image of the manifest. A workflow lists the images of its
+	// jobs, separated by commas.
+	Image  string            `json:"image"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Path   string            `json:"path"`
+}
+
+type manifestHeader struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name   string            `yaml:"name"`
+		Labels map[string]string `yaml:"labels"`
+	} `yaml:"metadata"`
+	Spec struct {
+		ContainerImage string `yaml:"containerImage"`
+		Jobs           []struct {
+			ContainerImage string `yaml:"containerImage"`
+		} `yaml:"jobs"`
+	} `yaml:"spec"`
+}
+
+// SummarizeManifest returns the summary of each document of the manifest file. The
+// manifest isn't compiled, so its templates (if any) aren't rendered.
+func SummarizeManifest(manifestFile string) ([]ManifestSummary, error) {
+	documents, err := yamlparser.DocumentsFromFile(manifestFile)
+	if err != nil {
+		return nil, errors.NewManifestError(fmt.Sprintf("Cannot summarize the manifest %s",
+			manifestFile), err)
+	}
+
+	var summaries []ManifestSummary
+	for _, document := range documents {
+		var header manifestHeader
+		if err := document.Decode(&header); err != nil {
+			return nil, errors.NewManifestError(fmt.Sprintf("Cannot summarize the manifest %s",
+				manifestFile), err)
+		}
+
+		image := header.Spec.ContainerImage
+		if image == "" {
+			var images []string
+			for _, j := range header.Spec.Jobs {
+				if j.ContainerImage != "" && !contains(images, j.ContainerImage) {
+					images = append(images, j.ContainerImage)
+				}
+			}
+
+			image = strings.Join(images, ",")
+		}
+
+		summaries = append(summaries, ManifestSummary{
+			Kind:   header.Kind,
+			Name:   header.Metadata.Name,
+			Image:  image,
+			Labels: header.Metadata.Labels,
+			Path:   manifestFile,
+		})
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,45 @@
+package specs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarizeManifest(t *testing.T) {
+	t.Run("should summarize each document of the manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestManifest(t, dir, "tasks.yml", baseTaskManifest+`---
+apiVersion: v2
+kind: Workflow
+metadata:
+    name: release
+spec:
+    jobs:
+        - name: build
+          containerImage: rust:alpine
+        - name: test
+          containerImage: rust:alpine
+        - name: publish
+          containerImage: docker:stable-dind
+`)
+
+		summaries, err := SummarizeManifest(filepath.Join(dir, "tasks.yml"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []ManifestSummary{
+			{Kind: "Task", Name: "base", Image: "alpine/terragrunt", Path: filepath.Join(dir, "tasks.yml")},
+			{Kind: "Workflow", Name: "release", Image: "rust:alpine,docker:stable-dind",
+				Path: filepath.Join(dir, "tasks.yml")},
+		}, summaries)
+	})
+
+	t.Run("should fail if the manifest isn't a valid yaml file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTestManifest(t, dir, "broken.yml", "kind: [Task\n")
+
+		_, err := SummarizeManifest(filepath.Join(dir, "broken.yml"))
+
+		assert.Error(t, err)
+	})
+}
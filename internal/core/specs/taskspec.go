@@ -1,46 +1,67 @@
 package specs
 
 type TaskManifestSpec struct {
-	APIVersion string `yaml:"apiVersion"`
-	Kind       string `yaml:"kind"`
-	Metadata   TaskMetadata
-	Spec       TaskSpec `yaml:"spec"`
+	APIVersion string       `yaml:"apiVersion" required:"true" description:"Version of the manifest spec."`
+	Kind       string       `yaml:"kind" required:"true" description:"Kind of the manifest."`
+	Metadata   TaskMetadata `yaml:"metadata" required:"true" description:"Metadata that identifies the task."`
+	Extends    ExtendsSpec  `yaml:"extends" description:"Task manifest that this one extends. Its fields are deep-merged under the ones of this manifest."`
+	Spec       TaskSpec     `yaml:"spec" required:"true" description:"Specification of the task."`
 }
 
 type TaskMetadata struct {
-	Name string `yaml:"name"`
+	Name               string            `yaml:"name" required:"true" description:"Name of the task. E.g.: 'my-task'."`
+	MinStilettoVersion string            `yaml:"minStilettoVersion" description:"Minimum version of Stiletto required to run the manifest. E.g.: '0.1.0'."`
+	Labels             map[string]string `yaml:"labels" description:"Labels of the task, used to select what runs. E.g.: 'stage: plan', selected with '--labels stage=plan'."`
+	Annotations        map[string]string `yaml:"annotations" description:"Free-form metadata of the task (E.g.: its owner), which doesn't affect how it runs."`
 }
 
 type TaskSpec struct {
-	ContainerImage string          `yaml:"containerImage"`
-	Workdir        string          `yaml:"workdir"`
-	MountDir       string          `yaml:"mountDir"`
-	BaseDir        string          `yaml:"baseDir"` // Optional, normally it's resolved or computed.
-	CommandsSpec   []*CommandsSpec `yaml:"commandsSpec"`
-	EnvVarsSpec    EnvVarsSpec     `yaml:"envVarsSpec"`
+	ContainerImage string                `yaml:"containerImage" required:"true" description:"Container image used to bootstrap the container where the commands run."`
+	Workdir        string                `yaml:"workDir" required:"true" description:"Directory, relative to the mountDir, where the commands run."`
+	MountDir       string                `yaml:"mountDir" required:"true" description:"Directory, relative to the baseDir, that's mounted in the container."`
+	BaseDir        string                `yaml:"baseDir" description:"Absolute directory where the mountDir is resolved from. It defaults to the current directory."` // Optional, normally it's resolved or computed.
+	CommandsSpec   []*CommandsSpec       `yaml:"commandsSpec" required:"true" description:"Commands to run in the container, in the order they're declared."`
+	Inputs         map[string]*InputSpec `yaml:"inputs" description:"Typed parameters of the manifest, available in its templates as '{{ .Inputs.name }}'."`
+	EnvVarsSpec    EnvVarsSpec           `yaml:"envVarsSpec" description:"Environment variables passed to the container."`
+	Strategy       StrategySpec          `yaml:"strategy" description:"Strategy of the task. E.g.: a matrix to run it once per combination of values."`
+	If             string                `yaml:"if" description:"Expression that decides whether the task runs. E.g.: \"git.branch == 'main'\"."`
+	Timeout        string                `yaml:"timeout" description:"Maximum duration of each attempt of the task. E.g.: '15m'."`
+	Retry          *RetrySpec            `yaml:"retry" description:"Retry the whole task, in a new container, if any of its commands fails."`
+	Shell          string                `yaml:"shell" enum:"sh,bash" description:"Shell that runs the 'run' and 'scriptFile' steps. It defaults to 'sh'."`
+
+	// InputValues are the values of the inputs of the manifest, resolved by the builder.
+	InputValues map[string]interface{} `yaml:"-"`
 }
 
 type EnvVarsSpec struct {
-	EnvVars        map[string]string      `yaml:"envVars"`
-	EnvVarsScanned EnvVarsScannedOptsSpec `yaml:"envVarsScanned"`
-	DotFiles       []string               `yaml:"dotFiles"`
+	EnvVars        map[string]string      `yaml:"envVars" description:"Environment variables set explicitly."`
+	EnvVarsScanned EnvVarsScannedOptsSpec `yaml:"envVarsScanned" description:"Environment variables scanned from the host."`
+	DotFiles       []string               `yaml:"dotFiles" description:"Dotfiles (E.g.: '.env') where environment variables are read from."`
 }
 
 type EnvVarsScannedOptsSpec struct {
-	ScanAWSEnvVars       EnvVarsScanOptsSpec `yaml:"scanAWSEnvVars"`
-	ScanTerraformEnvVars EnvVarsScanOptsSpec `yaml:"scanTerraformEnvVars"`
-	ScanCustomEnvVars    []string            `yaml:"scanCustomEnvVars"`
+	ScanAWSEnvVars       EnvVarsScanOptsSpec `yaml:"scanAWSEnvVars" description:"Scan the 'AWS_' environment variables of the host."`
+	ScanTerraformEnvVars EnvVarsScanOptsSpec `yaml:"scanTerraformEnvVars" description:"Scan the 'TF_' environment variables of the host."`
+	ScanCustomEnvVars    []string            `yaml:"scanCustomEnvVars" description:"Name of other environment variables to scan from the host."`
 }
 
 type EnvVarsScanOptsSpec struct {
-	Enabled               bool     `yaml:"enabled"`
-	FailIfNotSet          bool     `yaml:"failIfNotSet"`
-	IgnoreIfNotSetOrEmpty []string `yaml:"ignoreIfNotSetOrEmpty"`
-	RequiredEnvVars       []string `yaml:"requiredEnvVars"`
-	RemoveEnvVarsIfFound  []string `yaml:"removeEnvVarsIfFound"`
+	Enabled               bool     `yaml:"enabled" description:"Enable the scan."`
+	FailIfNotSet          bool     `yaml:"failIfNotSet" description:"Fail if a required environment variable is set, but empty."`
+	IgnoreIfNotSetOrEmpty []string `yaml:"ignoreIfNotSetOrEmpty" description:"Environment variables that are ignored if they're not set, or empty."`
+	RequiredEnvVars       []string `yaml:"requiredEnvVars" description:"Environment variables that should be set in the host."`
+	RemoveEnvVarsIfFound  []string `yaml:"removeEnvVarsIfFound" description:"Environment variables that are never passed to the container."`
 }
 
 type CommandsSpec struct {
-	Binary   string   `yaml:"binary"`
-	Commands []string `yaml:"commands"`
+	Binary           string     `yaml:"binary" description:"Binary prepended to each one of the commands. E.g.: 'terragrunt'."`
+	Commands         []string   `yaml:"commands" description:"Commands (or arguments of the binary) to run, without a shell."`
+	Run              string     `yaml:"run" description:"Script run by the shell of the task, as a single step that stops at the first failure. It supports pipes, '&&', redirects and variables."`
+	ScriptFile       string     `yaml:"scriptFile" description:"Script file, relative to the baseDir, copied into the container, and run by the shell of the task."`
+	If               string     `yaml:"if" description:"Expression that decides whether the commands run. E.g.: 'failure()'."`
+	Timeout          string     `yaml:"timeout" description:"Maximum duration of each attempt of the commands. E.g.: '90s'."`
+	Retry            *RetrySpec `yaml:"retry" description:"Retry each one of the commands, if it fails."`
+	ContinueOnError  bool       `yaml:"continueOnError" description:"Record the failures of the commands as warnings, and keep running the task."`
+	AllowedExitCodes []int      `yaml:"allowedExitCodes" description:"Non-zero exit codes recorded as warnings, instead of failures. E.g.: [2], for 'terraform plan -detailed-exitcode'."`
+	Isolated         bool       `yaml:"isolated" description:"Run the commands on the state left by the previous ones, but don't pass their changes (E.g.: the files they write) to the next ones."`
 }
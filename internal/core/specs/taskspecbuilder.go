@@ -3,11 +3,16 @@ package specs
 import (
 	"bytes"
 	"fmt"
+	"github.com/excoriate/stiletto/internal/core/commands"
 	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/core/expr"
 	"github.com/excoriate/stiletto/internal/core/job"
+	"github.com/excoriate/stiletto/internal/core/manifest"
+	"github.com/excoriate/stiletto/internal/core/scheduler"
 	"github.com/excoriate/stiletto/internal/core/validation"
 	"github.com/excoriate/stiletto/internal/errors"
 	"github.com/excoriate/stiletto/internal/utils"
+	"github.com/excoriate/stiletto/internal/version"
 	"github.com/excoriate/stiletto/internal/yamlparser"
 	"go.uber.org/zap"
 	"path/filepath"
@@ -18,7 +23,16 @@ type Builder struct {
 	manifestFile              string
 	manifestType              string
 	manifestFileBufferContent bytes.Buffer
-	taskManifestSpec          *TaskManifestSpec
+	manifestDocuments         []*manifestDocument
+	resolvedDocuments         []*yamlparser.Document
+	inputs                    map[string]interface{}
+	templateData              *TemplateData
+	diagnostics               []Diagnostic
+	failureDiagnostics        []Diagnostic
+	lenient                   bool
+	// sourceRoot is set if the manifest is loaded from a source (E.g.: a URL), so it isn't
+	// trusted. See manifest.TemplateOpts.Root.
+	sourceRoot string
 
 	// Cross-functional configuration as part of the builder pattern.
 	logger     *zap.Logger
@@ -28,19 +42,55 @@ type Builder struct {
 	baseDirAbs string
 }
 
+// manifestDocument is a manifest decoded out of one of the documents of the manifest file.
+type manifestDocument struct {
+	document *yamlparser.Document
+	spec     interface{}
+}
+
 type NewOpts struct {
 	ManifestType string
 	Client       *entities.Client
 	ManifestFile string
+	// Inputs are the values of the inputs declared in the manifest (see InputSpec).
+	Inputs map[string]interface{}
+	// Lenient makes the unknown fields of the manifest warnings, instead of errors.
+	Lenient bool
+	// Sources load the manifest files that aren't local (E.g.: 'https://...'). If nil,
+	// the default sources are used, cached in manifest.DefaultCacheDir.
+	Sources *manifest.Sources
 }
 
 type TaskFromManifestConverter interface {
 	Convert() (*ConvertedTask, error)
 }
 
+type JobFromManifestConverter interface {
+	Convert() (*ConvertedJob, error)
+}
+
+type WorkflowFromManifestConverter interface {
+	Convert() (*ConvertedWorkflow, error)
+}
+
 type ConvertedTask struct {
 	Task       *job.TaskNewArgs
 	TaskEnvCfg *job.EnvVarsOptions
+	// Labels are the labels of the task manifest, used to select the tasks to run.
+	Labels map[string]string
+}
+
+type ConvertedJob struct {
+	Job       *job.NewArgs
+	JobEnvCfg *job.EnvVarsOptions
+	Tasks     []ConvertedTask
+	// Labels are the labels of the job manifest, used to select the jobs to run.
+	Labels map[string]string
+}
+
+type ConvertedWorkflow struct {
+	Name string
+	Jobs []ConvertedJob
 }
 
 func (s *TaskManifestSpec) Convert() (*ConvertedTask, error) {
@@ -49,65 +99,166 @@ func (s *TaskManifestSpec) Convert() (*ConvertedTask, error) {
 			"Cannot convert a manifest to a Task that is empty or nil", nil)
 	}
 
+	envVarsOptions := convertEnvVarsSpec(s.Spec.EnvVarsSpec)
+
+	return &ConvertedTask{
+		Task:       convertTaskSpec(s.Metadata.Name, &s.Spec),
+		TaskEnvCfg: &envVarsOptions,
+		Labels:     s.Metadata.Labels,
+	}, nil
+}
+
+func (s *JobManifestSpec) Convert() (*ConvertedJob, error) {
+	if s == nil {
+		return nil, errors.NewManifestError(
+			"Cannot convert a manifest to a Job that is empty or nil", nil)
+	}
+
+	convertedJob := convertJobSpec(job.NewArgs{Name: s.Metadata.Name}, &s.Spec)
+	convertedJob.Labels = s.Metadata.Labels
+
+	return convertedJob, nil
+}
+
+func (s *WorkflowManifestSpec) Convert() (*ConvertedWorkflow, error) {
+	if s == nil {
+		return nil, errors.NewManifestError(
+			"Cannot convert a manifest to a Workflow that is empty or nil", nil)
+	}
+
+	var jobs []ConvertedJob
+	for _, workflowJob := range s.Spec.Jobs {
+		if workflowJob == nil {
+			continue
+		}
+
+		jobs = append(jobs, *convertJobSpec(job.NewArgs{
+			Name:  workflowJob.Name,
+			Needs: workflowJob.Needs,
+		}, &workflowJob.JobSpec))
+	}
+
+	return &ConvertedWorkflow{
+		Name: s.Metadata.Name,
+		Jobs: jobs,
+	}, nil
+}
+
+// convertJobSpec converts a job spec, and each one of its tasks.
+func convertJobSpec(args job.NewArgs, spec *JobSpec) *ConvertedJob {
+	var tasks []ConvertedTask
+	for _, task := range spec.Tasks {
+		if task == nil {
+			continue
+		}
+
+		envVarsOptions := convertEnvVarsSpec(task.EnvVarsSpec)
+		envVarsOptions.InheritEnvVarsFromJob = task.InheritEnvVarsFromJob
+
+		tasks = append(tasks, ConvertedTask{
+			Task:       convertTaskSpec(task.Name, &task.TaskSpec),
+			TaskEnvCfg: &envVarsOptions,
+		})
+	}
+
+	jobEnvVarsOptions := convertEnvVarsSpec(spec.EnvVarsSpec)
+	args.Timeout = parseTimeout(spec.Timeout)
+
+	return &ConvertedJob{
+		Job:       &args,
+		JobEnvCfg: &jobEnvVarsOptions,
+		Tasks:     tasks,
+	}
+}
+
+// convertTaskSpec converts a task spec into the arguments required by the job builder.
+// Each command declared in a 'commandsSpec' entry becomes its own command.
+func convertTaskSpec(name string, spec *TaskSpec) *job.TaskNewArgs {
 	var taskCommandArgs []job.TaskNewCMDArgs
-	for _, command := range s.Spec.CommandsSpec {
-		var newCmd = job.TaskNewCMDArgs{}
-		if command.Binary != "" {
-			newCmd.Binary = command.Binary
+	for _, command := range spec.CommandsSpec {
+		if command.Run != "" || command.ScriptFile != "" {
+			taskCommandArgs = append(taskCommandArgs, job.TaskNewCMDArgs{
+				Script:           command.Run,
+				ScriptFile:       command.ScriptFile,
+				Shell:            spec.Shell,
+				Condition:        command.If,
+				Timeout:          parseTimeout(command.Timeout),
+				Retry:            convertRetrySpec(command.Retry),
+				ContinueOnError:  command.ContinueOnError,
+				AllowedExitCodes: command.AllowedExitCodes,
+				Isolated:         command.Isolated,
+			})
+
+			continue
 		}
 
 		for _, cmd := range command.Commands {
-			newCmd.CommandArgs = cmd
+			taskCommandArgs = append(taskCommandArgs, job.TaskNewCMDArgs{
+				Binary:           command.Binary,
+				CommandArgs:      cmd,
+				Condition:        command.If,
+				Timeout:          parseTimeout(command.Timeout),
+				Retry:            convertRetrySpec(command.Retry),
+				ContinueOnError:  command.ContinueOnError,
+				AllowedExitCodes: command.AllowedExitCodes,
+				Isolated:         command.Isolated,
+			})
 		}
+	}
 
-		taskCommandArgs = append(taskCommandArgs, newCmd)
+	return &job.TaskNewArgs{
+		Name:           name,
+		ContainerImage: spec.ContainerImage,
+		WorkDir:        spec.Workdir,
+		MountDir:       spec.MountDir,
+		BaseDir:        spec.BaseDir,
+		Commands:       taskCommandArgs,
+		Matrix:         convertMatrixSpec(spec.Strategy.Matrix),
+		Condition:      spec.If,
+		Inputs:         spec.InputValues,
+		Timeout:        parseTimeout(spec.Timeout),
+		Retry:          convertRetrySpec(spec.Retry),
 	}
+}
 
+// convertEnvVarsSpec converts the env vars spec into the options used to decorate
+// a job, or a task with env vars.
+func convertEnvVarsSpec(spec EnvVarsSpec) job.EnvVarsOptions {
 	var envVarsOptions job.EnvVarsOptions
 
-	if s.Spec.EnvVarsSpec.EnvVarsScanned.ScanTerraformEnvVars.Enabled {
+	if spec.EnvVarsScanned.ScanTerraformEnvVars.Enabled {
 		envVarsOptions.EnvVarsTerraformCfg = job.EnvVarBehaviourOptions{
 			Enabled:               true,
-			FailIfNotSet:          s.Spec.EnvVarsSpec.EnvVarsScanned.ScanTerraformEnvVars.FailIfNotSet,
-			RequiredEnvVars:       s.Spec.EnvVarsSpec.EnvVarsScanned.ScanTerraformEnvVars.RequiredEnvVars,
-			IgnoreIfNotSetOrEmpty: s.Spec.EnvVarsSpec.EnvVarsScanned.ScanTerraformEnvVars.IgnoreIfNotSetOrEmpty,
-			RemoveEnvVarsIfFound:  s.Spec.EnvVarsSpec.EnvVarsScanned.ScanTerraformEnvVars.RemoveEnvVarsIfFound,
+			FailIfNotSet:          spec.EnvVarsScanned.ScanTerraformEnvVars.FailIfNotSet,
+			RequiredEnvVars:       spec.EnvVarsScanned.ScanTerraformEnvVars.RequiredEnvVars,
+			IgnoreIfNotSetOrEmpty: spec.EnvVarsScanned.ScanTerraformEnvVars.IgnoreIfNotSetOrEmpty,
+			RemoveEnvVarsIfFound:  spec.EnvVarsScanned.ScanTerraformEnvVars.RemoveEnvVarsIfFound,
 		}
 	}
 
-	if s.Spec.EnvVarsSpec.EnvVarsScanned.ScanAWSEnvVars.Enabled {
+	if spec.EnvVarsScanned.ScanAWSEnvVars.Enabled {
 		envVarsOptions.EnvVarsAWSCfg = job.EnvVarBehaviourOptions{
 			Enabled:               true,
-			FailIfNotSet:          s.Spec.EnvVarsSpec.EnvVarsScanned.ScanAWSEnvVars.FailIfNotSet,
-			RequiredEnvVars:       s.Spec.EnvVarsSpec.EnvVarsScanned.ScanAWSEnvVars.RequiredEnvVars,
-			IgnoreIfNotSetOrEmpty: s.Spec.EnvVarsSpec.EnvVarsScanned.ScanAWSEnvVars.IgnoreIfNotSetOrEmpty,
-			RemoveEnvVarsIfFound:  s.Spec.EnvVarsSpec.EnvVarsScanned.ScanAWSEnvVars.RemoveEnvVarsIfFound,
+			FailIfNotSet:          spec.EnvVarsScanned.ScanAWSEnvVars.FailIfNotSet,
+			RequiredEnvVars:       spec.EnvVarsScanned.ScanAWSEnvVars.RequiredEnvVars,
+			IgnoreIfNotSetOrEmpty: spec.EnvVarsScanned.ScanAWSEnvVars.IgnoreIfNotSetOrEmpty,
+			RemoveEnvVarsIfFound:  spec.EnvVarsScanned.ScanAWSEnvVars.RemoveEnvVarsIfFound,
 		}
 	}
 
-	if !utils.MapIsNulOrEmpty(s.Spec.EnvVarsSpec.EnvVars) {
-		envVarsOptions.EnvVarsExplicit = s.Spec.EnvVarsSpec.EnvVars
+	if !utils.MapIsNulOrEmpty(spec.EnvVars) {
+		envVarsOptions.EnvVarsExplicit = spec.EnvVars
 	}
 
-	if len(s.Spec.EnvVarsSpec.DotFiles) != 0 {
+	if len(spec.DotFiles) != 0 {
 		envVarsOptions.EnvVarsFromDotFileCfg = job.EnvVarBehaviourOptions{
 			Enabled:      true,
 			FailIfNotSet: true,
-			DotFiles:     s.Spec.EnvVarsSpec.DotFiles,
+			DotFiles:     spec.DotFiles,
 		}
 	}
 
-	return &ConvertedTask{
-		Task: &job.TaskNewArgs{
-			Name:           s.Metadata.Name,
-			ContainerImage: s.Spec.ContainerImage,
-			WorkDir:        s.Spec.Workdir,
-			MountDir:       s.Spec.MountDir,
-			BaseDir:        s.Spec.BaseDir,
-			Commands:       taskCommandArgs,
-		},
-		TaskEnvCfg: &envVarsOptions,
-	}, nil
+	return envVarsOptions
 }
 
 // WithExtractedManifestContent adds the manifest content to the builder.
@@ -149,44 +300,68 @@ func (b *Builder) WithCompiledManifestFunctions() *Builder {
 		return b
 	}
 
-	funcMapsCfg := entities.TmplCfgFuncMaps
+	if diagnostics := validateMatrixReferences(b.manifestFile, b.manifestFileBufferContent.String()); len(
+		diagnostics) != 0 {
+		var problems []string
+		for _, diagnostic := range diagnostics {
+			problems = append(problems, diagnostic.String())
+		}
 
-	for key, cfg := range funcMapsCfg {
-		var tempManifestContent string
-		tempManifestContent = b.manifestFileBufferContent.String()
+		errMsg := fmt.Sprintf("Cannot compile manifest template functions. The manifest %s is invalid:\n%s",
+			b.manifestFile, strings.Join(problems, "\n"))
+		b.logger.Error(errMsg)
+		b.err = errors.NewArgumentError(errMsg, nil)
+		b.failureDiagnostics = diagnostics
 
-		if strings.Contains(tempManifestContent, key) {
-			compilationOpts := utils.TemplateCompilationOpts{
-				TemplateContent: tempManifestContent,
-				Data:            &TaskManifestSpec{},
-				TemplateName:    "taskManifest",
-				FuncMap:         cfg,
-			}
+		return b
+	}
 
-			compiledTpl, err := utils.CompileTemplate(compilationOpts)
+	compiledManifestContent, err := compileManifestFunctions(b.manifestFile, b.manifestFileBufferContent.String(),
+		b.getTemplateData())
+	if err != nil {
+		errMsg := fmt.Sprintf("Cannot compile manifest template functions. Cannot compile template: %s", err)
+		b.logger.Error(errMsg)
+		b.err = errors.NewArgumentError(errMsg, err)
 
-			if err != nil {
-				errMsg := fmt.Sprintf("Cannot compile manifest template functions. Cannot compile template: %s", err)
-				b.logger.Error(errMsg)
-				b.err = errors.NewArgumentError(errMsg, err)
+		return b
+	}
 
-				return b
-			}
+	b.manifestFileBufferContent = bytes.Buffer{}
+	b.manifestFileBufferContent.WriteString(compiledManifestContent)
 
-			compiledManifestFileContent := compiledTpl.String()
-			manifestCompiledTemp := bytes.Buffer{}
-			manifestCompiledTemp.WriteString(compiledManifestFileContent)
+	b.logger.Info("manifest template functions compiled successfully")
 
-			b.manifestFileBufferContent = manifestCompiledTemp
-		}
+	return b
+}
+
+// getTemplateData returns the data available in the templates of the manifest. If the
+// inputs weren't resolved (see WithResolvedInputs), there are none.
+func (b *Builder) getTemplateData() *TemplateData {
+	if b.templateData == nil {
+		b.templateData = b.newTemplateData()
 	}
 
-	b.logger.Info("manifest template functions compiled successfully")
+	return b.templateData
+}
 
-	return b
+// compileManifestFunctions compiles the 'Stiletto' template functions (see
+// manifest.Functions), and the inputs, used in the content of a manifest file, in a
+// single pass. The paths used in the templates are relative to the manifest file.
+func compileManifestFunctions(manifestFile, manifestContent string, data *TemplateData) (string, error) {
+	templateData := *data
+	templateData.Matrix = matrixPlaceholders(manifestContent)
+
+	return manifest.Render(manifestContent, manifest.TemplateOpts{
+		Name: filepath.Base(manifestFile),
+		Dir:  filepath.Dir(manifestFile),
+		Root: data.sourceRoot,
+		Data: &templateData,
+	})
 }
 
-// WithConstructedSpec adds the manifest spec to the builder.
+// WithConstructedSpec adds the manifest spec to the builder. Each document of the
+// manifest file becomes its own manifest. If the manifests extended were resolved
+// (see WithResolvedInheritance), the resolved documents are used.
 func (b *Builder) WithConstructedSpec() *Builder {
 	if b.manifestFileBufferContent.String() == "" {
 		errMsg := "Cannot construct manifest spec. " +
@@ -198,24 +373,117 @@ func (b *Builder) WithConstructedSpec() *Builder {
 		return b
 	}
 
-	taskManifestSpec := &TaskManifestSpec{}
-	if err := yamlparser.YamlToStructWithContent(b.manifestFileBufferContent.String(), taskManifestSpec); err != nil {
-		errMsg := fmt.Sprintf("Cannot construct manifest spec. Cannot parse yaml file %s", b.manifestFile)
+	documents := b.resolvedDocuments
+	if documents == nil {
+		var err error
+		documents, err = yamlparser.DocumentsFromContent(b.manifestFileBufferContent.String())
+		if err != nil {
+			errMsg := fmt.Sprintf("Cannot construct manifest spec. Cannot parse yaml file %s", b.manifestFile)
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewArgumentError(errMsg, err)
-		return b
+			b.logger.Error(errMsg)
+			b.err = errors.NewArgumentError(errMsg, err)
+			b.failureDiagnostics = diagnosticsFromYAMLError(b.manifestFile, 0, err)
+			return b
+		}
+	}
+
+	var manifestDocuments []*manifestDocument
+	for _, document := range documents {
+		manifestSpec, warnings, err := b.decodeDocument(document)
+		if err != nil {
+			errMsg := fmt.Sprintf("Cannot construct manifest spec. Cannot parse yaml file %s", b.manifestFile)
+
+			b.logger.Error(errMsg)
+			b.err = errors.NewArgumentError(errMsg, err)
+			b.failureDiagnostics = diagnosticsFromYAMLError(b.manifestFile, document.Line, err)
+			return b
+		}
+
+		for _, warning := range warnings {
+			b.logger.Warn(warning.String())
+		}
+
+		b.diagnostics = append(b.diagnostics, warnings...)
+
+		inputValues := b.getTemplateData().Inputs
+
+		switch spec := manifestSpec.(type) {
+		case *TaskManifestSpec:
+			spec.Spec.InputValues = inputValues
+		case *JobManifestSpec:
+			spec.Spec.applyDefaults()
+			spec.Spec.setInputValues(inputValues)
+		case *WorkflowManifestSpec:
+			for _, workflowJob := range spec.Spec.Jobs {
+				if workflowJob != nil {
+					workflowJob.applyDefaults()
+					workflowJob.setInputValues(inputValues)
+				}
+			}
+		}
+
+		manifestDocuments = append(manifestDocuments, &manifestDocument{
+			document: document,
+			spec:     manifestSpec,
+		})
 	}
 
-	b.taskManifestSpec = taskManifestSpec
-	b.logger.Info("task manifest added to the builder")
+	b.manifestDocuments = manifestDocuments
+	b.logger.Info(fmt.Sprintf("%d manifest(s) of type '%s' added to the builder",
+		len(manifestDocuments), b.manifestType))
 
 	return b
 }
 
+// decodeDocument decodes a document of the manifest file into the spec of the
+// manifest type. The manifests declared with a previous version of the spec are
+// converted into the latest one first, and a warning is returned for every
+// deprecated field found.
+func (b *Builder) decodeDocument(document *yamlparser.Document) (interface{}, []Diagnostic, error) {
+	var header struct {
+		APIVersion string `yaml:"apiVersion"`
+	}
+
+	// A document that can't be decoded is reported once its whole spec is decoded.
+	_ = document.Decode(&header)
+
+	var warnings []Diagnostic
+	if apiVersionIndex(header.APIVersion) != -1 && header.APIVersion != LatestAPIVersion {
+		changes, err := ConvertDocument(document, entities.ManifestKindByType[b.manifestType],
+			header.APIVersion, LatestAPIVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, change := range changes {
+			file := b.manifestFile
+			if change.File != "" {
+				file = change.File
+			}
+
+			warnings = append(warnings, Diagnostic{
+				File:     file,
+				Line:     change.Line,
+				Column:   change.Column,
+				Path:     change.Path,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("'%s' is deprecated since apiVersion '%s', use '%s' instead. "+
+					"Run 'stiletto manifest migrate' to upgrade the manifest", change.From, change.Since, change.To),
+			})
+		}
+	}
+
+	manifestSpec := b.newManifestSpec()
+	if err := document.Decode(manifestSpec); err != nil {
+		return nil, nil, err
+	}
+
+	return manifestSpec, warnings, nil
+}
+
 // WithCompiledManifestStructure WithTaskManifests WithJobManifests adds job manifests to the builder.
 func (b *Builder) WithCompiledManifestStructure() *Builder {
-	if b.manifestType != entities.ManifestTypeTask {
+	if _, ok := entities.ManifestKindByType[b.manifestType]; !ok {
 		errMsg := fmt.Sprintf("Cannot compile manifest structure. Invalid manifest type: %s", b.manifestType)
 		b.logger.Error(errMsg)
 		b.err = errors.NewArgumentError(errMsg, nil)
@@ -223,155 +491,499 @@ func (b *Builder) WithCompiledManifestStructure() *Builder {
 		return b
 	}
 
-	if err := yamlparser.YamlStructureIsValid(b.manifestFile, &TaskManifestSpec{}); err != nil {
+	documents, err := yamlparser.DocumentsFromFile(b.manifestFile)
+	if err != nil {
 		errMsg := fmt.Sprintf("Cannot compile manifest structure. Invalid manifest structure: %s", err)
 
 		b.logger.Error(errMsg)
 		b.err = errors.NewArgumentError(errMsg, err)
+		b.failureDiagnostics = diagnosticsFromYAMLError(b.manifestFile, 0, err)
 		return b
 	}
 
-	taskManifestSpec := &TaskManifestSpec{}
+	for _, document := range documents {
+		if _, _, err := b.decodeDocument(document); err != nil {
+			errMsg := fmt.Sprintf("Cannot compile manifest structure. Cannot parse yaml file %s", b.manifestFile)
 
-	if err := yamlparser.YamlToStructFromFile(b.manifestFile,
-		taskManifestSpec); err != nil {
-		errMsg := fmt.Sprintf("Cannot compile manifest structure. Cannot parse yaml file %s", b.manifestFile)
-
-		b.logger.Error(errMsg)
-		b.err = errors.NewArgumentError(errMsg, err)
-		return b
+			b.logger.Error(errMsg)
+			b.err = errors.NewArgumentError(errMsg, err)
+			b.failureDiagnostics = diagnosticsFromYAMLError(b.manifestFile, document.Line, err)
+			return b
+		}
 	}
 
-	b.logger.Info("task manifest added to the builder")
+	b.logger.Info("manifest structure compiled successfully")
 
 	return b
 }
 
-// WithStrictDeepValidation adds strict deep validation to the builder.
+// WithStrictDeepValidation adds strict deep validation to the builder. Each document
+// of the manifest file is validated on its own, and every problem found is collected
+// as a diagnostic (see Diagnostics), instead of stopping at the first one. The fields
+// that the spec doesn't declare are errors too, unless the builder is lenient.
 func (b *Builder) WithStrictDeepValidation() *Builder {
-	specContent := b.taskManifestSpec
-	if specContent == nil {
-		errMsg := "task manifest is required prior to this API execution. " +
-			"Ensure that you've called the WithCompiledManifestStructure method"
+	if len(b.manifestDocuments) == 0 {
+		errMsg := "manifest is required prior to this API execution. " +
+			"Ensure that you've called the WithConstructedSpec method"
 
 		b.logger.Error(errMsg)
 		b.err = errors.NewManifestError(errMsg, nil)
 		return b
 	}
 
-	if specContent.Kind != "Task" && specContent.Kind != "Job" && specContent.
-		Kind != "Workflow" {
-		errMsg := fmt.Sprintf("invalid manifest kind: %s. Should be 'Job', 'Task' or 'Workflow'",
-			specContent.Kind)
+	for _, manifestDoc := range b.manifestDocuments {
+		v := &manifestValidator{file: b.manifestFile, document: manifestDoc.document}
+		b.validateKnownFields(v)
+
+		switch spec := manifestDoc.spec.(type) {
+		case *JobManifestSpec:
+			b.validateJobManifest(v, spec)
+		case *WorkflowManifestSpec:
+			b.validateWorkflowManifest(v, spec)
+		case *TaskManifestSpec:
+			b.validateTaskManifest(v, spec)
+		}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+		b.diagnostics = append(b.diagnostics, v.diagnostics...)
 	}
 
-	if specContent.APIVersion != "v1" {
-		errMsg := fmt.Sprintf("invalid manifest api version: %s. Should be 'v1'", specContent.APIVersion)
+	if HasErrors(b.diagnostics) {
+		var problems []string
+		for _, diagnostic := range b.diagnostics {
+			problems = append(problems, diagnostic.String())
+		}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+		b.err = errors.NewManifestError(fmt.Sprintf("The manifest %s is invalid:\n%s",
+			b.manifestFile, strings.Join(problems, "\n")), nil)
+		b.logger.Error(b.err.Error())
 	}
 
-	if specContent.Metadata.Name == "" {
-		errMsg := "manifest name is required. Give it a proper name. E.g.: 'my-task'"
+	return b
+}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+// Diagnostics returns the problems found in the manifest file. If the manifest
+// failed before its validation (E.g.: it's not a valid yaml), the problem that
+// stopped it is returned.
+func (b *Builder) Diagnostics() []Diagnostic {
+	if b.err != nil && !HasErrors(b.diagnostics) {
+		if len(b.failureDiagnostics) != 0 {
+			return b.failureDiagnostics
+		}
+
+		return []Diagnostic{{
+			File:     b.manifestFile,
+			Severity: SeverityError,
+			Message:  b.err.Error(),
+		}}
 	}
 
-	if specContent.Spec.ContainerImage == "" {
-		errMsg := "container image is required. " +
-			"It's required to bootstrap a container for the 'Dagger' runtime."
+	return b.diagnostics
+}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+func (b *Builder) validateTaskManifest(v *manifestValidator, specContent *TaskManifestSpec) {
+	b.validateManifestHeader(v, specContent.Kind, specContent.APIVersion, specContent.Metadata.Name,
+		specContent.Metadata.MinStilettoVersion)
+	validateLabels(v, specContent.Metadata.Labels, specContent.Metadata.Annotations)
+	b.validateTaskSpec(v, "spec", &specContent.Spec)
+}
+
+func (b *Builder) validateJobManifest(v *manifestValidator, specContent *JobManifestSpec) {
+	b.validateManifestHeader(v, specContent.Kind, specContent.APIVersion, specContent.Metadata.Name,
+		specContent.Metadata.MinStilettoVersion)
+	validateLabels(v, specContent.Metadata.Labels, specContent.Metadata.Annotations)
+	b.validateJobSpec(v, "spec", specContent.Metadata.Name, &specContent.Spec)
+}
+
+func (b *Builder) validateWorkflowManifest(v *manifestValidator, specContent *WorkflowManifestSpec) {
+	b.validateManifestHeader(v, specContent.Kind, specContent.APIVersion, specContent.Metadata.Name,
+		specContent.Metadata.MinStilettoVersion)
+	validateLabels(v, specContent.Metadata.Labels, specContent.Metadata.Annotations)
+
+	if len(specContent.Spec.Jobs) == 0 {
+		v.error("spec.jobs", "The workflow '%s' has no jobs. It should declare at least one job",
+			specContent.Metadata.Name)
+		return
+	}
+
+	jobNames := map[string]bool{}
+	for idx, workflowJob := range specContent.Spec.Jobs {
+		jobPath := fmt.Sprintf("spec.jobs[%d]", idx)
+
+		if workflowJob == nil || workflowJob.Name == "" {
+			v.error(jobPath, "The job in position %d of the workflow '%s' has no name. "+
+				"Give it a proper name. E.g.: 'my-job'", idx, specContent.Metadata.Name)
+			continue
+		}
+
+		if jobNames[workflowJob.Name] {
+			v.error(joinPath(jobPath, "name"), "The job '%s' is declared more than once in the workflow '%s'",
+				workflowJob.Name, specContent.Metadata.Name)
+		}
+
+		jobNames[workflowJob.Name] = true
+
+		if len(workflowJob.Inputs) != 0 {
+			v.error(joinPath(jobPath, "inputs"), "The inputs can only be declared in the spec of the manifest")
+		}
+
+		b.validateJobSpec(v, jobPath, workflowJob.Name, &workflowJob.JobSpec)
+	}
+
+	needsAreValid := true
+	for idx, workflowJob := range specContent.Spec.Jobs {
+		if workflowJob == nil {
+			continue
+		}
+
+		for needIdx, need := range workflowJob.Needs {
+			needPath := fmt.Sprintf("spec.jobs[%d].needs[%d]", idx, needIdx)
+
+			if need == workflowJob.Name {
+				needsAreValid = false
+				v.error(needPath, "The job '%s' can't need itself", workflowJob.Name)
+			} else if !jobNames[need] {
+				needsAreValid = false
+				v.error(needPath, "The job '%s' needs the job '%s', which isn't declared in the workflow",
+					workflowJob.Name, need)
+			}
+		}
+	}
+
+	if !needsAreValid || len(jobNames) != len(specContent.Spec.Jobs) {
+		return
+	}
+
+	var jobs []entities.Job
+	for _, workflowJob := range specContent.Spec.Jobs {
+		jobs = append(jobs, entities.Job{Name: workflowJob.Name, Needs: workflowJob.Needs})
+	}
+
+	dag, err := scheduler.NewDAG(jobs)
+	if err == nil {
+		_, err = dag.TopologicalOrder()
+	}
+
+	if err != nil {
+		v.error("spec.jobs", "%s", err)
 	}
+}
+
+// validateJobSpec validates the spec of a job, no matter if it's declared in a job, or in a workflow manifest.
+func (b *Builder) validateJobSpec(v *manifestValidator, path, jobName string, spec *JobSpec) {
+	validateTimeout(v, joinPath(path, "timeout"), spec.Timeout)
+
+	if len(spec.Tasks) == 0 {
+		v.error(joinPath(path, "tasks"), "The job '%s' has no tasks. It should declare at least one task",
+			jobName)
+		return
+	}
+
+	taskNames := map[string]bool{}
+	for idx, task := range spec.Tasks {
+		taskPath := joinPath(path, fmt.Sprintf("tasks[%d]", idx))
+
+		if task == nil || task.Name == "" {
+			v.error(taskPath, "The task in position %d of the job '%s' has no name. "+
+				"Give it a proper name. E.g.: 'my-task'", idx, jobName)
+			continue
+		}
+
+		if taskNames[task.Name] {
+			v.error(joinPath(taskPath, "name"), "The task '%s' is declared more than once in the job '%s'",
+				task.Name, jobName)
+		}
 
-	if specContent.Spec.BaseDir == "" {
+		taskNames[task.Name] = true
+
+		if len(task.Inputs) != 0 {
+			v.error(joinPath(taskPath, "inputs"), "The inputs can only be declared in the spec of the manifest")
+		}
+
+		b.validateTaskSpec(v, taskPath, &task.TaskSpec)
+	}
+}
+
+// validateManifestHeader validates the fields that are common to every manifest kind.
+func (b *Builder) validateManifestHeader(v *manifestValidator, kind, apiVersion, name, minStilettoVersion string) {
+	if kind != entities.ManifestKindTask && kind != entities.ManifestKindJob && kind != entities.
+		ManifestKindWorkflow {
+		v.error("kind", "invalid manifest kind: %s. Should be 'Job', 'Task' or 'Workflow'", kind)
+	} else if expectedKind := entities.ManifestKindByType[b.manifestType]; kind != expectedKind {
+		v.error("kind", "invalid manifest kind: %s. The manifest %s was loaded as a '%s' manifest",
+			kind, b.manifestFile, expectedKind)
+	}
+
+	if apiVersionIndex(apiVersion) == -1 {
+		v.error("apiVersion", "invalid manifest api version: %s. Should be one of: %s", apiVersion,
+			strings.Join(SupportedAPIVersions(), ", "))
+	}
+
+	if name == "" {
+		v.error("metadata.name", "manifest name is required. Give it a proper name. E.g.: 'my-task'")
+	}
+
+	if minStilettoVersion == "" {
+		return
+	}
+
+	// The version is always validated, but the dev builds (which aren't a released
+	// version) can run any manifest.
+	if err := version.Validate(minStilettoVersion); err != nil {
+		v.error("metadata.minStilettoVersion", "%s", err)
+	} else if !version.IsDev() {
+		comparison, err := version.Compare(version.Version, minStilettoVersion)
+		if err != nil {
+			v.error("metadata.minStilettoVersion", "%s", err)
+		} else if comparison < 0 {
+			v.error("metadata.minStilettoVersion", "The manifest requires Stiletto %s or newer, "+
+				"but the current version is %s. Upgrade Stiletto to run it", minStilettoVersion, version.Version)
+		}
+	}
+}
+
+// validateTaskSpec validates the spec of a task, no matter if it's declared in a task, or in a job manifest.
+func (b *Builder) validateTaskSpec(v *manifestValidator, path string, spec *TaskSpec) {
+	if spec.ContainerImage == "" {
+		v.error(joinPath(path, "containerImage"), "container image is required. "+
+			"It's required to bootstrap a container for the 'Dagger' runtime.")
+	}
+
+	if spec.BaseDir == "" {
 		b.logger.Info("The 'baseDir' in the task manifest isn't set, " +
 			"so it'll be resolved to the current directory")
 
-		specContent.Spec.BaseDir = b.baseDirAbs
+		spec.BaseDir = b.baseDirAbs
 	}
 
-	if specContent.Spec.Workdir == "" {
-		errMsg := "workDir is required. "
+	if spec.Workdir == "" {
+		v.error(joinPath(path, "workDir"), "workDir is required.")
+	}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+	if spec.MountDir == "" {
+		v.error(joinPath(path, "mountDir"), "mountDir is required.")
 	}
 
-	if specContent.Spec.MountDir == "" {
-		errMsg := "mountDir is required. "
+	// The directories that use the matrix variables are validated once the matrix is expanded.
+	if spec.Workdir != "" && spec.MountDir != "" && !usesMatrixVariables(spec.Workdir) &&
+		!usesMatrixVariables(spec.MountDir) {
+		if err := validation.WorkDirIsValid(validation.WorkDirIsValidArgs{
+			BaseDir:  spec.BaseDir,
+			WorkDir:  spec.Workdir,
+			MountDir: spec.MountDir,
+		}); err != nil {
+			v.error(joinPath(path, "workDir"), "The manifest directory configuration is invalid: %s", err)
+		}
+	}
 
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
-		return b
+	if spec.Strategy.Matrix != nil {
+		validateMatrixSpec(v, joinPath(path, "strategy.matrix"), spec.Strategy.Matrix)
 	}
 
-	if err := validation.WorkDirIsValid(validation.WorkDirIsValidArgs{
-		BaseDir:  specContent.Spec.BaseDir,
-		WorkDir:  specContent.Spec.Workdir,
-		MountDir: specContent.Spec.MountDir,
-	}); err != nil {
-		errMsg := fmt.Sprintf("The manifest directory configuration is invalid: %s", err)
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
+	validateCondition(v, joinPath(path, "if"), spec.If)
+	validateTimeout(v, joinPath(path, "timeout"), spec.Timeout)
+	validateRetrySpec(v, joinPath(path, "retry"), spec.Retry)
 
-		return b
+	if len(spec.CommandsSpec) == 0 {
+		v.error(joinPath(path, "commandsSpec"), "The manifest commands are invalid. "+
+			"They should have at least one command")
 	}
 
-	if len(specContent.Spec.CommandsSpec) == 0 {
-		errMsg := "The manifest commands are invalid. They should have at least one command"
-		b.logger.Error(errMsg)
-		b.err = errors.NewManifestError(errMsg, nil)
+	if spec.Shell != "" && spec.Shell != commands.ShellSh && spec.Shell != commands.ShellBash {
+		v.error(joinPath(path, "shell"), "invalid shell: %s. Should be '%s' or '%s'", spec.Shell,
+			commands.ShellSh, commands.ShellBash)
+	}
 
-		return b
+	for idx, cmd := range spec.CommandsSpec {
+		cmdPath := joinPath(path, fmt.Sprintf("commandsSpec[%d]", idx))
+		if cmd == nil || len(cmd.Commands) == 0 && cmd.Run == "" && cmd.ScriptFile == "" {
+			v.error(cmdPath, "The manifest commands are invalid. "+
+				"It was detected a configuration, but without any command to execute")
+		}
+
+		if cmd != nil {
+			b.validateScriptStep(v, cmdPath, spec.BaseDir, cmd)
+			validateCondition(v, joinPath(cmdPath, "if"), cmd.If)
+			validateTimeout(v, joinPath(cmdPath, "timeout"), cmd.Timeout)
+			validateRetrySpec(v, joinPath(cmdPath, "retry"), cmd.Retry)
+
+			for codeIdx, code := range cmd.AllowedExitCodes {
+				if code < 0 || code > 255 {
+					v.error(joinPath(cmdPath, fmt.Sprintf("allowedExitCodes[%d]", codeIdx)),
+						"The allowed exit code %d is invalid. It should be between 0 and 255", code)
+				}
+			}
+		}
 	}
+}
 
-	for _, cmd := range specContent.Spec.CommandsSpec {
-		if len(cmd.Commands) == 0 {
-			errMsg := "The manifest commands are invalid. It was detected a configuration, " +
-				"but without any command to execute"
-			b.logger.Error(errMsg)
-			b.err = errors.NewManifestError(errMsg, nil)
-			return b
+// validateScriptStep validates the 'run' and 'scriptFile' steps. Each entry of the
+// commandsSpec is either a list of commands, a script, or a script file.
+func (b *Builder) validateScriptStep(v *manifestValidator, path, baseDir string, cmd *CommandsSpec) {
+	steps := 0
+	for _, declared := range []bool{len(cmd.Commands) != 0, cmd.Run != "", cmd.ScriptFile != ""} {
+		if declared {
+			steps++
 		}
 	}
 
-	return b
+	if steps > 1 {
+		v.error(path, "The commands, run and scriptFile fields can't be combined. "+
+			"Split them into their own commandsSpec entries")
+	}
+
+	if cmd.Binary != "" && (cmd.Run != "" || cmd.ScriptFile != "") {
+		v.error(joinPath(path, "binary"), "The binary can only be used along with commands. "+
+			"Call it in the script instead")
+	}
+
+	if cmd.ScriptFile == "" || usesMatrixVariables(cmd.ScriptFile) {
+		return
+	}
+
+	if filepath.IsAbs(cmd.ScriptFile) {
+		v.error(joinPath(path, "scriptFile"), "The scriptFile '%s' should be relative to the baseDir",
+			cmd.ScriptFile)
+		return
+	}
+
+	if err := utils.FileExistAndItIsAFile(filepath.Join(baseDir, cmd.ScriptFile)); err != nil {
+		v.error(joinPath(path, "scriptFile"), "The scriptFile '%s' can't be found in %s: %s",
+			cmd.ScriptFile, baseDir, err)
+	}
 }
 
-// Build builds the manifest.
+// validateCondition validates the syntax of an 'if' expression. See the expr package.
+func validateCondition(v *manifestValidator, path, condition string) {
+	if strings.TrimSpace(condition) == "" {
+		return
+	}
+
+	if _, err := expr.Parse(condition); err != nil {
+		v.error(path, "The 'if' condition is invalid: %s", err)
+	}
+}
+
+// newManifestSpec returns an empty spec, of the type that corresponds to the manifest type.
+func (b *Builder) newManifestSpec() interface{} {
+	switch b.manifestType {
+	case entities.ManifestTypeJob:
+		return &JobManifestSpec{}
+	case entities.ManifestTypeWorkflow:
+		return &WorkflowManifestSpec{}
+	default:
+		return &TaskManifestSpec{}
+	}
+}
+
+// DetectManifestType returns the manifest type that corresponds to the kind declared
+// in the first document of the manifest file.
+func DetectManifestType(manifestFile string) (string, error) {
+	documents, err := yamlparser.DocumentsFromFile(manifestFile)
+	if err != nil {
+		return "", errors.NewManifestError(fmt.Sprintf("Cannot detect the kind of the manifest %s",
+			manifestFile), err)
+	}
+
+	var header struct {
+		Kind string `yaml:"kind"`
+	}
+
+	if err := documents[0].Decode(&header); err != nil {
+		return "", errors.NewManifestError(fmt.Sprintf("Cannot detect the kind of the manifest %s",
+			manifestFile), err)
+	}
+
+	for manifestType, kind := range entities.ManifestKindByType {
+		if kind == header.Kind {
+			return manifestType, nil
+		}
+	}
+
+	return "", errors.NewManifestError(fmt.Sprintf("Cannot detect the kind of the manifest %s. "+
+		"Invalid manifest kind: '%s'. Should be 'Job', 'Task' or 'Workflow'", manifestFile, header.Kind), nil)
+}
+
+// Build builds the manifest. It fails if the manifest file has more than one
+// document; in that case, use BuildTasks instead.
 func (b *Builder) Build() (*TaskManifestSpec, error) {
-	if b.err != nil {
+	taskManifestSpecs, err := b.BuildTasks()
+	if err != nil {
+		return &TaskManifestSpec{}, err
+	}
+
+	if len(taskManifestSpecs) != 1 {
 		return &TaskManifestSpec{}, errors.NewConfigurationError(fmt.Sprintf(
+			"cannot build manifest of type '%s'. The manifest file %s has %d documents, "+
+				"but only one was expected", b.manifestType, b.manifestFile, len(taskManifestSpecs)), nil)
+	}
+
+	return taskManifestSpecs[0], nil
+}
+
+// BuildTasks builds the task manifests, one per document of the manifest file.
+func (b *Builder) BuildTasks() ([]*TaskManifestSpec, error) {
+	if err := b.buildErr(entities.ManifestTypeTask); err != nil {
+		return nil, err
+	}
+
+	var taskManifestSpecs []*TaskManifestSpec
+	for _, manifestDoc := range b.manifestDocuments {
+		taskManifestSpec := *manifestDoc.spec.(*TaskManifestSpec)
+		taskManifestSpecs = append(taskManifestSpecs, &taskManifestSpec)
+	}
+
+	return taskManifestSpecs, nil
+}
+
+// BuildJobs builds the job manifests, one per document of the manifest file.
+func (b *Builder) BuildJobs() ([]*JobManifestSpec, error) {
+	if err := b.buildErr(entities.ManifestTypeJob); err != nil {
+		return nil, err
+	}
+
+	var jobManifestSpecs []*JobManifestSpec
+	for _, manifestDoc := range b.manifestDocuments {
+		jobManifestSpec := *manifestDoc.spec.(*JobManifestSpec)
+		jobManifestSpecs = append(jobManifestSpecs, &jobManifestSpec)
+	}
+
+	return jobManifestSpecs, nil
+}
+
+// BuildWorkflows builds the workflow manifests, one per document of the manifest file.
+func (b *Builder) BuildWorkflows() ([]*WorkflowManifestSpec, error) {
+	if err := b.buildErr(entities.ManifestTypeWorkflow); err != nil {
+		return nil, err
+	}
+
+	var workflowManifestSpecs []*WorkflowManifestSpec
+	for _, manifestDoc := range b.manifestDocuments {
+		workflowManifestSpec := *manifestDoc.spec.(*WorkflowManifestSpec)
+		workflowManifestSpecs = append(workflowManifestSpecs, &workflowManifestSpec)
+	}
+
+	return workflowManifestSpecs, nil
+}
+
+// buildErr returns the error that prevents the manifests from being built as the given type.
+func (b *Builder) buildErr(manifestType string) error {
+	if b.err != nil {
+		return errors.NewConfigurationError(fmt.Sprintf(
 			"cannot build manifest of type '%s'", b.manifestType), b.err)
 	}
 
-	return &TaskManifestSpec{
-		APIVersion: b.taskManifestSpec.APIVersion,
-		Kind:       b.taskManifestSpec.Kind,
-		Metadata: TaskMetadata{
-			Name: b.taskManifestSpec.Metadata.Name,
-		},
-		Spec: TaskSpec{
-			ContainerImage: b.taskManifestSpec.Spec.ContainerImage,
-			Workdir:        b.taskManifestSpec.Spec.Workdir,
-			MountDir:       b.taskManifestSpec.Spec.MountDir,
-			BaseDir:        b.taskManifestSpec.Spec.BaseDir,
-			CommandsSpec:   b.taskManifestSpec.Spec.CommandsSpec,
-			EnvVarsSpec:    b.taskManifestSpec.Spec.EnvVarsSpec,
-		},
-	}, nil
+	if b.manifestType != manifestType {
+		return errors.NewConfigurationError(fmt.Sprintf(
+			"cannot build manifest of type '%s' as a manifest of type '%s'", b.manifestType,
+			manifestType), nil)
+	}
+
+	if len(b.manifestDocuments) == 0 {
+		return errors.NewConfigurationError(fmt.Sprintf(
+			"cannot build manifest of type '%s'. No manifest was constructed", b.manifestType), nil)
+	}
+
+	return nil
 }
 
 // NewTaskSpecBuilder creates a new instance of ManifestBuilder.
@@ -398,11 +1010,34 @@ func NewTaskSpecBuilder(opts NewOpts) (*Builder, error) {
 		return nil, errors.NewArgumentError(errMsg, nil)
 	}
 
+	sources := opts.Sources
+	if sources == nil {
+		sources = manifest.NewSources(manifest.DefaultCacheDir())
+	}
+
+	// The manifests of a source (E.g.: a URL, or a git repository) are built out of the
+	// local file they're materialized into.
+	if manifest.IsRemote(opts.ManifestFile) {
+		resolved, err := sources.Resolve(opts.ManifestFile)
+		if err != nil {
+			errMsg := fmt.Sprintf("Cannot create a manifest builder client. Cannot load the manifest %s",
+				opts.ManifestFile)
+			logger.Error(errMsg)
+			return nil, errors.NewArgumentError(errMsg, err)
+		}
+
+		logger.Info(fmt.Sprintf("The manifest %s is loaded from %s", opts.ManifestFile, resolved.Path))
+
+		opts.ManifestFile = resolved.Path
+	}
+
 	// Joining the manifest filepath with the current directory.
-	manifestFileFull := filepath.Join(opts.Client.CfgDir.BaseDir, opts.ManifestFile)
-	logger.Info(fmt.Sprintf("The manifest full path is resolved as: %s", manifestFileFull))
+	if !filepath.IsAbs(opts.ManifestFile) {
+		manifestFileFull := filepath.Join(opts.Client.CfgDir.BaseDir, opts.ManifestFile)
+		logger.Info(fmt.Sprintf("The manifest full path is resolved as: %s", manifestFileFull))
 
-	opts.ManifestFile = manifestFileFull
+		opts.ManifestFile = manifestFileFull
+	}
 
 	if err := yamlparser.YamlFileIsValid(opts.ManifestFile); err != nil {
 		errMsg := fmt.Sprintf("Cannot create a manifest builder client. Invalid manifest file: %s",
@@ -412,13 +1047,19 @@ func NewTaskSpecBuilder(opts NewOpts) (*Builder, error) {
 		return nil, errors.NewArgumentError(errMsg, err)
 	}
 
+	// The manifests materialized by a source aren't trusted, even if they're passed as
+	// local files. E.g.: by the CLI, once it resolves them.
+	sourceRoot, _ := sources.Root(opts.ManifestFile)
+
 	return &Builder{
-		manifestType:     opts.ManifestType,
-		manifestFile:     opts.ManifestFile,
-		taskManifestSpec: nil,
-		client:           opts.Client,
-		logger:           opts.Client.Logger,
-		baseDir:          opts.Client.CfgDir.BaseDir,
-		baseDirAbs:       opts.Client.CfgDir.BaseDirAbs,
+		manifestType: opts.ManifestType,
+		manifestFile: opts.ManifestFile,
+		client:       opts.Client,
+		logger:       opts.Client.Logger,
+		baseDir:      opts.Client.CfgDir.BaseDir,
+		baseDirAbs:   opts.Client.CfgDir.BaseDirAbs,
+		inputs:       opts.Inputs,
+		lenient:      opts.Lenient,
+		sourceRoot:   sourceRoot,
 	}, nil
 }
@@ -0,0 +1,150 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/utils"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// TemplateData is the data available in the templates of a manifest. E.g.:
+//
+//	image: 'my-app:{{ .Git.Tag }}-{{ .Host.Arch }}'
+//	BUILD_ID: '{{ .Run.ID }}'
+type TemplateData struct {
+	// Inputs are the values of the inputs of the manifest (see InputSpec).
+	Inputs map[string]interface{}
+	// Matrix keeps the matrix variables (E.g.: '{{ .Matrix.image }}') as they are, since
+	// they're rendered once the job builder expands the matrix of the task.
+	Matrix map[string]string
+	Host   HostData
+	Git    GitData
+	// Env are the environment variables of the host.
+	Env      map[string]string
+	Run      RunData
+	Manifest ManifestData
+
+	// sourceRoot is set if the manifest isn't trusted. See manifest.TemplateOpts.Root.
+	sourceRoot string
+}
+
+// HostData describes the host that runs Stiletto.
+type HostData struct {
+	// OS and Arch are the ones Stiletto was built for. E.g.: 'linux', and 'amd64'.
+	OS   string
+	Arch string
+	User string
+	Home string
+	// Cwd is the directory Stiletto runs from.
+	Cwd string
+}
+
+// GitData describes the git repository Stiletto runs from. Its fields are empty if it
+// doesn't run from a git repository.
+type GitData struct {
+	IsRepo bool
+	Branch string
+	SHA    string
+	// Tag is the tag that points to the commit checked out, if any.
+	Tag string
+	// Remote is the URL of the 'origin' remote, without credentials.
+	Remote string
+
+	dirCfg *entities.DirCfg
+}
+
+// Dirty is true if any of the files tracked was modified. It's a method, so the tracked
+// files are only hashed if a template uses it. E.g.: '{{ if .Git.Dirty }}-dirty{{ end }}'.
+func (g GitData) Dirty() bool {
+	return g.dirCfg.IsGitDirty()
+}
+
+// RunData identifies the run of Stiletto. It's the same for every manifest of the run.
+type RunData struct {
+	ID string
+	// Timestamp is the time the run started, in UTC, formatted as RFC 3339.
+	Timestamp string
+}
+
+// ManifestData describes the manifest file rendered.
+type ManifestData struct {
+	// Path is the absolute path of the manifest file, and Dir is its directory.
+	Path string
+	Dir  string
+}
+
+// newTemplateData returns the data available in the templates of the manifest of the
+// builder, without inputs.
+func (b *Builder) newTemplateData() *TemplateData {
+	data := &TemplateData{
+		Inputs: map[string]interface{}{},
+		Host: HostData{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+		},
+		Env: map[string]string{},
+		Run: RunData{ID: b.client.Id, Timestamp: b.client.StartedAt.UTC().Format(time.RFC3339)},
+	}
+
+	if data.Run.ID == "" {
+		data.Run.ID = utils.GetUUID()
+	}
+
+	if b.client.StartedAt.IsZero() {
+		data.Run.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	if current, err := user.Current(); err == nil {
+		data.Host.User = current.Username
+	} else {
+		data.Host.User = os.Getenv("USER")
+	}
+
+	if dirCfg := b.client.CfgDir; dirCfg != nil {
+		data.Host.Home = dirCfg.HomeDirAbs
+		data.Host.Cwd = dirCfg.BaseDirAbs
+		data.Git = GitData{
+			IsRepo: dirCfg.IsGitRepo,
+			Branch: dirCfg.GitBranch,
+			SHA:    dirCfg.GitSHA,
+			Tag:    dirCfg.GitTag,
+			Remote: dirCfg.GitRemote,
+			dirCfg: dirCfg,
+		}
+	}
+
+	data.Env = utils.HostEnvVars()
+
+	// The manifests loaded from a source (E.g.: a URL) can't read the host, other than
+	// through the inputs passed.
+	if b.sourceRoot != "" {
+		data.sourceRoot = b.sourceRoot
+		data.Host.User, data.Host.Home, data.Host.Cwd = "", "", ""
+		data.Env = map[string]string{}
+	}
+
+	data.Manifest = manifestData(b.manifestFile)
+
+	return data
+}
+
+// forManifest returns a copy of the data, for the templates of another manifest file.
+// E.g.: the one extended by the manifest.
+func (d *TemplateData) forManifest(manifestFile string) *TemplateData {
+	data := *d
+	data.Manifest = manifestData(manifestFile)
+
+	return &data
+}
+
+func manifestData(manifestFile string) ManifestData {
+	manifestFileAbs, err := filepath.Abs(manifestFile)
+	if err != nil {
+		manifestFileAbs = manifestFile
+	}
+
+	return ManifestData{Path: manifestFileAbs, Dir: filepath.Dir(manifestFileAbs)}
+}
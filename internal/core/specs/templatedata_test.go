@@ -0,0 +1,88 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTemplateData(t *testing.T) {
+	t.Run("should render the host, git, env, run and manifest data", func(t *testing.T) {
+		t.Setenv("STILETTO_TEST_REGION", "eu-west-1")
+		t.Setenv("STILETTO_TEST_DSN", "postgres://db?sslmode=disable&user=admin")
+
+		dir := t.TempDir()
+		builder, err := NewTaskSpecBuilder(NewOpts{
+			ManifestType: entities.ManifestTypeTask,
+			ManifestFile: "manifest.yml",
+			Client: &entities.Client{
+				Logger:    zap.NewNop(),
+				Id:        "run-1",
+				StartedAt: time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+				CfgDir: &entities.DirCfg{
+					BaseDir:    dir,
+					BaseDirAbs: dir,
+					IsGitRepo:  true,
+					GitBranch:  "main",
+					GitSHA:     "4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+					GitTag:     "v1.2.0",
+					GitDirty:   func() bool { return true },
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		data := builder.newTemplateData()
+		rendered, err := compileManifestFunctions(builder.manifestFile, `os: {{ .Host.OS }}/{{ .Host.Arch }}
+cwd: {{ .Host.Cwd }}
+version: {{ .Git.Tag }}{{ if .Git.Dirty }}-dirty{{ end }} ({{ .Git.Branch }})
+region: {{ .Env.STILETTO_TEST_REGION }}
+dsn: {{ .Env.STILETTO_TEST_DSN }}
+run: {{ .Run.ID }} {{ .Run.Timestamp }}
+manifest: {{ .Manifest.Dir }}
+`, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, `os: `+runtime.GOOS+`/`+runtime.GOARCH+`
+cwd: `+dir+`
+version: v1.2.0-dirty (main)
+region: eu-west-1
+dsn: postgres://db?sslmode=disable&user=admin
+run: run-1 2026-10-18T09:30:00Z
+manifest: `+dir+`
+`, rendered)
+
+		assert.Equal(t, dir+"/base/terragrunt.yml", data.forManifest(dir+"/base/terragrunt.yml").Manifest.Path)
+	})
+
+	t.Run("should only read whether the repository is dirty if a template uses it", func(t *testing.T) {
+		reads := 0
+		builder, err := NewTaskSpecBuilder(NewOpts{
+			ManifestType: entities.ManifestTypeTask,
+			ManifestFile: "manifest.yml",
+			Client: &entities.Client{
+				Logger: zap.NewNop(),
+				CfgDir: &entities.DirCfg{IsGitRepo: true, GitBranch: "main", GitDirty: func() bool {
+					reads++
+					return false
+				}},
+			},
+		})
+		assert.NoError(t, err)
+
+		data := builder.newTemplateData()
+
+		rendered, err := compileManifestFunctions(builder.manifestFile, `branch: {{ .Git.Branch }}`, data)
+		assert.NoError(t, err)
+		assert.Equal(t, "branch: main", rendered)
+		assert.Equal(t, 0, reads)
+
+		rendered, err = compileManifestFunctions(builder.manifestFile, `dirty: {{ .Git.Dirty }}`, data)
+		assert.NoError(t, err)
+		assert.Equal(t, "dirty: false", rendered)
+		assert.Equal(t, 1, reads)
+	})
+}
@@ -0,0 +1,29 @@
+package specs
+
+import (
+	"time"
+)
+
+// parseTimeout parses a timeout of the manifest, as a duration (E.g.: '90s', or '1h30m').
+// An empty, or invalid timeout (reported by validateTimeout) means there's none.
+func parseTimeout(timeout string) time.Duration {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration < 0 {
+		return 0
+	}
+
+	return duration
+}
+
+// validateTimeout validates a timeout of the manifest.
+func validateTimeout(v *manifestValidator, path, timeout string) {
+	if timeout == "" {
+		return
+	}
+
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration <= 0 {
+		v.error(path, "The timeout '%s' is invalid. It should be a positive duration. E.g.: '90s', "+
+			"'15m', or '1h30m'", timeout)
+	}
+}
@@ -0,0 +1,165 @@
+package specs
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/errors"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"strings"
+)
+
+// LatestAPIVersion is the version of the spec the manifests are decoded into. The
+// manifests declared with a previous version are converted into it first.
+const LatestAPIVersion = entities.ManifestAPIVersionV2
+
+// fieldRename is a field that was renamed from one version of the spec to the next one.
+type fieldRename struct {
+	// paths are the mappings (by manifest kind) where the field is declared. '[*]'
+	// matches every item of a sequence.
+	paths map[string][]string
+	from  string
+	to    string
+}
+
+// specVersion is a version of the manifest spec, along with the changes that
+// convert a manifest from the previous version into it.
+type specVersion struct {
+	name    string
+	renames []fieldRename
+}
+
+// specVersions is the registry of the versions of the spec, from the oldest to the latest.
+var specVersions = []specVersion{
+	{name: entities.ManifestAPIVersionV1},
+	{
+		name: entities.ManifestAPIVersionV2,
+		renames: []fieldRename{
+			{
+				// 'workDir' follows the same casing as 'mountDir' and 'baseDir'.
+				paths: map[string][]string{
+					entities.ManifestKindTask:     {"spec"},
+					entities.ManifestKindJob:      {"spec", "spec.tasks[*]"},
+					entities.ManifestKindWorkflow: {"spec.jobs[*]", "spec.jobs[*].tasks[*]"},
+				},
+				from: "workdir",
+				to:   "workDir",
+			},
+		},
+	},
+}
+
+// SupportedAPIVersions returns the versions of the spec, from the oldest to the latest.
+func SupportedAPIVersions() []string {
+	var versions []string
+	for _, version := range specVersions {
+		versions = append(versions, version.name)
+	}
+
+	return versions
+}
+
+func apiVersionIndex(apiVersion string) int {
+	for idx, version := range specVersions {
+		if version.name == apiVersion {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// FieldChange is a field renamed when a manifest is converted between two versions of the spec.
+type FieldChange struct {
+	Path   string
+	Line   int
+	Column int
+	From   string
+	To     string
+
+	// Since is the version of the spec that introduced the change.
+	Since string
+
+	// File is the file the field was merged in from (E.g.: an extended manifest), if it
+	// doesn't come from the manifest's file.
+	File string
+}
+
+// ConvertDocument converts, in place, a document of a manifest of the given kind from
+// one version of the spec to another one (either newer or older). The 'apiVersion'
+// field isn't changed. It returns the fields renamed, with the position they had
+// in the original document.
+func ConvertDocument(document *yamlparser.Document, kind, from, to string) ([]FieldChange, error) {
+	fromIdx, toIdx := apiVersionIndex(from), apiVersionIndex(to)
+	if fromIdx == -1 || toIdx == -1 {
+		return nil, errors.NewManifestError(fmt.Sprintf("Cannot convert the manifest from '%s' to '%s'. "+
+			"The supported versions are: %s", from, to, strings.Join(SupportedAPIVersions(), ", ")), nil)
+	}
+
+	var changes []FieldChange
+
+	// Upgrading applies the renames of every newer version; downgrading reverts them.
+	for idx := fromIdx + 1; idx <= toIdx; idx++ {
+		for _, rename := range specVersions[idx].renames {
+			renameChanges, err := renameField(document, kind, rename.paths[kind], rename.from, rename.to)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range renameChanges {
+				renameChanges[i].Since = specVersions[idx].name
+			}
+
+			changes = append(changes, renameChanges...)
+		}
+	}
+
+	for idx := fromIdx; idx > toIdx; idx-- {
+		for _, rename := range specVersions[idx].renames {
+			renameChanges, err := renameField(document, kind, rename.paths[kind], rename.to, rename.from)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range renameChanges {
+				renameChanges[i].Since = specVersions[idx].name
+			}
+
+			changes = append(changes, renameChanges...)
+		}
+	}
+
+	return changes, nil
+}
+
+func renameField(document *yamlparser.Document, kind string, patterns []string, from, to string) ([]FieldChange, error) {
+	var changes []FieldChange
+
+	for _, pattern := range patterns {
+		for _, match := range document.LookupAll(pattern) {
+			key := document.LookupKey(match.Path, from)
+			if key == nil {
+				continue
+			}
+
+			if document.LookupKey(match.Path, to) != nil {
+				return nil, errors.NewManifestError(fmt.Sprintf("Cannot convert the %s manifest. "+
+					"Both '%s' and '%s' are declared in '%s'", kind, from, to, match.Path), nil)
+			}
+
+			file, _ := document.Source(key)
+
+			changes = append(changes, FieldChange{
+				Path:   joinPath(match.Path, from),
+				Line:   key.Line,
+				Column: key.Column,
+				File:   file,
+				From:   from,
+				To:     to,
+			})
+
+			key.Value = to
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,142 @@
+package specs
+
+import (
+	"github.com/excoriate/stiletto/internal/core/entities"
+	"github.com/excoriate/stiletto/internal/version"
+	"github.com/excoriate/stiletto/internal/yamlparser"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const jobManifestV1 = `---
+apiVersion: v1
+kind: Job
+metadata:
+    name: build
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workdir: src
+    tasks:
+        - name: compile
+          workdir: src
+          commandsSpec:
+              - binary: cargo
+                commands:
+                    - build
+`
+
+func TestConvertDocument(t *testing.T) {
+	t.Run("should rename the fields when upgrading, and revert them when downgrading", func(t *testing.T) {
+		documents, err := yamlparser.DocumentsFromContent(jobManifestV1)
+		assert.NoError(t, err)
+
+		changes, err := ConvertDocument(documents[0], entities.ManifestKindJob, "v1", "v2")
+
+		assert.NoError(t, err)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "spec.workdir", changes[0].Path)
+		assert.Equal(t, 9, changes[0].Line)
+		assert.Equal(t, "spec.tasks[0].workdir", changes[1].Path)
+		assert.Equal(t, "v2", changes[1].Since)
+
+		var spec JobManifestSpec
+		assert.NoError(t, documents[0].Decode(&spec))
+		assert.Equal(t, "src", spec.Spec.Workdir)
+
+		changes, err = ConvertDocument(documents[0], entities.ManifestKindJob, "v2", "v1")
+
+		assert.NoError(t, err)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "workdir", changes[0].To)
+		assert.NotNil(t, documents[0].LookupKey("spec", "workdir"))
+	})
+
+	t.Run("should fail when the old and the new field are both declared", func(t *testing.T) {
+		documents, err := yamlparser.DocumentsFromContent("spec:\n    workdir: a\n    workDir: b\n")
+		assert.NoError(t, err)
+
+		_, err = ConvertDocument(documents[0], entities.ManifestKindTask, "v1", "v2")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail with an unknown version", func(t *testing.T) {
+		documents, err := yamlparser.DocumentsFromContent(jobManifestV1)
+		assert.NoError(t, err)
+
+		_, err = ConvertDocument(documents[0], entities.ManifestKindJob, "v1", "v9")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestManifestVersions(t *testing.T) {
+	t.Run("should build a manifest of a previous version, and warn about its deprecated fields", func(t *testing.T) {
+		builder := newTestBuilder(t, entities.ManifestTypeJob, jobManifestV1)
+
+		jobs, err := builder.BuildJobs()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "src", jobs[0].Spec.Tasks[0].Workdir)
+
+		diagnostics := builder.Diagnostics()
+		assert.Len(t, diagnostics, 2)
+		assert.Equal(t, SeverityWarning, diagnostics[0].Severity)
+		assert.Equal(t, "spec.workdir", diagnostics[0].Path)
+	})
+
+	t.Run("should fail when the manifest requires a newer version of Stiletto", func(t *testing.T) {
+		previous := version.Version
+		version.Version = "0.1.0"
+		defer func() { version.Version = previous }()
+
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: build
+    minStilettoVersion: 1.2.0
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - commands: [cargo build]
+`)
+
+		_, err := builder.BuildTasks()
+
+		assert.Error(t, err)
+		assert.Equal(t, "metadata.minStilettoVersion", builder.Diagnostics()[0].Path)
+		assert.Equal(t, 6, builder.Diagnostics()[0].Line)
+	})
+
+	t.Run("should fail with a malformed version, even in a dev build", func(t *testing.T) {
+		previous := version.Version
+		version.Version = "dev"
+		defer func() { version.Version = previous }()
+
+		builder := newTestBuilder(t, entities.ManifestTypeTask, `---
+apiVersion: v2
+kind: Task
+metadata:
+    name: build
+    minStilettoVersion: banana
+spec:
+    containerImage: rust:alpine
+    mountDir: .
+    workDir: src
+    commandsSpec:
+        - commands: [cargo build]
+`)
+
+		_, err := builder.BuildTasks()
+
+		assert.Error(t, err)
+		assert.Len(t, builder.Diagnostics(), 1)
+		assert.Equal(t, "metadata.minStilettoVersion", builder.Diagnostics()[0].Path)
+		assert.Equal(t, 6, builder.Diagnostics()[0].Line)
+		assert.Contains(t, builder.Diagnostics()[0].Message, "invalid version: banana")
+	})
+}
@@ -0,0 +1,29 @@
+package specs
+
+// WorkflowManifestSpec declares several jobs, and the dependencies between them.
+type WorkflowManifestSpec struct {
+	APIVersion string           `yaml:"apiVersion" required:"true" description:"Version of the manifest spec."`
+	Kind       string           `yaml:"kind" required:"true" description:"Kind of the manifest."`
+	Metadata   WorkflowMetadata `yaml:"metadata" required:"true" description:"Metadata that identifies the workflow."`
+	Spec       WorkflowSpec     `yaml:"spec" required:"true" description:"Specification of the workflow."`
+}
+
+type WorkflowMetadata struct {
+	Name               string            `yaml:"name" required:"true" description:"Name of the workflow. E.g.: 'my-workflow'."`
+	MinStilettoVersion string            `yaml:"minStilettoVersion" description:"Minimum version of Stiletto required to run the manifest. E.g.: '0.1.0'."`
+	Labels             map[string]string `yaml:"labels" description:"Labels of the workflow, used to select what runs. E.g.: 'stage: plan', selected with '--labels stage=plan'."`
+	Annotations        map[string]string `yaml:"annotations" description:"Free-form metadata of the workflow (E.g.: its owner), which doesn't affect how it runs."`
+}
+
+type WorkflowSpec struct {
+	Jobs   []*WorkflowJobSpec    `yaml:"jobs" required:"true" description:"Jobs of the workflow."`
+	Inputs map[string]*InputSpec `yaml:"inputs" description:"Typed parameters of the manifest, available in its templates as '{{ .Inputs.name }}'."`
+}
+
+// WorkflowJobSpec is a job declared inline in a workflow manifest. The 'needs'
+// field lists the jobs (by name) that should succeed before this one runs.
+type WorkflowJobSpec struct {
+	Name    string   `yaml:"name" required:"true" description:"Name of the job, unique within the workflow."`
+	Needs   []string `yaml:"needs" description:"Name of the jobs that should succeed before this one runs."`
+	JobSpec `yaml:",inline"`
+}
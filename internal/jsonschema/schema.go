@@ -0,0 +1,165 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+)
+
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema is a (draft-07) JSON Schema. Only the keywords used by Stiletto are supported.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+	Else                 *Schema            `json:"else,omitempty"`
+}
+
+// Provider is implemented by the types that describe their own schema, instead
+// of having it reflected out of their structure.
+type Provider interface {
+	JSONSchema() *Schema
+}
+
+var providerType = reflect.TypeOf((*Provider)(nil)).Elem()
+
+// Reflect generates the schema of the given value, out of its type. The properties
+// are named after the 'yaml' tag of each field, and these other tags are supported:
+//   - description: the description of the property.
+//   - required: if "true", the property is required.
+//   - enum: the comma-separated list of the values allowed.
+//
+// Structs are closed (they don't accept additional properties), and the fields
+// tagged as ',inline' are flattened into their parent.
+func Reflect(v interface{}) *Schema {
+	return reflectType(reflect.TypeOf(v))
+}
+
+func reflectType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return reflectType(t.Elem())
+	}
+
+	if t.Implements(providerType) {
+		return reflect.Zero(t).Interface().(Provider).JSONSchema()
+	}
+
+	if reflect.PtrTo(t).Implements(providerType) {
+		return reflect.New(t).Interface().(Provider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: reflectType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reflectType(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{
+			Type:                 "object",
+			Properties:           map[string]*Schema{},
+			AdditionalProperties: false,
+		}
+
+		reflectFields(t, schema)
+
+		return schema
+	default:
+		// Interfaces, and any other type, accept any value.
+		return &Schema{}
+	}
+}
+
+func reflectFields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, inline, skip := yamlName(field)
+		if skip {
+			continue
+		}
+
+		if inline {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+
+			reflectFields(fieldType, schema)
+			continue
+		}
+
+		property := reflectType(field.Type)
+
+		if description := field.Tag.Get("description"); description != "" {
+			property.Description = description
+		}
+
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, value := range strings.Split(enum, ",") {
+				property.Enum = append(property.Enum, strings.TrimSpace(value))
+			}
+		}
+
+		if field.Tag.Get("required") == "true" {
+			schema.Required = append(schema.Required, name)
+
+			if property.Type == "array" {
+				property.MinItems = intPtr(1)
+			}
+		}
+
+		schema.Properties[name] = property
+	}
+}
+
+// yamlName returns the name of the field as it's declared in yaml, and whether
+// it's inlined, or should be skipped.
+func yamlName(field reflect.StructField) (name string, inline, skip bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "inline" {
+			return "", true, false
+		}
+	}
+
+	if parts[0] != "" {
+		return parts[0], false, false
+	}
+
+	// Same default as yaml.v3: the field name, lowercased.
+	return strings.ToLower(field.Name), false, false
+}
+
+func intPtr(v int) *int {
+	return &v
+}
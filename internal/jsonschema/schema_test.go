@@ -0,0 +1,48 @@
+package jsonschema
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type inlined struct {
+	Image string `yaml:"image" required:"true"`
+}
+
+type example struct {
+	Name     string            `yaml:"name" required:"true" description:"The name."`
+	Mode     string            `yaml:"mode" enum:"fast, slow"`
+	Tags     []string          `yaml:"tags" required:"true"`
+	Labels   map[string]string `yaml:"labels"`
+	Retries  int
+	Ignored  string `yaml:"-"`
+	inlined  `yaml:",inline"`
+	internal string
+}
+
+func TestReflect(t *testing.T) {
+	schema := Reflect(example{})
+
+	t.Run("should name the properties after the yaml tags, and flatten the inlined ones", func(t *testing.T) {
+		assert.Equal(t, "object", schema.Type)
+		assert.Equal(t, false, schema.AdditionalProperties)
+		assert.Len(t, schema.Properties, 6)
+		assert.Contains(t, schema.Properties, "retries")
+		assert.Contains(t, schema.Properties, "image")
+		assert.NotContains(t, schema.Properties, "ignored")
+	})
+
+	t.Run("should map the tags into descriptions, enums and required properties", func(t *testing.T) {
+		assert.Equal(t, "The name.", schema.Properties["name"].Description)
+		assert.Equal(t, []interface{}{"fast", "slow"}, schema.Properties["mode"].Enum)
+		assert.Equal(t, []string{"name", "tags", "image"}, schema.Required)
+		assert.Equal(t, 1, *schema.Properties["tags"].MinItems)
+	})
+
+	t.Run("should map the go types into json types", func(t *testing.T) {
+		assert.Equal(t, "integer", schema.Properties["retries"].Type)
+		assert.Equal(t, "array", schema.Properties["tags"].Type)
+		assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+		assert.Equal(t, &Schema{Type: "string"}, schema.Properties["labels"].AdditionalProperties)
+	})
+}
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"fmt"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+	"os"
+	"strings"
+)
+
+type Prompter struct {
+}
+
+// IsInteractive returns true if the standard input is a terminal, so the user can be prompted.
+func (p *Prompter) IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+func (p *Prompter) AskText(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		question = fmt.Sprintf("%s (default: %s)", question, defaultValue)
+	}
+
+	answer, err := pterm.DefaultInteractiveTextInput.WithDefaultText(question).Show()
+	if err != nil {
+		return "", err
+	}
+
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return defaultValue, nil
+	}
+
+	return answer, nil
+}
+
+func (p *Prompter) AskSelect(question string, options []string, defaultOption string) (string, error) {
+	return pterm.DefaultInteractiveSelect.
+		WithDefaultText(question).
+		WithOptions(options).
+		WithDefaultOption(defaultOption).
+		Show()
+}
+
+func NewTUIPrompter() UXPrompter {
+	return &Prompter{}
+}
@@ -13,3 +13,9 @@ type UXTitleGenerator interface {
 	ShowSubTitle(mainTitle, subtitle string)
 	ShowExecutionDetails(opt ExecutionDetails)
 }
+
+type UXPrompter interface {
+	IsInteractive() bool
+	AskText(question, defaultValue string) (string, error)
+	AskSelect(question string, options []string, defaultOption string) (string, error)
+}
@@ -21,6 +21,21 @@ func FetchAllEnvVarsFromHost() (EnvVars, error) {
 	return result, nil
 }
 
+// HostEnvVars returns the environment variables of the host, as they're set. Unlike
+// FetchAllEnvVarsFromHost, the values that contain '=' (E.g.: a base64 token, or a
+// connection string) are kept whole, and their quotes aren't removed.
+func HostEnvVars() EnvVars {
+	result := make(EnvVars)
+
+	for _, env := range os.Environ() {
+		if key, value, found := strings.Cut(env, "="); found && key != "" {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
 // FetchEnvVarsWithPrefix fetches environment variables that start with the specified prefix
 // and returns an error if any of the variables either do not exist or have an empty value.
 func FetchEnvVarsWithPrefix(prefix string) (EnvVars, error) {
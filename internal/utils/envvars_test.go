@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHostEnvVars(t *testing.T) {
+	t.Run("should keep the values that contain '=', and their quotes", func(t *testing.T) {
+		t.Setenv("STILETTO_TEST_TOKEN", "dG9rZW4=")
+		t.Setenv("STILETTO_TEST_DSN", `host=db user="admin"`)
+
+		envVars := HostEnvVars()
+
+		assert.Equal(t, "dG9rZW4=", envVars["STILETTO_TEST_TOKEN"])
+		assert.Equal(t, `host=db user="admin"`, envVars["STILETTO_TEST_DSN"])
+	})
+}
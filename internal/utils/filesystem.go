@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func IsValidDir(path string) error {
@@ -29,6 +30,12 @@ func PathToAbsolute(path string) (string, error) {
 	return absolutePath, nil
 }
 
+// IsWithinDir reports whether the path is the parent directory, or one of its descendants.
+func IsWithinDir(parent, path string) bool {
+	rel, err := filepath.Rel(parent, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func FileExistAndItIsAFile(filePath string) error {
 	if filePath == "" {
 		return fmt.Errorf("empty file path")
@@ -88,8 +95,9 @@ func FindGitRepoDir(pathname string, levels int) (string, error) {
 		return "", fmt.Errorf("error converting path %s to absolute path: %s", pathname, err.Error())
 	}
 	for i := 0; i < levels; i++ {
+		// The '.git' of the worktrees, and of the submodules, is a file.
 		gitPath := filepath.Join(absPath, ".git")
-		if stat, err := os.Stat(gitPath); err == nil && stat.IsDir() {
+		if _, err := os.Stat(gitPath); err == nil {
 			return absPath, nil
 		}
 		parentPath := filepath.Dir(absPath)
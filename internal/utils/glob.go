@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GlobFiles returns the paths (relative to the root directory, and slash-separated)
+// that match the pattern. Besides the syntax of path.Match, the '**' segment matches
+// any number of directories. E.g.: 'live/**/terragrunt.hcl'. The '.git' directories
+// are skipped.
+func GlobFiles(root, pattern string) ([]string, error) {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if MatchGlob(pattern, rel) {
+			matches = append(matches, rel)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot match the glob pattern %s in %s: %w", pattern, root, err)
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+// MatchGlob reports whether the slash-separated path matches the pattern, where
+// '**' matches any number of directories. See GlobFiles.
+func MatchGlob(pattern, name string) bool {
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(patterns, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+
+	if patterns[0] == "**" {
+		for skipped := 0; skipped <= len(names); skipped++ {
+			if matchGlobSegments(patterns[1:], names[skipped:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(names) == 0 {
+		return false
+	}
+
+	if matched, _ := path.Match(patterns[0], names[0]); !matched {
+		return false
+	}
+
+	return matchGlobSegments(patterns[1:], names[1:])
+}
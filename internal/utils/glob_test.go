@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Run("should match any number of directories with '**'", func(t *testing.T) {
+		assert.True(t, MatchGlob("live/**/terragrunt.hcl", "live/terragrunt.hcl"))
+		assert.True(t, MatchGlob("live/**/terragrunt.hcl", "live/prod/vpc/terragrunt.hcl"))
+		assert.True(t, MatchGlob("**/*.yml", "tasks/build.yml"))
+		assert.False(t, MatchGlob("live/**/terragrunt.hcl", "modules/vpc/terragrunt.hcl"))
+		assert.False(t, MatchGlob("live/*/terragrunt.hcl", "live/prod/vpc/terragrunt.hcl"))
+	})
+}
+
+func TestGlobFiles(t *testing.T) {
+	t.Run("should return the sorted matches, relative to the root", func(t *testing.T) {
+		root := t.TempDir()
+		for _, file := range []string{"live/prod/terragrunt.hcl", "live/dev/terragrunt.hcl",
+			"live/dev/main.tf", ".git/terragrunt.hcl"} {
+			assert.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(file)), 0755))
+			assert.NoError(t, os.WriteFile(filepath.Join(root, file), []byte{}, 0644))
+		}
+
+		matches, err := GlobFiles(root, "**/terragrunt.hcl")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"live/dev/terragrunt.hcl", "live/prod/terragrunt.hcl"}, matches)
+	})
+}
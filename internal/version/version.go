@@ -0,0 +1,73 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of Stiletto. It's set at build time (see .goreleaser.yaml),
+// and it's 'dev' for the local builds.
+var Version = "dev"
+
+// IsDev returns true if the current build isn't a released version.
+func IsDev() bool {
+	return Version == "dev"
+}
+
+// Compare compares two semantic versions (E.g.: '1.2.3', or 'v1.2.3'). It returns -1,
+// 0 or 1 if a is lower, equal or greater than b. Pre-release and build suffixes are ignored.
+func Compare(a, b string) (int, error) {
+	partsA, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+
+	partsB, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range partsA {
+		if partsA[i] < partsB[i] {
+			return -1, nil
+		}
+
+		if partsA[i] > partsB[i] {
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// Validate checks that the version is a semantic version. E.g.: '1.2.3', or 'v1.2.3'.
+func Validate(version string) error {
+	_, err := parse(version)
+	return err
+}
+
+func parse(version string) ([3]int, error) {
+	var parts [3]int
+
+	normalised := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(normalised, "-+"); idx != -1 {
+		normalised = normalised[:idx]
+	}
+
+	segments := strings.Split(normalised, ".")
+	if len(segments) == 0 || len(segments) > 3 {
+		return parts, fmt.Errorf("invalid version: %s. Should be a semantic version. E.g.: '1.2.3'", version)
+	}
+
+	for i, segment := range segments {
+		number, err := strconv.Atoi(segment)
+		if err != nil || number < 0 {
+			return parts, fmt.Errorf("invalid version: %s. Should be a semantic version. E.g.: '1.2.3'", version)
+		}
+
+		parts[i] = number
+	}
+
+	return parts, nil
+}
@@ -0,0 +1,35 @@
+package version
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("should compare semantic versions", func(t *testing.T) {
+		cases := []struct {
+			a, b     string
+			expected int
+		}{
+			{"1.2.3", "1.2.3", 0},
+			{"v1.2.3", "1.2.3", 0},
+			{"1.2", "1.2.0", 0},
+			{"1.10.0", "1.9.0", 1},
+			{"0.0.9", "0.1.0", -1},
+			{"1.0.0-rc.1", "1.0.0", 0},
+		}
+
+		for _, c := range cases {
+			result, err := Compare(c.a, c.b)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, result, "%s vs %s", c.a, c.b)
+		}
+	})
+
+	t.Run("should fail with a version that isn't semantic", func(t *testing.T) {
+		_, err := Compare("latest", "1.0.0")
+
+		assert.Error(t, err)
+	})
+}
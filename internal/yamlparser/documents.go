@@ -0,0 +1,125 @@
+package yamlparser
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+)
+
+// Document is a single document of a (possibly multi-document) yaml stream.
+type Document struct {
+	// Index is the position of the document in the stream, starting at 1.
+	Index int
+
+	// Line is the line where the document's content starts.
+	Line int
+
+	// Node is the root node of the document.
+	Node *yaml.Node
+
+	// sources holds the file of the nodes merged in from another file (E.g.: the ones
+	// of an extended manifest). The rest of the nodes come from the document's file.
+	sources map[*yaml.Node]string
+}
+
+// MergeSource records that the nodes of the other document, which are merged into this
+// one, come from the given file. The ones that the other document already records as
+// merged in from another file keep it.
+func (d *Document) MergeSource(other *Document, file string) {
+	if d.sources == nil {
+		d.sources = map[*yaml.Node]string{}
+	}
+
+	var record func(node *yaml.Node)
+	record = func(node *yaml.Node) {
+		if _, ok := d.sources[node]; ok {
+			return
+		}
+
+		d.sources[node] = file
+		if source, ok := other.sources[node]; ok {
+			d.sources[node] = source
+		}
+
+		for _, item := range node.Content {
+			record(item)
+		}
+	}
+
+	record(other.Node)
+}
+
+// Source returns the file the node was merged in from, or false if it comes from the
+// document's file.
+func (d *Document) Source(node *yaml.Node) (string, bool) {
+	source, ok := d.sources[node]
+	return source, ok
+}
+
+// Decode decodes the document into the given struct.
+func (d *Document) Decode(schema interface{}) error {
+	if err := d.Node.Decode(schema); err != nil {
+		return fmt.Errorf("%s did not have a valid structure: %w", d, err)
+	}
+
+	return nil
+}
+
+// String describes the document, so it can be referenced in error messages.
+func (d *Document) String() string {
+	return fmt.Sprintf("document %d (line %d)", d.Index, d.Line)
+}
+
+// DocumentsFromContent splits a yaml stream into its documents. Empty documents
+// (E.g.: a trailing '---') are ignored.
+func DocumentsFromContent(yamlContent string) ([]*Document, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(yamlContent))
+
+	var documents []*Document
+	for index := 1; ; index++ {
+		node := &yaml.Node{}
+		if err := decoder.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("document %d of the yaml file did not have a valid structure: %w",
+				index, err)
+		}
+
+		if len(node.Content) == 0 || node.Content[0].Tag == "!!null" {
+			index--
+			continue
+		}
+
+		documents = append(documents, &Document{
+			Index: index,
+			Line:  node.Content[0].Line,
+			Node:  node,
+		})
+	}
+
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("the yaml file has no documents")
+	}
+
+	return documents, nil
+}
+
+// DocumentsFromFile splits a yaml file into its documents.
+func DocumentsFromFile(yamlFile string) ([]*Document, error) {
+	content, err := os.ReadFile(yamlFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the yaml file: %s", err.Error())
+	}
+
+	documents, err := DocumentsFromContent(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("the yaml file %s is invalid: %w", yamlFile, err)
+	}
+
+	return documents, nil
+}
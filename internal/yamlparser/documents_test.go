@@ -0,0 +1,75 @@
+package yamlparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDocumentsFromContent(t *testing.T) {
+	t.Run("should return every document of a multi-document yaml", func(t *testing.T) {
+		content := `---
+kind: Task
+metadata:
+    name: first
+---
+kind: Task
+metadata:
+    name: second
+---
+`
+		documents, err := DocumentsFromContent(content)
+
+		assert.NoError(t, err)
+		assert.Len(t, documents, 2)
+		assert.Equal(t, 1, documents[0].Index)
+		assert.Equal(t, 2, documents[0].Line)
+		assert.Equal(t, 2, documents[1].Index)
+		assert.Equal(t, 6, documents[1].Line)
+
+		var second struct {
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+		}
+
+		assert.NoError(t, documents[1].Decode(&second))
+		assert.Equal(t, "second", second.Metadata.Name)
+	})
+
+	t.Run("should report the position of the document that can't be decoded", func(t *testing.T) {
+		content := `kind: Task
+---
+kind: [Task
+`
+		_, err := DocumentsFromContent(content)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "document 2")
+	})
+
+	t.Run("should report the document and line of a decoding error", func(t *testing.T) {
+		content := `kind: Task
+---
+kind:
+    - Task
+`
+		documents, err := DocumentsFromContent(content)
+		assert.NoError(t, err)
+
+		var manifest struct {
+			Kind string `yaml:"kind"`
+		}
+
+		err = documents[1].Decode(&manifest)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "document 2 (line 3)")
+		assert.Contains(t, err.Error(), "line 4")
+	})
+
+	t.Run("should fail when there are no documents", func(t *testing.T) {
+		_, err := DocumentsFromContent("---\n")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,193 @@
+package yamlparser
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Problem is a problem reported by the yaml parser, at a given line.
+type Problem struct {
+	Line    int
+	Message string
+}
+
+var problemLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// Problems extracts the problems (and their lines) out of an error returned by the
+// yaml parser. If the error carries no line, a single problem with line 0 is returned.
+func Problems(err error) []Problem {
+	if err == nil {
+		return nil
+	}
+
+	var messages []string
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{rootCause(err).Error()}
+	}
+
+	var problems []Problem
+	for _, message := range messages {
+		problem := Problem{Message: message}
+
+		if match := problemLineRegex.FindStringSubmatch(message); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = match[2]
+		}
+
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+func rootCause(err error) error {
+	for {
+		unwrapped := errors.Unwrap(err)
+		if unwrapped == nil {
+			return err
+		}
+
+		err = unwrapped
+	}
+}
+
+// Lookup returns the node at the given path of the document. The path is a
+// dot-separated list of keys, where the items of a sequence are referenced by
+// their index. E.g.: 'spec.tasks[0].workdir'. If the path doesn't exist, the
+// deepest node that exists is returned, along with false.
+func (d *Document) Lookup(path string) (*yaml.Node, bool) {
+	node := d.Node
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if path == "" {
+		return node, true
+	}
+
+	for _, segment := range pathSegments(path) {
+		next := child(node, segment)
+		if next == nil {
+			return node, false
+		}
+
+		node = next
+	}
+
+	return node, true
+}
+
+// pathSegments splits a path like 'spec.tasks[0].workdir' into 'spec', 'tasks', '0' and 'workdir'.
+func pathSegments(path string) []string {
+	var segments []string
+	for _, key := range strings.Split(path, ".") {
+		name, indexes, _ := strings.Cut(key, "[")
+		if name != "" {
+			segments = append(segments, name)
+		}
+
+		if indexes == "" {
+			continue
+		}
+
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			segments = append(segments, "["+index)
+		}
+	}
+
+	return segments
+}
+
+func child(node *yaml.Node, segment string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if !strings.HasPrefix(segment, "[") {
+			return nil
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(segment, "["))
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return nil
+		}
+
+		return node.Content[index]
+	}
+
+	return nil
+}
+
+// Match is a node that matches a path pattern, along with its concrete path.
+type Match struct {
+	Path string
+	Node *yaml.Node
+}
+
+// LookupAll returns every node that matches the given path pattern, where '[*]'
+// matches every item of a sequence. E.g.: 'spec.jobs[*].tasks[*]'.
+func (d *Document) LookupAll(pattern string) []Match {
+	root, _ := d.Lookup("")
+	matches := []Match{{Node: root}}
+
+	for _, segment := range pathSegments(pattern) {
+		var next []Match
+		for _, match := range matches {
+			if segment == "[*" {
+				if match.Node.Kind != yaml.SequenceNode {
+					continue
+				}
+
+				for index, item := range match.Node.Content {
+					next = append(next, Match{Path: fmt.Sprintf("%s[%d]", match.Path, index), Node: item})
+				}
+
+				continue
+			}
+
+			if node := child(match.Node, segment); node != nil {
+				path := segment
+				if strings.HasPrefix(segment, "[") {
+					path = match.Path + segment + "]"
+				} else if match.Path != "" {
+					path = match.Path + "." + segment
+				}
+
+				next = append(next, Match{Path: path, Node: node})
+			}
+		}
+
+		matches = next
+	}
+
+	return matches
+}
+
+// LookupKey returns the key node of the given field, in the mapping at the given
+// path. It returns nil if the path isn't a mapping, or if the field isn't declared.
+func (d *Document) LookupKey(path, field string) *yaml.Node {
+	node, found := d.Lookup(path)
+	if !found || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == field {
+			return node.Content[i]
+		}
+	}
+
+	return nil
+}
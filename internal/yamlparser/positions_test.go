@@ -0,0 +1,89 @@
+package yamlparser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	documents, err := DocumentsFromContent(`---
+kind: Job
+spec:
+    tasks:
+        - name: first
+        - name: second
+          workdir: src
+`)
+
+	assert.NoError(t, err)
+
+	t.Run("should return the node at the given path", func(t *testing.T) {
+		node, found := documents[0].Lookup("spec.tasks[1].workdir")
+
+		assert.True(t, found)
+		assert.Equal(t, "src", node.Value)
+		assert.Equal(t, 7, node.Line)
+		assert.Equal(t, 20, node.Column)
+	})
+
+	t.Run("should return the deepest node found when the path doesn't exist", func(t *testing.T) {
+		node, found := documents[0].Lookup("spec.tasks[0].workdir")
+
+		assert.False(t, found)
+		assert.Equal(t, 5, node.Line)
+		assert.Equal(t, 11, node.Column)
+	})
+}
+
+func TestProblems(t *testing.T) {
+	t.Run("should return one problem per type error, with its line", func(t *testing.T) {
+		documents, err := DocumentsFromContent("name: first\nenabled: nope\ncount: many\n")
+		assert.NoError(t, err)
+
+		var spec struct {
+			Enabled bool `yaml:"enabled"`
+			Count   int  `yaml:"count"`
+		}
+
+		problems := Problems(documents[0].Decode(&spec))
+
+		assert.Len(t, problems, 2)
+		assert.Equal(t, 2, problems[0].Line)
+		assert.Equal(t, 3, problems[1].Line)
+	})
+
+	t.Run("should return the line of a syntax error", func(t *testing.T) {
+		_, err := DocumentsFromContent("name: first\nkind: Task\n  spec: {}\n")
+
+		problems := Problems(err)
+
+		assert.Len(t, problems, 1)
+		assert.Equal(t, 3, problems[0].Line)
+	})
+}
+
+func TestLookupAll(t *testing.T) {
+	documents, err := DocumentsFromContent(`---
+spec:
+    jobs:
+        - tasks:
+              - name: first
+              - name: second
+        - tasks:
+              - name: third
+`)
+
+	assert.NoError(t, err)
+
+	t.Run("should return every node that matches the pattern, with its path", func(t *testing.T) {
+		matches := documents[0].LookupAll("spec.jobs[*].tasks[*].name")
+
+		assert.Len(t, matches, 3)
+		assert.Equal(t, "spec.jobs[0].tasks[1].name", matches[1].Path)
+		assert.Equal(t, "third", matches[2].Node.Value)
+	})
+
+	t.Run("should return nothing when the pattern doesn't match", func(t *testing.T) {
+		assert.Empty(t, documents[0].LookupAll("spec.tasks[*]"))
+	})
+}
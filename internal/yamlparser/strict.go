@@ -0,0 +1,218 @@
+package yamlparser
+
+import (
+	"fmt"
+	"github.com/excoriate/stiletto/internal/jsonschema"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+// UnknownField is a field of a document that its schema doesn't declare. E.g.: a
+// typo, such as 'RemoveEnvVarsIfFound' instead of 'removeEnvVarsIfFound'.
+type UnknownField struct {
+	// Path is the path of the field. E.g.: 'spec.envVarsSpec.RemoveEnvVarsIfFound'.
+	Path   string
+	Name   string
+	Line   int
+	Column int
+	// Suggestion is the declared field closest to the unknown one, if any is close enough.
+	Suggestion string
+	// File is the file the field was merged in from (see Document.MergeSource), if it
+	// doesn't come from the document's file.
+	File string
+}
+
+// UnknownFields returns the fields of the document that the schema doesn't declare,
+// in the order they're found. It's the equivalent of decoding the document with the
+// 'KnownFields' option of the yaml decoder, but every unknown field is reported
+// (with its position), instead of only the first one. The fields pulled in through an
+// alias, or a merge key ('<<: *base'), are checked against the schema of where they're
+// pulled into.
+func (d *Document) UnknownFields(schema *jsonschema.Schema) []UnknownField {
+	root, _ := d.Lookup("")
+
+	collector := &unknownFieldsCollector{document: d, reported: map[*yaml.Node]bool{}}
+	collector.collect(root, schema, "")
+
+	return collector.unknownFields
+}
+
+// unknownFieldsCollector walks the nodes of a document along with their schema. As
+// an anchored node can be pulled in several times, each one of its unknown fields is
+// only reported the first time.
+type unknownFieldsCollector struct {
+	document      *Document
+	unknownFields []UnknownField
+	reported      map[*yaml.Node]bool
+}
+
+func (c *unknownFieldsCollector) collect(node *yaml.Node, schema *jsonschema.Schema, path string) {
+	if node == nil || schema == nil {
+		return
+	}
+
+	if node.Kind == yaml.AliasNode {
+		c.collect(node.Alias, schema, path)
+		return
+	}
+
+	if len(schema.OneOf) != 0 {
+		c.collect(node, schemaForNode(node, schema.OneOf), path)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for idx, item := range node.Content {
+			c.collect(item, schema.Items, fmt.Sprintf("%s[%d]", path, idx))
+		}
+	case yaml.MappingNode:
+		additionalProperties, _ := schema.AdditionalProperties.(*jsonschema.Schema)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			// The mappings merged in (E.g.: '<<: *base', or '<<: [*base, *other]') are
+			// checked as if their fields were declared in this one.
+			if key.ShortTag() == mergeTag {
+				for _, merged := range mergedMappings(value) {
+					c.collect(merged, schema, path)
+				}
+
+				continue
+			}
+
+			fieldPath := key.Value
+			if path != "" {
+				fieldPath = path + "." + key.Value
+			}
+
+			if property, ok := schema.Properties[key.Value]; ok {
+				c.collect(value, property, fieldPath)
+				continue
+			}
+
+			if additionalProperties != nil {
+				c.collect(value, additionalProperties, fieldPath)
+				continue
+			}
+
+			// Only the closed objects (E.g.: the ones reflected out of structs) reject
+			// the fields they don't declare.
+			if allowed, ok := schema.AdditionalProperties.(bool); !ok || allowed {
+				continue
+			}
+
+			if c.reported[key] {
+				continue
+			}
+
+			c.reported[key] = true
+
+			var declared []string
+			for name := range schema.Properties {
+				declared = append(declared, name)
+			}
+
+			file, _ := c.document.Source(key)
+
+			c.unknownFields = append(c.unknownFields, UnknownField{
+				Path:       fieldPath,
+				Name:       key.Value,
+				Line:       key.Line,
+				Column:     key.Column,
+				Suggestion: Suggest(key.Value, declared),
+				File:       file,
+			})
+		}
+	}
+}
+
+// mergeTag is the tag of the merge key ('<<') of a mapping.
+const mergeTag = "!!merge"
+
+// mergedMappings returns the nodes merged in by the value of a merge key, which is
+// either a single node (usually an alias), or a sequence of them.
+func mergedMappings(value *yaml.Node) []*yaml.Node {
+	if value.Kind == yaml.SequenceNode {
+		return value.Content
+	}
+
+	return []*yaml.Node{value}
+}
+
+// schemaForNode returns the first schema, out of the alternatives of a 'oneOf', whose
+// type matches the kind of the node.
+func schemaForNode(node *yaml.Node, alternatives []*jsonschema.Schema) *jsonschema.Schema {
+	nodeType := map[yaml.Kind]string{yaml.MappingNode: "object", yaml.SequenceNode: "array"}[node.Kind]
+
+	for _, alternative := range alternatives {
+		if alternative.Type == nodeType {
+			return alternative
+		}
+	}
+
+	return nil
+}
+
+// Suggest returns the candidate closest to the name, by edit distance (ignoring the
+// case), if it's close enough to be a typo of it. Otherwise, it returns an empty string.
+func Suggest(name string, candidates []string) string {
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted)
+
+	maxDistance := len(name) / 3
+	if maxDistance == 0 {
+		maxDistance = 1
+	}
+
+	suggestion, bestDistance := "", maxDistance+1
+	for _, candidate := range sorted {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if distance < bestDistance {
+			suggestion, bestDistance = candidate, distance
+		}
+	}
+
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between both strings.
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+
+	previous := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current := make([]int, len(target)+1)
+		current[0] = i
+
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous = current
+	}
+
+	return previous[len(target)]
+}
+
+func minInt(values ...int) int {
+	minimum := values[0]
+	for _, value := range values[1:] {
+		if value < minimum {
+			minimum = value
+		}
+	}
+
+	return minimum
+}
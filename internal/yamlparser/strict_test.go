@@ -0,0 +1,106 @@
+package yamlparser
+
+import (
+	"github.com/excoriate/stiletto/internal/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type strictCommand struct {
+	Binary   string   `yaml:"binary"`
+	Commands []string `yaml:"commands"`
+}
+
+type strictSpec struct {
+	WorkDir      string            `yaml:"workDir"`
+	EnvVars      map[string]string `yaml:"envVars"`
+	CommandsSpec []strictCommand   `yaml:"commandsSpec"`
+}
+
+func TestUnknownFields(t *testing.T) {
+	schema := jsonschema.Reflect(struct {
+		Spec strictSpec `yaml:"spec"`
+	}{})
+
+	t.Run("should report every unknown field, with its position and a suggestion", func(t *testing.T) {
+		documents, err := DocumentsFromContent(`---
+spec:
+    WorkDir: src
+    envVars:
+        ANY_NAME: value
+    commandsSpec:
+        - binary: cargo
+          comands: [build]
+    timeout: 5m
+`)
+
+		assert.NoError(t, err)
+
+		unknownFields := documents[0].UnknownFields(schema)
+
+		assert.Equal(t, []UnknownField{
+			{Path: "spec.WorkDir", Name: "WorkDir", Line: 3, Column: 5, Suggestion: "workDir"},
+			{Path: "spec.commandsSpec[0].comands", Name: "comands", Line: 8, Column: 11, Suggestion: "commands"},
+			{Path: "spec.timeout", Name: "timeout", Line: 9, Column: 5},
+		}, unknownFields)
+	})
+
+	t.Run("should check the fields pulled in through anchors and merges", func(t *testing.T) {
+		documents, err := DocumentsFromContent(`---
+spec:
+    commandsSpec:
+        - &base
+          binary: cargo
+          comands: [build]
+        - <<: *base
+          commands: [test]
+        - <<: [*base]
+          binaryy: cargo
+        - *base
+`)
+
+		assert.NoError(t, err)
+
+		unknownFields := documents[0].UnknownFields(schema)
+
+		assert.Equal(t, []UnknownField{
+			{Path: "spec.commandsSpec[0].comands", Name: "comands", Line: 6, Column: 11, Suggestion: "commands"},
+			{Path: "spec.commandsSpec[2].binaryy", Name: "binaryy", Line: 10, Column: 11, Suggestion: "binary"},
+		}, unknownFields)
+	})
+
+	t.Run("should accept the merge keys of a document without unknown fields", func(t *testing.T) {
+		documents, err := DocumentsFromContent(`---
+spec:
+    commandsSpec:
+        - &base
+          binary: cargo
+          commands: [build]
+        - <<: *base
+          commands: [test]
+`)
+
+		assert.NoError(t, err)
+		assert.Empty(t, documents[0].UnknownFields(schema))
+	})
+
+	t.Run("should accept a document without unknown fields", func(t *testing.T) {
+		documents, err := DocumentsFromContent("spec:\n    workDir: src\n")
+
+		assert.NoError(t, err)
+		assert.Empty(t, documents[0].UnknownFields(schema))
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	t.Run("should suggest the closest candidate, ignoring the case", func(t *testing.T) {
+		candidates := []string{"removeEnvVarsIfFound", "requiredEnvVars", "envVars"}
+
+		assert.Equal(t, "removeEnvVarsIfFound", Suggest("RemoveEnvVarsIfFound", candidates))
+		assert.Equal(t, "requiredEnvVars", Suggest("requiredEnvVar", candidates))
+	})
+
+	t.Run("should not suggest a candidate that's too far", func(t *testing.T) {
+		assert.Equal(t, "", Suggest("image", []string{"containerImage", "mountDir"}))
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/excoriate/stiletto/internal/tui"
 	"github.com/excoriate/stiletto/internal/utils"
 	"go.uber.org/zap"
+	"time"
 )
 
 // Builder InstanceClient is the client for the pipeline instance.
@@ -65,7 +66,7 @@ func (b *Builder) WithHost() *Builder {
 	}
 
 	if b.dirCfg == nil {
-		b.dirCfg = entities.GetDirCfg()
+		b.dirCfg = entities.GetDirCfg(b.logger)
 	}
 
 	hostCfg := &entities.HostConfig{
@@ -107,6 +108,7 @@ func (b *Builder) Build() (*entities.Client, error) {
 
 	return &entities.Client{
 		Id:        utils.GetUUID(),
+		StartedAt: time.Now(),
 		CfgCore:   b.clientCfg,
 		CfgCLI:    b.cliCfg,
 		CfgAPI:    b.apiCfg,
@@ -126,7 +128,7 @@ func NewClient(clientType string) *Builder {
 
 	envVarsFromHost, _ := utils.FetchAllEnvVarsFromHost()
 
-	dirCfg := entities.GetDirCfg()
+	dirCfg := entities.GetDirCfg(logger)
 
 	coreCfg := &entities.ClientConfig{
 		Classification: utils.NormaliseStringUpper(clientType),